@@ -1,8 +1,48 @@
 namespace go com.game.trade.addrtx
 
+enum ErrorCode{
+    UNSUPPORTED_COIN = 1,
+    INVALID_UID = 2,
+    INVALID_ARGUMENT = 3,
+    DERIVATION_FAILED = 4,
+    UPSTREAM_UNAVAILABLE = 5,
+    INSUFFICIENT_FUNDS = 6,
+    INTERNAL_ERROR = 7,
+    ADDRESS_NOT_FOUND = 8,
+}
+
+//how GetTX prices ETH gas, the server's configured strategy is used when unset
+enum FeeStrategy{
+    NODE_SUGGESTED = 1,
+    FIXED = 2,
+    PERCENTILE = 3,
+}
+
+//how GetTX picks the BTC utxos to spend, the server's configured one is used
+//when unset
+enum CoinSelection{
+    BRANCH_AND_BOUND = 1,
+    LARGEST_FIRST = 2,
+    OLDEST_FIRST = 3,
+    KNAPSACK = 4,
+}
+
+//the kind of BTC address, each derives from its own account key: P2PKH 1...
+//along BIP-44, P2WPKH bc1q... along BIP-84, P2SH_P2WPKH 3... along BIP-49 and
+//P2TR bc1p... along BIP-86. The server's configured type is used when unset
+enum BTCAddrType{
+    P2PKH = 1,
+    P2WPKH = 2,
+    P2SH_P2WPKH = 3,
+    P2TR = 4,
+}
+
+//uid is in [0, 2^62), uid < 2^31 derives account/uid and larger uids derive
+//account/(uid >> 31)/(uid & (2^31 - 1)), anything else is INVALID_UID
 struct GetAddrMsg{
     1: required string coinType;
     2: required i64 uid;
+    3: optional BTCAddrType addrType;
 }
 struct GetTXMsg{
     1: required string coinType;
@@ -10,9 +50,59 @@ struct GetTXMsg{
     3: required i64 fromAmount;
     4: required i64 toUID;
     5: required i64 toAmount;
+    //ETH only, fees are in wei. maxFeePerGas is the gas price of legacy txs
+    //and both are used by FIXED. feePercentile in [0, 100] is used by PERCENTILE.
+    //EIP-1559 dynamic fee txs are built unless legacyTX is set
+    6: optional FeeStrategy feeStrategy;
+    7: optional i64 maxFeePerGas;
+    8: optional i64 maxPriorityFeePerGas;
+    9: optional i32 feePercentile;
+    10: optional bool legacyTX;
+    //ETH only, symbol of a configured ERC-20 token, amounts are then in the
+    //token's smallest unit and the tx calls transfer on the token contract
+    11: optional string token;
+    //BTC only, feeRate is in satoshi per virtual byte and estimated by the
+    //backend when unset
+    12: optional CoinSelection coinSelection;
+    13: optional i64 feeRate;
+    //BTC only, the address types of the sender, which also receives the
+    //change, and of the recipient
+    14: optional BTCAddrType fromAddrType;
+    15: optional BTCAddrType toAddrType;
+}
+//either uidList or the range [startUID, startUID+count) is set
+struct GetAddrBatchMsg{
+    1: required string coinType;
+    2: optional list<i64> uidList;
+    3: optional i64 startUID;
+    4: optional i32 count;
+    5: optional BTCAddrType addrType;
+}
+
+struct GetUIDByAddrMsg{
+    1: required string coinType;
+    2: required string addr;
+}
+
+//psbts are the base64 PSBTs of a tx returned by GetTX, signed by the signers
+//of some of its inputs each
+struct FinalizeTXMsg{
+    1: required string coinType;
+    2: required list<string> psbts;
+}
+
+exception AddrTXException{
+    1: ErrorCode code;
+    2: string message;
 }
 
 service AddrTXService{
-    string GetAddr(1: GetAddrMsg msg);
-    string GetTX(1: GetTXMsg msg);
+    string GetAddr(1: GetAddrMsg msg) throws (1: AddrTXException err);
+    //BTC txs are returned as base64 PSBTs, ETH ones as hex encoded json
+    string GetTX(1: GetTXMsg msg) throws (1: AddrTXException err);
+    map<i64, string> GetAddrBatch(1: GetAddrBatchMsg msg) throws (1: AddrTXException err);
+    i64 GetUIDByAddr(1: GetUIDByAddrMsg msg) throws (1: AddrTXException err);
+    //combines and finalizes the signed PSBTs, broadcasts the tx and returns
+    //its txid
+    string FinalizeTX(1: FinalizeTXMsg msg) throws (1: AddrTXException err);
 }
\ No newline at end of file
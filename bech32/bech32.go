@@ -0,0 +1,227 @@
+//Package bech32 implements the bech32 (BIP-173) and bech32m (BIP-350)
+//encodings and segwit addresses.
+package bech32
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//Encoding is the checksum variant of a bech32 string.
+type Encoding int
+
+//checksum variants, segwit v0 addresses use Bech32 and later versions Bech32m
+const (
+	Bech32 Encoding = iota + 1
+	Bech32m
+)
+
+const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+//maxLength is the longest bech32 string BIP-173 allows
+const maxLength = 90
+
+var charsetRev [128]int8
+
+func init() {
+	for i := range charsetRev {
+		charsetRev[i] = -1
+	}
+	for i, c := range charset {
+		charsetRev[c] = int8(i)
+	}
+}
+
+func (e Encoding) constant() uint32 {
+	if e == Bech32m {
+		return 0x2bc830a3
+	}
+	return 1
+}
+
+func polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func hrpExpand(hrp string) []byte {
+	ret := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		ret = append(ret, hrp[i]>>5)
+	}
+	ret = append(ret, 0)
+	for i := 0; i < len(hrp); i++ {
+		ret = append(ret, hrp[i]&31)
+	}
+	return ret
+}
+
+func createChecksum(hrp string, data []byte, enc Encoding) []byte {
+	values := append(hrpExpand(hrp), data...)
+	values = append(values, 0, 0, 0, 0, 0, 0)
+	mod := polymod(values) ^ enc.constant()
+	ret := make([]byte, 6)
+	for i := range ret {
+		ret[i] = byte(mod>>uint(5*(5-i))) & 31
+	}
+	return ret
+}
+
+//Encode encodes data, 5 bit values, with the human readable part hrp.
+func Encode(hrp string, data []byte, enc Encoding) (string, error) {
+	if len(hrp) < 1 || len(hrp)+1+len(data)+6 > maxLength {
+		return "", fmt.Errorf("invalid bech32 length")
+	}
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", fmt.Errorf("invalid hrp character %q", hrp[i])
+		}
+	}
+	hrp = strings.ToLower(hrp)
+	var b strings.Builder
+	b.WriteString(hrp)
+	b.WriteByte('1')
+	for _, d := range append(data, createChecksum(hrp, data, enc)...) {
+		if d > 31 {
+			return "", fmt.Errorf("invalid data value %d", d)
+		}
+		b.WriteByte(charset[d])
+	}
+	return b.String(), nil
+}
+
+//Decode decodes a bech32 or bech32m string into its lower case human readable
+//part and 5 bit data without checksum.
+func Decode(s string) (string, []byte, Encoding, error) {
+	if len(s) > maxLength {
+		return "", nil, 0, errors.New("bech32 string too long")
+	}
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, 0, errors.New("bech32 string of mixed case")
+	}
+	s = strings.ToLower(s)
+	pos := strings.LastIndexByte(s, '1')
+	if pos < 1 || pos+7 > len(s) {
+		return "", nil, 0, errors.New("bech32 separator misplaced")
+	}
+	hrp := s[:pos]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, 0, fmt.Errorf("invalid hrp character %q", hrp[i])
+		}
+	}
+	data := make([]byte, 0, len(s)-pos-1)
+	for i := pos + 1; i < len(s); i++ {
+		c := s[i]
+		if c >= 128 || charsetRev[c] < 0 {
+			return "", nil, 0, fmt.Errorf("invalid bech32 character %q", c)
+		}
+		data = append(data, byte(charsetRev[c]))
+	}
+	var enc Encoding
+	switch polymod(append(hrpExpand(hrp), data...)) {
+	case Bech32.constant():
+		enc = Bech32
+	case Bech32m.constant():
+		enc = Bech32m
+	default:
+		return "", nil, 0, errors.New("invalid bech32 checksum")
+	}
+	return hrp, data[:len(data)-6], enc, nil
+}
+
+//ConvertBits regroups data of fromBits bit values into toBits bit values,
+//padding the last group with zeros if pad is set.
+func ConvertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	var acc uint32
+	var bits uint
+	maxv := uint32(1)<<toBits - 1
+	ret := make([]byte, 0, len(data)*int(fromBits)/int(toBits)+1)
+	for _, v := range data {
+		if uint32(v)>>fromBits != 0 {
+			return nil, fmt.Errorf("invalid data value %d", v)
+		}
+		acc = acc<<fromBits | uint32(v)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			ret = append(ret, byte(acc>>bits&maxv))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			ret = append(ret, byte(acc<<(toBits-bits)&maxv))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxv != 0 {
+		return nil, errors.New("invalid padding")
+	}
+	return ret, nil
+}
+
+//EncodeSegwitAddress encodes the witness program of version with hrp, "bc"
+//for mainnet and "tb" for testnet.
+func EncodeSegwitAddress(hrp string, version byte, program []byte) (string, error) {
+	if err := checkProgram(version, program); err != nil {
+		return "", err
+	}
+	enc := Bech32
+	if version > 0 {
+		enc = Bech32m
+	}
+	data, err := ConvertBits(program, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	return Encode(hrp, append([]byte{version}, data...), enc)
+}
+
+//DecodeSegwitAddress returns the witness version and program of addr, which
+//must have the human readable part hrp.
+func DecodeSegwitAddress(hrp, addr string) (byte, []byte, error) {
+	gotHRP, data, enc, err := Decode(addr)
+	if err != nil {
+		return 0, nil, err
+	}
+	if gotHRP != hrp {
+		return 0, nil, fmt.Errorf("address hrp %s, expected %s", gotHRP, hrp)
+	}
+	if len(data) < 1 {
+		return 0, nil, errors.New("empty segwit address")
+	}
+	version := data[0]
+	if version == 0 && enc != Bech32 || version > 0 && enc != Bech32m {
+		return 0, nil, fmt.Errorf("witness version %d with wrong checksum variant", version)
+	}
+	program, err := ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := checkProgram(version, program); err != nil {
+		return 0, nil, err
+	}
+	return version, program, nil
+}
+
+func checkProgram(version byte, program []byte) error {
+	if version > 16 {
+		return fmt.Errorf("invalid witness version %d", version)
+	}
+	if len(program) < 2 || len(program) > 40 {
+		return fmt.Errorf("invalid witness program length %d", len(program))
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return fmt.Errorf("invalid witness v0 program length %d", len(program))
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package bech32
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+//test vectors from BIP-173 and BIP-350
+var validChecksums = map[Encoding][]string{
+	Bech32: {
+		"A12UEL5L",
+		"a12uel5l",
+		"an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs",
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+		"?1ezyfcl",
+	},
+	Bech32m: {
+		"A1LQFN3A",
+		"a1lqfn3a",
+		"an83characterlonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11sg7hg6",
+		"abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx",
+		"split1checkupstagehandshakeupstreamerranterredcaperredlc445v",
+		"?1v759aa",
+	},
+}
+
+var invalidStrings = []string{
+	"\x201nwldj5",
+	"\x7f1axkwrx",
+	"an84characterslonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1569pvx",
+	"pzry9x0s0muk",
+	"1pzry9x0s0muk",
+	"x1b4n0q5v",
+	"li1dgmt3",
+	"A1G7SGD8",
+	"10a06t8",
+	"1qzzfhee",
+	"M1VUXWEZ",
+	"1p2gdwpf",
+}
+
+var validAddresses = []struct {
+	addr   string
+	script string
+}{
+	{"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", "0014751e76e8199196d454941c45d1b3a323f1433bd6"},
+	{"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262"},
+	{"bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y", "5128751e76e8199196d454941c45d1b3a323f1433bd6751e76e8199196d454941c45d1b3a323f1433bd6"},
+	{"BC1SW50QGDZ25J", "6002751e"},
+	{"bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs", "5210751e76e8199196d454941c45d1b3a323"},
+	{"tb1qqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesrxh6hy", "0020000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433"},
+	{"tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c", "5120000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433"},
+	{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"},
+}
+
+var invalidAddresses = []string{
+	//bech32m checksum on v0, bech32 checksum on v1 and later
+	"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh",
+	"tb1q0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq24jc47",
+	"bc1p38j9r5y49hruaue7wxjce0updqjuyyx0kh56v8s25huc6995vvpql3jow4",
+	"BC130XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ7ZWS8R",
+	//program lengths
+	"bc1pw5dgrnzv",
+	"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v8n0nx0muaewav253zgeav",
+	"BC1QR508D6QEJXTDG4Y5R3ZARVARYV98GJ9P",
+	//mixed case, padding, empty program
+	"tb1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq47Zagq",
+	"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v07qwwzcrf",
+	"tb1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vpggkg4j",
+	"bc1gmk9yu",
+}
+
+func TestChecksum(t *testing.T) {
+	for enc, cases := range validChecksums {
+		for _, s := range cases {
+			hrp, data, got, err := Decode(s)
+			if err != nil {
+				t.Errorf("%s: %v", s, err)
+				continue
+			}
+			if got != enc {
+				t.Errorf("%s: encoding %d, expected %d", s, got, enc)
+			}
+			encoded, err := Encode(hrp, data, enc)
+			if err != nil || encoded != strings.ToLower(s) {
+				t.Errorf("%s: re-encoded %s %v", s, encoded, err)
+			}
+		}
+	}
+	for _, s := range invalidStrings {
+		if _, _, _, err := Decode(s); err == nil {
+			t.Errorf("%q decoded", s)
+		}
+	}
+}
+
+func TestSegwitAddress(t *testing.T) {
+	for _, c := range validAddresses {
+		hrp := strings.ToLower(c.addr[:2])
+		version, program, err := DecodeSegwitAddress(hrp, c.addr)
+		if err != nil {
+			t.Errorf("%s: %v", c.addr, err)
+			continue
+		}
+		script := []byte{version, byte(len(program))}
+		if version > 0 {
+			script[0] = 0x50 + version
+		}
+		if got := hex.EncodeToString(append(script, program...)); got != c.script {
+			t.Errorf("%s: script %s, expected %s", c.addr, got, c.script)
+		}
+		addr, err := EncodeSegwitAddress(hrp, version, program)
+		if err != nil || addr != strings.ToLower(c.addr) {
+			t.Errorf("%s: re-encoded %s %v", c.addr, addr, err)
+		}
+	}
+	for _, addr := range invalidAddresses {
+		if _, _, err := DecodeSegwitAddress("bc", addr); err == nil {
+			if _, _, err := DecodeSegwitAddress("tb", addr); err == nil {
+				t.Errorf("%s decoded", addr)
+			}
+		}
+	}
+	if _, _, err := DecodeSegwitAddress("tb", validAddresses[0].addr); err == nil {
+		t.Errorf("mainnet address decoded as testnet")
+	}
+}
@@ -0,0 +1,206 @@
+package btc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+//BitcoindService is a service using the JSON-RPC interface of bitcoind or btcd.
+type BitcoindService struct {
+	url        string
+	user       string
+	password   string
+	wallet     bool
+	httpClient *http.Client
+	id         uint64
+}
+
+//BitcoindError is an error returned by the node.
+type BitcoindError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *BitcoindError) Error() string {
+	return fmt.Sprintf("bitcoind error %d: %s", e.Code, e.Message)
+}
+
+//NewBitcoindService creates BitcoindService for the node at url. If wallet is
+//set, utxos are listed by the node's wallet with listunspent, which only
+//knows addresses imported into it, otherwise the utxo set is scanned with
+//scantxoutset.
+func NewBitcoindService(url, user, password string, wallet bool) *BitcoindService {
+	b := &BitcoindService{}
+	b.url = url
+	b.user = user
+	b.password = password
+	b.wallet = wallet
+	b.httpClient = &http.Client{Timeout: 2 * time.Minute}
+	return b
+}
+
+//GetServiceName return service name.
+func (b *BitcoindService) GetServiceName() string {
+	return "BitcoindService"
+}
+
+//call invokes method with params and decodes the result into result.
+func (b *BitcoindService) call(result interface{}, method string, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	req := map[string]interface{}{
+		"jsonrpc": "1.0",
+		"id":      atomic.AddUint64(&b.id, 1),
+		"method":  method,
+		"params":  params,
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequest("POST", b.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if b.user != "" {
+		httpReq.SetBasicAuth(b.user, b.password)
+	}
+	resp, err := b.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	//bitcoind answers rpc errors with status 500 and a json body
+	var r struct {
+		Result json.RawMessage `json:"result"`
+		Error  *BitcoindError  `json:"error"`
+	}
+	if err = json.Unmarshal(body, &r); err != nil {
+		return fmt.Errorf("bitcoind returns %s: %s", resp.Status, body)
+	}
+	if r.Error != nil {
+		return r.Error
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(r.Result, result)
+}
+
+//GetUTXO gets unspent transaction outputs of addr from the node.
+func (b *BitcoindService) GetUTXO(addr string, key *Key) (UTXOs, error) {
+	type unspent struct {
+		TXID          string      `json:"txid"`
+		Vout          uint32      `json:"vout"`
+		ScriptPubKey  string      `json:"scriptPubKey"`
+		Amount        json.Number `json:"amount"`
+		Confirmations uint64      `json:"confirmations"`
+		Height        uint64      `json:"height"`
+	}
+	var unspents []unspent
+	var tip uint64
+	if b.wallet {
+		if err := b.call(&unspents, "listunspent", 0, 9999999, []string{addr}); err != nil {
+			return nil, err
+		}
+	} else {
+		var scan struct {
+			Success  bool      `json:"success"`
+			Height   uint64    `json:"height"`
+			Unspents []unspent `json:"unspents"`
+		}
+		if err := b.call(&scan, "scantxoutset", "start", []string{"addr(" + addr + ")"}); err != nil {
+			return nil, err
+		}
+		if !scan.Success {
+			return nil, fmt.Errorf("scantxoutset of %s did not succeed", addr)
+		}
+		unspents, tip = scan.Unspents, scan.Height
+	}
+
+	utxos := make(UTXOs, 0, len(unspents))
+	for _, u := range unspents {
+		var err error
+		utxo := &UTXO{}
+		utxo.Addr = addr
+		if utxo.Hash, err = hex.DecodeString(u.TXID); err != nil {
+			return nil, err
+		}
+		utxo.Index = u.Vout
+		if utxo.Amount, err = parseAmount(u.Amount.String()); err != nil {
+			return nil, err
+		}
+		if utxo.Script, err = hex.DecodeString(u.ScriptPubKey); err != nil {
+			return nil, err
+		}
+		utxo.Age = u.Confirmations
+		//the utxo set only holds confirmed outputs and reports their height
+		if !b.wallet && tip >= u.Height {
+			utxo.Age = tip - u.Height + 1
+		}
+		utxo.Key = key
+		utxos = append(utxos, utxo)
+	}
+	return utxos, nil
+}
+
+//BlockHeight returns the height of the chain tip.
+func (b *BitcoindService) BlockHeight() (uint64, error) {
+	var height uint64
+	err := b.call(&height, "getblockcount")
+	return height, err
+}
+
+//EstimateFeeRate estimates the fee rate with estimatesmartfee.
+func (b *BitcoindService) EstimateFeeRate(blocks int) (uint64, error) {
+	var estimate struct {
+		FeeRate *float64 `json:"feerate"`
+		Errors  []string `json:"errors"`
+	}
+	if err := b.call(&estimate, "estimatesmartfee", blocks); err != nil {
+		return 0, err
+	}
+	if estimate.FeeRate == nil {
+		return 0, fmt.Errorf("no fee estimate for %d blocks: %v", blocks, estimate.Errors)
+	}
+	return feeRateOfBTCPerKB(*estimate.FeeRate), nil
+}
+
+//GetRawTX returns the raw tx of txid, with gettransaction from the wallet if
+//wallet is set since getrawtransaction needs -txindex for confirmed txs.
+func (b *BitcoindService) GetRawTX(txid []byte) ([]byte, error) {
+	var rawtx string
+	if b.wallet {
+		var tx struct {
+			Hex string `json:"hex"`
+		}
+		if err := b.call(&tx, "gettransaction", hex.EncodeToString(txid)); err != nil {
+			return nil, err
+		}
+		rawtx = tx.Hex
+	} else if err := b.call(&rawtx, "getrawtransaction", hex.EncodeToString(txid)); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(rawtx)
+}
+
+//SendTX sends a transaction with sendrawtransaction.
+func (b *BitcoindService) SendTX(data []byte) ([]byte, error) {
+	var txid string
+	if err := b.call(&txid, "sendrawtransaction", hex.EncodeToString(data)); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(txid)
+}
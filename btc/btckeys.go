@@ -150,6 +150,15 @@ func (priv *PrivateKey) Sign(hash []byte) ([]byte, error) {
 	return sig.Serialize(), nil
 }
 
+//Verify checks sig, a DER encoded ECDSA signature, is of hash by pub.
+func (pub *PublicKey) Verify(hash, sig []byte) bool {
+	signature, err := btcec.ParseDERSignature(sig, btcec.S256())
+	if err != nil {
+		return false
+	}
+	return signature.Verify(hash, pub.key)
+}
+
 //SignMessage sign using bitcoin sign struct
 func (key *Key) SignMessage(hash []byte) ([]byte, error) {
 	msg := make([]byte, 0)
@@ -207,6 +216,15 @@ func (pub *PublicKey) GetAddress() (string, []byte) {
 	return publicKeyEncoded, ripeHashedBytes
 }
 
+//Hash160 returns RIPEMD160(SHA256(b)), the hash of keys and scripts in
+//addresses.
+func Hash160(b []byte) []byte {
+	h := sha256.Sum256(b)
+	ripeHash := ripemd160.New()
+	ripeHash.Write(h[:])
+	return ripeHash.Sum(nil)
+}
+
 //IsTestnet returns true if addr is for testnet.
 func IsTestnet(addr string) (bool, error) {
 	bytes, _, err := base58check.Decode(addr)
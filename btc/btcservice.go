@@ -1,15 +1,9 @@
 package btc
 
 import (
-	"bytes"
-	"encoding/hex"
-	"encoding/json"
 	"errors"
-	"io/ioutil"
-	"math/rand"
-	"net/http"
-	"net/url"
-	"strconv"
+	"math"
+	"strings"
 )
 
 //UTXO represents unspent transaction outputs.
@@ -26,23 +20,43 @@ type UTXO struct {
 //UTXOs is for sorting UTXO
 type UTXOs []*UTXO
 
-var cacheUTXO = make(map[string]UTXOs)
-
 //Service is for getting UTXO or sending transactions , basically by using WEB API.
+//GetUTXO returns the unspent outputs of an address with Hash in the byte order
+//txids are displayed in, SendTX broadcasts a raw tx and returns its txid.
 type Service interface {
 	GetServiceName() string
 	GetUTXO(string, *Key) (UTXOs, error)
 	SendTX([]byte) ([]byte, error)
 }
 
-//TestServices is an array containing generator of Services for testnet
-var TestServices = []func() (Service, error){
-	NewBlockrServiceForTest,
+//FeeEstimator is a service that estimates the fee rate, in satoshi per
+//virtual byte, of a tx to confirm within blocks.
+type FeeEstimator interface {
+	EstimateFeeRate(blocks int) (uint64, error)
+}
+
+//RawTXGetter is a service that returns the raw tx of a txid in display order,
+//the previous txs PSBT signers check the spent amounts with.
+type RawTXGetter interface {
+	GetRawTX(txid []byte) ([]byte, error)
 }
 
-//TestServices is an array containing generator of Services
-var Services = []func() (Service, error){
-	NewBlockrService,
+//MinFeeRate is the minimum relay fee rate in satoshi per virtual byte.
+const MinFeeRate = 1
+
+//MaxFeeRate is the highest fee rate in satoshi per virtual byte accepted, the
+//default maxfeerate of bitcoind's sendrawtransaction.
+const MaxFeeRate = 10000
+
+//feeRateOfBTCPerKB converts a rate in BTC per 1000 virtual bytes to satoshi
+//per virtual byte, rounded up to at least MinFeeRate.
+func feeRateOfBTCPerKB(rate float64) uint64 {
+	perKB := uint64(math.Floor(rate*BTC + 0.5))
+	satoshi := (perKB + 999) / 1000
+	if satoshi < MinFeeRate {
+		return MinFeeRate
+	}
+	return satoshi
 }
 
 //to sort UTXO
@@ -57,165 +71,75 @@ func (us UTXOs) Swap(i, j int) {
 	us[i], us[j] = us[j], us[i]
 }
 
-//Less returns true is age is smaller.
+//Less returns true if amount is smaller.
 func (us UTXOs) Less(i, j int) bool {
 	return us[i].Amount < us[j].Amount
 }
 
-//SelectService returns a service randomly.
-func SelectService(isTestnet bool) (Service, error) {
-	n := rand.Int() % len(Services)
-	if isTestnet {
-		return TestServices[n]()
-	}
-	return Services[n]()
-}
-
-//SetTXSpent sets  tx hash is already spent.
-func SetUTXOSpent(hash []byte) {
-	for k, v := range cacheUTXO {
-		for i, utxo := range v {
-			if bytes.Compare(hash, utxo.Hash) == 0 {
-				v = append(v[0:i], v[i+1:]...)
-				cacheUTXO[k] = v
-				return
-			}
-		}
-	}
-}
-
-type unspent struct {
-	Status string
-	Data   struct {
-		Address string
-		Unspent []struct {
-			Tx            string
-			Amount        string
-			N             int
-			Confirmations int
-			Script        string
-		}
-	}
-	Code    int
-	Message string
-}
-
-type sendtx struct {
-	Status  string
-	Data    string
-	Code    int
-	Message string
-}
-
-//BlockrService is a service using Blockr.io.
-type BlockrService struct {
-	isTestnet bool
-}
-
-//NewBlockrServiceForTest creates BlockrService struct for test.
-func NewBlockrServiceForTest() (Service, error) {
-	b := &BlockrService{isTestnet: true}
-	return b, nil
-}
-
-//NewBlockrService creates BlockrService struct for not test.
-func NewBlockrService() (Service, error) {
-	return &BlockrService{isTestnet: false}, nil
-}
-
-//GetServiceName return service name.
-func (b *BlockrService) GetServiceName() string {
-	return "BlockrService"
-}
-
-//SendTX send a transaction using Blockr.io.
-func (b *BlockrService) SendTX(data []byte) ([]byte, error) {
-	var btc string
-
-	if b.isTestnet {
-		btc = "tbtc"
-	} else {
-		btc = "btc"
-	}
-
-	resp, err := http.PostForm("http://"+btc+".blockr.io/api/v1/tx/push",
-		url.Values{"hex": {hex.EncodeToString(data)}})
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	logging.Println(string(body))
-	var u sendtx
-	err = json.Unmarshal(body, &u)
-	if err != nil {
-		return nil, err
-	}
-	if u.Status != "success" {
-		return nil, errors.New("blockr returns " + u.Message)
-
-	}
-	return hex.DecodeString(u.Data)
-}
-
-//GetUTXO gets unspent transaction outputs by using Blockr.io.
-func (b *BlockrService) GetUTXO(addr string, key *Key) (UTXOs, error) {
-	if cacheUTXO[addr] != nil {
-		return cacheUTXO[addr], nil
-	}
-	var btc string
-
-	if b.isTestnet {
-		btc = "tbtc"
-	} else {
-		btc = "btc"
-	}
-	//http://btc.blockr.io/api/v1/address/unspent/18BjFdiThEtu7D8hF3yURRPyPh9gNkRcBB
-	resp, err := http.Get("http://" + btc + ".blockr.io/api/v1/address/unspent/" + addr)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	var u unspent
-	err = json.Unmarshal(body, &u)
-	if err != nil {
-		return nil, err
-	}
-	if u.Status != "success" {
-		return nil, errors.New("blockr returns " + u.Message)
-	}
+//Backend names of the services, see NewService.
+const (
+	BackendBitcoind = "bitcoind"
+	BackendElectrum = "electrum"
+	BackendEsplora  = "esplora"
+)
 
-	utxos := make(UTXOs, 0, len(u.Data.Unspent))
-	for _, tx := range u.Data.Unspent {
-		utxo := UTXO{}
-		utxo.Addr = addr
-		amount, err := strconv.ParseFloat(tx.Amount, 64)
-		if err != nil {
-			return nil, err
+//ServiceConfig selects and configures a Service.
+type ServiceConfig struct {
+	//Backend is one of BackendBitcoind, BackendElectrum and BackendEsplora
+	Backend string
+	//URL is the JSON-RPC endpoint of bitcoind/btcd, the host:port of an
+	//electrum server or the base URL of an esplora API
+	URL string
+	//User and Password authenticate to bitcoind
+	User     string
+	Password string
+	//Wallet makes bitcoind list utxos of its wallet, where the addresses are
+	//imported watch-only, instead of scanning the utxo set
+	Wallet bool
+	//TLS connects to the electrum server over TLS
+	TLS bool
+}
+
+//NewService creates the service conf selects.
+func NewService(conf ServiceConfig) (Service, error) {
+	if conf.URL == "" {
+		return nil, errors.New("btc service url not set")
+	}
+	switch conf.Backend {
+	case BackendBitcoind:
+		return NewBitcoindService(conf.URL, conf.User, conf.Password, conf.Wallet), nil
+	case BackendElectrum:
+		return NewElectrumService(conf.URL, conf.TLS), nil
+	case BackendEsplora:
+		return NewEsploraService(conf.URL), nil
+	default:
+		return nil, errors.New("unknown btc backend " + conf.Backend)
+	}
+}
+
+//parseAmount converts a decimal BTC amount such as "0.00123" to satoshi
+//without going through a float.
+func parseAmount(amount string) (uint64, error) {
+	whole, frac := amount, ""
+	if i := strings.IndexByte(amount, '.'); i >= 0 {
+		whole, frac = amount[:i], amount[i+1:]
+	}
+	if whole == "" {
+		return 0, errors.New("invalid btc amount: " + amount)
+	}
+	if len(frac) > 8 {
+		return 0, errors.New("btc amount has more than 8 decimals: " + amount)
+	}
+	digits := whole + frac + strings.Repeat("0", 8-len(frac))
+	if len(digits) > 19 {
+		return 0, errors.New("invalid btc amount: " + amount)
+	}
+	var satoshi uint64
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, errors.New("invalid btc amount: " + amount)
 		}
-		utxo.Amount = uint64(amount * BTC)
-		utxo.Hash, err = hex.DecodeString(tx.Tx)
-		if err != nil {
-			return nil, err
-		}
-		utxo.Index = uint32(tx.N)
-		utxo.Script, err = hex.DecodeString(tx.Script)
-		if err != nil {
-			return nil, err
-		}
-		utxo.Age = uint64(tx.Confirmations)
-		utxo.Key = key
-		utxos = append(utxos, &utxo)
-	}
-	if key != nil {
-		cacheUTXO[addr] = utxos
+		satoshi = satoshi*10 + uint64(c-'0')
 	}
-	return utxos, nil
+	return satoshi, nil
 }
@@ -2,13 +2,14 @@ package btc
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 
 	base58check "github.com/GameLeLe/trade-addr-tx-service/base58check"
+	bech32 "github.com/GameLeLe/trade-addr-tx-service/bech32"
 )
 
 //TXin represents tx input of a transaction.
@@ -19,6 +20,16 @@ type TXin struct {
 	Sequence         uint32
 	PrevScriptPubkey []byte
 	CreateScriptSig  func(rawTransactionHashed []byte) ([]byte, error)
+
+	//Amount is the value of the spent output and RedeemScript the script a
+	//P2SH one hashes, the segwit signature hashes commit to them
+	Amount       uint64
+	RedeemScript []byte
+
+	//Witness is the witness stack of a segwit input, CreateWitness makes it
+	//from the signature hash of the input when set
+	Witness       [][]byte
+	CreateWitness func(sigHash []byte) ([][]byte, error)
 }
 
 //TXout represents tx output of a transaction.
@@ -33,6 +44,9 @@ type TX struct {
 	Txout      []*TXout
 	Locktime   uint32
 	CustomData []byte
+
+	//Version is the tx version, 1 if not set
+	Version uint32
 }
 
 func (tx *TX) check() error {
@@ -68,26 +82,38 @@ func (tx *TX) AttachCustomData(customData []byte) error {
 	return nil
 }
 
-//MakeTX makes transaction and return tx hex string(not send)
+//MakeTX makes transaction and return tx hex string(not send).
+//Each input is signed by CreateWitness if set, else by CreateScriptSig, with
+//the signature hash of the script it spends. The scriptSig of a P2SH-P2WPKH
+//input pushes its RedeemScript.
 func (tx *TX) MakeTX() ([]byte, error) {
 	var err error
 	if err = tx.check(); err != nil {
 		return nil, err
 	}
+	sigHashes, err := tx.SigHashes(SigHashDefault)
+	if err != nil {
+		return nil, err
+	}
 
 	for i, in := range tx.Txin {
-		rawTransactionHashed := tx.getRawTransactionHash(i)
-
-		if in.CreateScriptSig == nil {
-			return nil, errors.New("in.CreateScriptSig must be set")
+		switch {
+		case in.CreateWitness != nil:
+			in.Witness, err = in.CreateWitness(sigHashes[i])
+			if err == nil && in.isNestedWitness() {
+				in.scriptSig = pushData(in.RedeemScript)
+			}
+		case in.CreateScriptSig != nil:
+			in.scriptSig, err = in.CreateScriptSig(sigHashes[i])
+		default:
+			return nil, errors.New("in.CreateScriptSig or in.CreateWitness must be set")
 		}
-		in.scriptSig, err = in.CreateScriptSig(rawTransactionHashed[:])
 		if err != nil {
 			return nil, err
 		}
 	}
 	//Sign the raw transaction, and output it to the console.
-	finalTransaction := tx.createRawTransaction(-1)
+	finalTransaction := tx.createRawTransaction(true)
 	finalTransactionHex := hex.EncodeToString(finalTransaction)
 
 	fmt.Println("Your final transaction is")
@@ -96,42 +122,69 @@ func (tx *TX) MakeTX() ([]byte, error) {
 	return finalTransaction, nil
 }
 
-func (tx *TX) getTransactionHash() ([]byte, error) {
-	rawtx, err := tx.MakeTX()
-	if err != nil {
+//MakeUnsignedTX makes transaction without any scriptSig and returns the raw tx
+//bytes, so that it can be handed to an offline signer.
+func (tx *TX) MakeUnsignedTX() ([]byte, error) {
+	if err := tx.check(); err != nil {
 		return nil, err
 	}
-	hash := sha256.Sum256(rawtx)
-	h := sha256.Sum256(hash[:])
+	return tx.createRawTransaction(false), nil
+}
+
+//TXID returns the tx hash in display order, the double SHA256 of the tx
+//serialized without witness.
+func (tx *TX) TXID() []byte {
+	return hashTX(tx.createRawTransaction(false))
+}
+
+//WTXID returns the BIP-141 witness tx hash in display order, the same as TXID
+//for a tx without witness.
+func (tx *TX) WTXID() []byte {
+	return hashTX(tx.createRawTransaction(true))
+}
+
+func hashTX(rawtx []byte) []byte {
+	h := sha256Sum(sha256Sum(rawtx))
 	reversed := make([]byte, len(h))
 	for i, tb := range h {
 		reversed[len(h)-i-1] = tb
 	}
-	return reversed, nil
+	return reversed
+}
+
+func (tx *TX) version() uint32 {
+	if tx.Version == 0 {
+		return 1
+	}
+	return tx.Version
 }
 
-func (tx *TX) getRawTransactionHash(numSign int) []byte {
-	rawTransaction := tx.createRawTransaction(numSign)
-	//After completing the raw transaction, we append
-	//SIGHASH_ALL in little-endian format to the end of the raw transaction.
-	hashCodeType := []byte{0x01, 0x00, 0x00, 0x00}
+func (tx *TX) hasWitness() bool {
+	for _, in := range tx.Txin {
+		if len(in.Witness) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+//isNestedWitness reports whether in spends a witness program nested in P2SH.
+func (in *TXin) isNestedWitness() bool {
+	if GetScriptType(in.PrevScriptPubkey) != ScriptP2SH {
+		return false
+	}
+	t := GetScriptType(in.RedeemScript)
+	return t == ScriptP2WPKH || t == ScriptP2WSH
+}
 
-	var rawTransactionBuffer bytes.Buffer
-	rawTransactionBuffer.Write(rawTransaction)
-	rawTransactionBuffer.Write(hashCodeType)
-	rawTransactionWithHashCodeType := rawTransactionBuffer.Bytes()
-	//Hash the raw transaction twice before the signing
-	hash := sha256.Sum256(rawTransactionWithHashCodeType)
-	h := sha256.Sum256(hash[:])
-	return h[:]
+//pushData returns the script pushing data, which is shorter than 76 bytes.
+func pushData(data []byte) []byte {
+	return append([]byte{byte(len(data))}, data...)
 }
 
 func addCustomData(buffer *bytes.Buffer, data []byte) {
 	//Add custom data
-	var script []byte
-	script = append(script, opRETURN)
-	script = append(script, byte(len(data)))
-	script = append(script, data...)
+	script := customDataScript(data)
 
 	satoshiBytes := make([]byte, 8)
 	binary.LittleEndian.PutUint64(satoshiBytes, 0)
@@ -142,22 +195,26 @@ func addCustomData(buffer *bytes.Buffer, data []byte) {
 }
 
 //createRawTransaction creates a transaction from tx struct.
-//if numSing>=0, this returns a transaction for singning, and
-//numSign is number of txin which will be singed later.
-//if numSing<0, returns a transaction for broadcast.
-func (tx *TX) createRawTransaction(numSign int) []byte {
+//if withWitness is set and any input has a witness, it is serialized by
+//BIP-144 with the marker, flag and witness of every input.
+func (tx *TX) createRawTransaction(withWitness bool) []byte {
 	//Create the raw transaction.
 	var buffer bytes.Buffer
 
 	//Version field
-	version := []byte{0x01, 0x00, 0x00, 0x00}
-	buffer.Write(version)
+	writeUint32(&buffer, tx.version())
+
+	withWitness = withWitness && tx.hasWitness()
+	if withWitness {
+		//marker and flag
+		buffer.Write([]byte{0x00, 0x01})
+	}
 
 	//# of inputs
 	inputs := toVI(uint64(len(tx.Txin)))
 	buffer.Write(inputs)
 
-	for nIn, in := range tx.Txin {
+	for _, in := range tx.Txin {
 		//Input transaction hash
 
 		//Convert input transaction hash to little-endian form
@@ -172,15 +229,7 @@ func (tx *TX) createRawTransaction(numSign int) []byte {
 		binary.LittleEndian.PutUint32(outputIndexBytes, in.Index)
 		buffer.Write(outputIndexBytes)
 
-		var script []byte
-		switch {
-		case nIn == numSign:
-			script = in.PrevScriptPubkey
-		case numSign >= 0:
-			script = []byte{}
-		default:
-			script = in.scriptSig
-		}
+		script := in.scriptSig
 		//Script sig length
 		scriptSigLength := len(script)
 		buffer.Write(toVI(uint64(scriptSigLength)))
@@ -219,6 +268,12 @@ func (tx *TX) createRawTransaction(numSign int) []byte {
 		buffer.Write(out.ScriptPubkey)
 	}
 
+	if withWitness {
+		for _, in := range tx.Txin {
+			writeWitness(&buffer, in.Witness)
+		}
+	}
+
 	//Lock time field
 	lockTimeField := make([]byte, 4)
 	binary.LittleEndian.PutUint32(lockTimeField, tx.Locktime)
@@ -251,6 +306,133 @@ func toVI(n uint64) []byte {
 	return b
 }
 
+//ParseTX parses a raw tx serialized with or without witness. The previous tx
+//hashes of the inputs are in display order as TXin.Hash is, OP_RETURN outputs
+//are kept in Txout.
+func ParseTX(rawtx []byte) (*TX, error) {
+	r := &txReader{b: rawtx}
+	tx := &TX{}
+	tx.Version = r.uint32()
+	withWitness := len(r.b) >= 2 && r.b[0] == 0x00 && r.b[1] == 0x01
+	if withWitness {
+		r.read(2)
+	}
+	nIn := r.count()
+	for i := uint64(0); i < nIn && r.err == nil; i++ {
+		in := &TXin{}
+		hash := r.read(32)
+		in.Hash = make([]byte, len(hash))
+		for j, b := range hash {
+			in.Hash[len(hash)-j-1] = b
+		}
+		in.Index = r.uint32()
+		in.scriptSig = r.readVarBytes()
+		in.Sequence = r.uint32()
+		tx.Txin = append(tx.Txin, in)
+	}
+	nOut := r.count()
+	for i := uint64(0); i < nOut && r.err == nil; i++ {
+		out := &TXout{}
+		out.Value = r.uint64()
+		out.ScriptPubkey = r.readVarBytes()
+		tx.Txout = append(tx.Txout, out)
+	}
+	if withWitness {
+		for _, in := range tx.Txin {
+			in.Witness = r.readWitness()
+		}
+	}
+	tx.Locktime = r.uint32()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(r.b) != 0 {
+		return nil, fmt.Errorf("%d bytes after tx", len(r.b))
+	}
+	return tx, nil
+}
+
+//txReader reads the fields of a serialized tx, the first error sticks and
+//makes later reads return zero values.
+type txReader struct {
+	b   []byte
+	err error
+}
+
+func (r *txReader) read(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.b) {
+		r.err = errors.New("unexpected end of tx")
+		return nil
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
+
+func (r *txReader) readVI() uint64 {
+	b := r.read(1)
+	if b == nil {
+		return 0
+	}
+	switch b[0] {
+	case 0xfd:
+		if b = r.read(2); b != nil {
+			return uint64(binary.LittleEndian.Uint16(b))
+		}
+	case 0xfe:
+		return uint64(r.uint32())
+	case 0xff:
+		return r.uint64()
+	default:
+		return uint64(b[0])
+	}
+	return 0
+}
+
+//count reads the number of items that follow, each at least a byte long.
+func (r *txReader) count() uint64 {
+	n := r.readVI()
+	if r.err == nil && n > uint64(len(r.b)) {
+		r.err = fmt.Errorf("count %d exceeds tx length", n)
+		return 0
+	}
+	return n
+}
+
+func (r *txReader) readVarBytes() []byte {
+	n := r.count()
+	if r.err != nil {
+		return nil
+	}
+	return append([]byte{}, r.read(int(n))...)
+}
+
+func (r *txReader) readWitness() [][]byte {
+	n := r.count()
+	var witness [][]byte
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		witness = append(witness, r.readVarBytes())
+	}
+	return witness
+}
+
+func (r *txReader) uint32() uint32 {
+	if b := r.read(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *txReader) uint64() uint64 {
+	if b := r.read(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
 //CreateP2PKHScriptPubkey ...
 func CreateP2PKHScriptPubkey(publicKeyBase58 string) ([]byte, error) {
 	publicKeyBytes, _, err := base58check.Decode(publicKeyBase58)
@@ -269,3 +451,87 @@ func CreateP2PKHScriptPubkey(publicKeyBase58 string) ([]byte, error) {
 	script := scriptPubKey.Bytes()
 	return script, nil
 }
+
+//CreateP2SHScriptPubkey returns the scriptPubkey paying to a base58 P2SH
+//address, the counterpart of CreateP2PKHScriptPubkey.
+func CreateP2SHScriptPubkey(scriptHashBase58 string) ([]byte, error) {
+	scriptHashBytes, _, err := base58check.Decode(scriptHashBase58)
+	if err != nil {
+		return nil, err
+	}
+	if len(scriptHashBytes) != 21 || (scriptHashBytes[0] != 0x05 && scriptHashBytes[0] != 0xc4) {
+		return nil, fmt.Errorf("%s is not a P2SH address", scriptHashBase58)
+	}
+	scriptHashBytes = scriptHashBytes[1:]
+
+	var scriptPubKey bytes.Buffer
+	scriptPubKey.WriteByte(opHASH160)
+	scriptPubKey.WriteByte(byte(len(scriptHashBytes))) //PUSH
+	scriptPubKey.Write(scriptHashBytes)
+	scriptPubKey.WriteByte(opEQUAL)
+	return scriptPubKey.Bytes(), nil
+}
+
+//P2WPKHRedeemScript returns the witness program of pubKeyHash, the redeem
+//script a P2SH-P2WPKH address hashes and its scriptSig pushes.
+func P2WPKHRedeemScript(pubKeyHash []byte) []byte {
+	return witnessScript(0, pubKeyHash)
+}
+
+//AddressScript returns the scriptPubkey paying to a base58 P2PKH or P2SH
+//address or a bech32 segwit address of mainnet or testnet.
+func AddressScript(addr string) ([]byte, error) {
+	if hrp := segwitHRP(addr); hrp != "" {
+		version, program, err := bech32.DecodeSegwitAddress(hrp, addr)
+		if err != nil {
+			return nil, err
+		}
+		return witnessScript(version, program), nil
+	}
+	decoded, _, err := base58check.Decode(addr)
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded) != 21 {
+		return nil, fmt.Errorf("invalid address %s", addr)
+	}
+	hash := decoded[1:]
+	switch decoded[0] {
+	case 0x00, 0x6f:
+		script := []byte{opDUP, opHASH160, byte(len(hash))}
+		script = append(script, hash...)
+		return append(script, opEQUALVERIFY, opCHECKSIG), nil
+	case 0x05, 0xc4:
+		script := []byte{opHASH160, byte(len(hash))}
+		script = append(script, hash...)
+		return append(script, opEQUAL), nil
+	default:
+		return nil, fmt.Errorf("unsupported address version of %s", addr)
+	}
+}
+
+//segwitHRP returns the human readable part of a mainnet or testnet segwit
+//address, "" for base58 addresses.
+func segwitHRP(addr string) string {
+	if len(addr) < 3 {
+		return ""
+	}
+	switch strings.ToLower(addr[:3]) {
+	case "bc1":
+		return "bc"
+	case "tb1":
+		return "tb"
+	}
+	return ""
+}
+
+//witnessScript returns the scriptPubkey of a witness program: the version as
+//OP_0 to OP_16 and the program pushed.
+func witnessScript(version byte, program []byte) []byte {
+	op := op0
+	if version > 0 {
+		op = op1 + version - 1
+	}
+	script := []byte{op, byte(len(program))}
+	return append(script, program...)
+}
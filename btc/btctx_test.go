@@ -0,0 +1,84 @@
+package btc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+//bip143SignedTX is the native P2WPKH example of BIP-143 signed
+const bip143SignedTX = "01000000000102fff7f7881a8099afa6940d42d1e7f6362bec38171ea3edf433541db4e4ad969f00000000494830450221008b9d1dc26ba6a9cb62127b02742fa9d754cd3bebf337f7a55d114c8e5cdd30be022040529b194ba3f9281a99f2b1c0a19c0489bc22ede944ccf4ecbab4cc618ef3ed01eeffffffef51e1b804cc89d182d279655c3aa89e815b1b309fe287d9b2b55d57b90ec68a0100000000ffffffff02202cb206000000001976a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac9093510d000000001976a9143bde42dbee7e4dbe6a21b2d50ce2f0167faa815988ac000247304402203609e17b84f6a7d30c80bfa610b5b4542f32a8a0d5447a12fb1366d7f01cc44a0220573a954c4518331561406f90300e8f3358f51928d43c212a8caed02de67eebee0121025476c2e83188368da1ff3e292e7acafcdb3566bb0ad253f62fc70f07aeee635711000000"
+
+func TestMakeTXWitness(t *testing.T) {
+	//signed native P2WPKH example of BIP-143, the first input is P2PK
+	tx := bip143P2WPKHTX()
+	scriptSig := mustDecodeHex("4830450221008b9d1dc26ba6a9cb62127b02742fa9d754cd3bebf337f7a55d114c8e5cdd30be022040529b194ba3f9281a99f2b1c0a19c0489bc22ede944ccf4ecbab4cc618ef3ed01")
+	witness := [][]byte{
+		mustDecodeHex("304402203609e17b84f6a7d30c80bfa610b5b4542f32a8a0d5447a12fb1366d7f01cc44a0220573a954c4518331561406f90300e8f3358f51928d43c212a8caed02de67eebee01"),
+		mustDecodeHex("025476c2e83188368da1ff3e292e7acafcdb3566bb0ad253f62fc70f07aeee6357"),
+	}
+	var signed [][]byte
+	tx.Txin[0].CreateScriptSig = func(sigHash []byte) ([]byte, error) {
+		signed = append(signed, sigHash)
+		return scriptSig, nil
+	}
+	tx.Txin[1].CreateWitness = func(sigHash []byte) ([][]byte, error) {
+		signed = append(signed, sigHash)
+		return witness, nil
+	}
+	txid, wtxid := tx.TXID(), tx.WTXID()
+	if !bytes.Equal(txid, wtxid) {
+		t.Errorf("wtxid %x of a tx without witness differs from txid %x", wtxid, txid)
+	}
+	rawtx, err := tx.MakeTX()
+	if err != nil {
+		t.Fatalf("make tx: %v", err)
+	}
+	if hex.EncodeToString(rawtx) != bip143SignedTX {
+		t.Errorf("unexpected signed tx %x", rawtx)
+	}
+	if len(signed) != 2 || hex.EncodeToString(signed[1]) != "c37af31116d1b27caf68aae9e3ac82f1477929014d5b917657d0eb49478cb670" {
+		t.Errorf("inputs signed with %x", signed)
+	}
+	//the scriptSig changes the txid, the witness only the wtxid
+	if newTXID := tx.TXID(); bytes.Equal(newTXID, txid) || bytes.Equal(newTXID, tx.WTXID()) {
+		t.Errorf("txid %x, wtxid %x", newTXID, tx.WTXID())
+	}
+	rawtx, _ = tx.MakeUnsignedTX()
+	if !bytes.Equal(hashTX(rawtx), tx.TXID()) {
+		t.Errorf("txid is not the hash of the tx without witness")
+	}
+	if !bytes.Equal(hashTX(tx.createRawTransaction(true)), tx.WTXID()) {
+		t.Errorf("wtxid is not the hash of the tx with witness")
+	}
+
+	//a P2SH-P2WPKH input pushes its redeem script
+	tx = &TX{
+		Txin: []*TXin{{
+			Hash:             mustDecodeHex("77541aeb3c4dac9260b68f74f44c973081a9d4cb2ebe8038b2d70faa201b6bdb"),
+			Index:            1,
+			Sequence:         0xfffffffe,
+			PrevScriptPubkey: mustDecodeHex("a9144733f37cf4db86fbc2efed2500b4f4e49f31202387"),
+			Amount:           1000000000,
+			RedeemScript:     mustDecodeHex("001479091972186c449eb1ded22b78e40d009bdf0089"),
+			CreateWitness: func(sigHash []byte) ([][]byte, error) {
+				return [][]byte{sigHash}, nil
+			},
+		}},
+		Txout:    []*TXout{{Value: 199996600, ScriptPubkey: mustDecodeHex("76a914a457b684d7f0d539a46a45bbc043f35b59d0d96388ac")}},
+		Locktime: 1170,
+		Version:  2,
+	}
+	rawtx, err = tx.MakeTX()
+	if err != nil || !bytes.HasPrefix(rawtx, mustDecodeHex("020000000001")) {
+		t.Fatalf("nested witness tx %x %v", rawtx, err)
+	}
+	if !bytes.Contains(rawtx, mustDecodeHex("0100000017"+"16001479091972186c449eb1ded22b78e40d009bdf0089"+"feffffff")) {
+		t.Errorf("scriptSig does not push the redeem script: %x", rawtx)
+	}
+
+	tx.Txin[0].CreateWitness = nil
+	if _, err := tx.MakeTX(); err == nil {
+		t.Errorf("tx made without signer")
+	}
+}
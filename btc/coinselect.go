@@ -0,0 +1,410 @@
+package btc
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+//TXFee is how a CoinSelector prices the tx it selects inputs for.
+type TXFee struct {
+	//Rate is in satoshi per virtual byte
+	Rate uint64
+	//Outputs are the scriptPubKeys of the outputs paying the target, one
+	//P2PKH output if empty
+	Outputs [][]byte
+	//Change is the scriptPubKey of the change output, P2PKH if nil
+	Change []byte
+}
+
+//p2pkhPlaceholder stands for scripts left unset in TXFee
+var p2pkhPlaceholder = append(append([]byte{opDUP, opHASH160, 20}, make([]byte, 20)...), opEQUALVERIFY, opCHECKSIG)
+
+func (f *TXFee) outputs() [][]byte {
+	if len(f.Outputs) == 0 {
+		return [][]byte{p2pkhPlaceholder}
+	}
+	return f.Outputs
+}
+
+func (f *TXFee) change() []byte {
+	if f.Change == nil {
+		return p2pkhPlaceholder
+	}
+	return f.Change
+}
+
+//total is the fee of a tx spending utxos, with change or not.
+func (f *TXFee) total(utxos UTXOs, change bool) uint64 {
+	prevScripts := make([][]byte, 0, len(utxos))
+	for _, u := range utxos {
+		prevScripts = append(prevScripts, u.Script)
+	}
+	scripts := f.outputs()
+	if change {
+		scripts = append(append([][]byte{}, scripts...), f.change())
+	}
+	return VSize(EstimateWeight(prevScripts, scripts)) * f.Rate
+}
+
+//pricing approximates the fee of a tx one input at a time while selecting,
+//rounding each part up so the selected inputs cover the fee of the tx.
+type pricing struct {
+	fee *TXFee
+	//base is the fee of a tx without inputs or change
+	base uint64
+	//change is the fee of the change output
+	change uint64
+}
+
+func newPricing(utxos UTXOs, fee *TXFee) *pricing {
+	p := &pricing{fee: fee}
+	weight := EstimateWeight(nil, fee.outputs())
+	for _, u := range utxos {
+		if isWitnessSpend(u.Script) {
+			weight += 2
+			break
+		}
+	}
+	p.base = VSize(weight) * fee.Rate
+	p.change = VSize(OutputWeight(fee.change())) * fee.Rate
+	return p
+}
+
+//input is the fee of spending u.
+func (p *pricing) input(u *UTXO) uint64 {
+	return VSize(InputWeight(u.Script)) * p.fee.Rate
+}
+
+//effectiveValue is what spending u adds after paying for its input, utxos
+//costing more than they are worth are never selected.
+func (p *pricing) effectiveValue(u *UTXO) (uint64, bool) {
+	fee := p.input(u)
+	if u.Amount <= fee {
+		return 0, false
+	}
+	return u.Amount - fee, true
+}
+
+//spendable returns the utxos worth spending.
+func (p *pricing) spendable(utxos UTXOs) UTXOs {
+	result := make(UTXOs, 0, len(utxos))
+	for _, u := range utxos {
+		if _, ok := p.effectiveValue(u); ok {
+			result = append(result, u)
+		}
+	}
+	return result
+}
+
+//Selection is the utxos a CoinSelector chose to pay a target.
+type Selection struct {
+	UTXOs UTXOs
+	//Fee is paid by the tx, change too small to be worth an output is
+	//added to it
+	Fee uint64
+	//Change is the value of the change output, 0 if there is none
+	Change uint64
+}
+
+//newSelection prices spending utxos to pay target, adding a change output
+//only if the change is above its dust threshold. It returns nil if utxos do
+//not cover target and the fee.
+func newSelection(utxos UTXOs, target uint64, fee *TXFee) *Selection {
+	var in uint64
+	for _, u := range utxos {
+		in += u.Amount
+	}
+	noChange := fee.total(utxos, false)
+	if in < target+noChange {
+		return nil
+	}
+	s := &Selection{UTXOs: utxos, Fee: in - target}
+	withChange := fee.total(utxos, true)
+	if in >= target+withChange+DustThreshold(fee.change()) {
+		s.Fee = withChange
+		s.Change = in - target - withChange
+	}
+	return s
+}
+
+//InsufficientFundsError is returned when the utxos can not pay the target
+//and its fee.
+type InsufficientFundsError struct {
+	Have uint64
+	Need uint64
+}
+
+func (e *InsufficientFundsError) Error() string {
+	return fmt.Sprintf("insufficient funds: have %d, need %d", e.Have, e.Need)
+}
+
+//insufficient returns the error of utxos not paying target.
+func insufficient(utxos UTXOs, target uint64, fee *TXFee) error {
+	var have uint64
+	for _, u := range utxos {
+		have += u.Amount
+	}
+	return &InsufficientFundsError{Have: have, Need: target + fee.total(utxos, false)}
+}
+
+//CoinSelector chooses the utxos a tx paying target spends, priced by fee.
+//utxos may be reordered.
+type CoinSelector interface {
+	Select(utxos UTXOs, target uint64, fee *TXFee) (*Selection, error)
+}
+
+//accumulate selects utxos in order until they pay target.
+func accumulate(utxos UTXOs, target uint64, fee *TXFee) (*Selection, error) {
+	utxos = newPricing(utxos, fee).spendable(utxos)
+	for i := range utxos {
+		if s := newSelection(utxos[:i+1], target, fee); s != nil {
+			return s, nil
+		}
+	}
+	return nil, insufficient(utxos, target, fee)
+}
+
+//LargestFirst spends the largest utxos first, it uses few inputs.
+type LargestFirst struct{}
+
+//Select implements CoinSelector.
+func (LargestFirst) Select(utxos UTXOs, target uint64, fee *TXFee) (*Selection, error) {
+	sort.Sort(sort.Reverse(utxos))
+	return accumulate(utxos, target, fee)
+}
+
+//OldestFirst spends the utxos with the most confirmations first, the largest
+//first among equally old ones.
+type OldestFirst struct{}
+
+//Select implements CoinSelector.
+func (OldestFirst) Select(utxos UTXOs, target uint64, fee *TXFee) (*Selection, error) {
+	sort.Slice(utxos, func(i, j int) bool {
+		if utxos[i].Age != utxos[j].Age {
+			return utxos[i].Age > utxos[j].Age
+		}
+		return utxos[i].Amount > utxos[j].Amount
+	})
+	return accumulate(utxos, target, fee)
+}
+
+//DefaultBnBTries bounds the search of BranchAndBound.
+const DefaultBnBTries = 100000
+
+//BranchAndBound searches for utxos paying target without a change output,
+//wasting at most the cost of creating and later spending change. If there is
+//none it uses Fallback, Knapsack if nil.
+type BranchAndBound struct {
+	//Tries bounds the nodes searched, DefaultBnBTries if 0
+	Tries    int
+	Fallback CoinSelector
+}
+
+//bnbSearch is the depth first search of a changeless selection.
+type bnbSearch struct {
+	values []uint64
+	//remaining is the sum of values[i:]
+	remaining []uint64
+	low       uint64
+	high      uint64
+	tries     int
+	selected  []bool
+	best      []bool
+	bestSum   uint64
+}
+
+func (s *bnbSearch) search(i int, sum uint64) {
+	if s.tries <= 0 || sum > s.high {
+		return
+	}
+	s.tries--
+	if sum >= s.low {
+		if s.best == nil || sum < s.bestSum {
+			s.best = append(s.best[:0], s.selected...)
+			s.bestSum = sum
+			if sum == s.low {
+				s.tries = 0
+			}
+		}
+		return
+	}
+	if i == len(s.values) || sum+s.remaining[i] < s.low {
+		return
+	}
+	s.selected[i] = true
+	s.search(i+1, sum+s.values[i])
+	s.selected[i] = false
+	s.search(i+1, sum)
+}
+
+//Select implements CoinSelector.
+func (b BranchAndBound) Select(utxos UTXOs, target uint64, fee *TXFee) (*Selection, error) {
+	p := newPricing(utxos, fee)
+	utxos = p.spendable(utxos)
+	sort.Slice(utxos, func(i, j int) bool {
+		return utxos[i].Amount > utxos[j].Amount
+	})
+	s := &bnbSearch{}
+	s.values = make([]uint64, len(utxos))
+	s.remaining = make([]uint64, len(utxos)+1)
+	for i, u := range utxos {
+		s.values[i], _ = p.effectiveValue(u)
+	}
+	for i := len(utxos) - 1; i >= 0; i-- {
+		s.remaining[i] = s.remaining[i+1] + s.values[i]
+	}
+	//change would cost its output now and its input later
+	s.low = target + p.base
+	s.high = s.low + p.change + VSize(InputWeight(fee.change()))*fee.Rate
+	s.tries = b.Tries
+	if s.tries == 0 {
+		s.tries = DefaultBnBTries
+	}
+	s.selected = make([]bool, len(utxos))
+	s.search(0, 0)
+	if s.best != nil {
+		var chosen UTXOs
+		for i, ok := range s.best {
+			if ok {
+				chosen = append(chosen, utxos[i])
+			}
+		}
+		if sel := newSelection(chosen, target, fee); sel != nil {
+			return sel, nil
+		}
+	}
+	fallback := b.Fallback
+	if fallback == nil {
+		fallback = Knapsack{}
+	}
+	return fallback.Select(utxos, target, fee)
+}
+
+//DefaultKnapsackIterations is how many random subsets Knapsack tries.
+const DefaultKnapsackIterations = 1000
+
+//Knapsack approximates the smallest subset of utxos paying target, preferring
+//an exact match or change above the dust limit, as bitcoind did before
+//branch and bound.
+type Knapsack struct {
+	//Iterations is DefaultKnapsackIterations if 0
+	Iterations int
+	//Rand is the source of the random subsets, math/rand if nil. A *rand.Rand
+	//must not be shared by concurrent Selects.
+	Rand *rand.Rand
+}
+
+func (k Knapsack) coin() bool {
+	if k.Rand != nil {
+		return k.Rand.Intn(2) == 0
+	}
+	return rand.Intn(2) == 0
+}
+
+//bestSubset returns the random subset of values with the smallest sum
+//reaching target, all of them if none is smaller.
+func (k Knapsack) bestSubset(values []uint64, target uint64) ([]bool, uint64) {
+	iterations := k.Iterations
+	if iterations == 0 {
+		iterations = DefaultKnapsackIterations
+	}
+	best := make([]bool, len(values))
+	var bestSum uint64
+	for i, v := range values {
+		best[i] = true
+		bestSum += v
+	}
+	included := make([]bool, len(values))
+	for rep := 0; rep < iterations && bestSum != target; rep++ {
+		for i := range included {
+			included[i] = false
+		}
+		var sum uint64
+		reached := false
+		for pass := 0; pass < 2 && !reached; pass++ {
+			for i, v := range values {
+				//the second pass adds what the first random pass left out
+				if pass == 0 && !k.coin() || pass == 1 && included[i] {
+					continue
+				}
+				sum += v
+				included[i] = true
+				if sum >= target {
+					reached = true
+					if sum < bestSum {
+						bestSum = sum
+						copy(best, included)
+					}
+					sum -= v
+					included[i] = false
+				}
+			}
+		}
+	}
+	return best, bestSum
+}
+
+//Select implements CoinSelector.
+func (k Knapsack) Select(utxos UTXOs, target uint64, fee *TXFee) (*Selection, error) {
+	p := newPricing(utxos, fee)
+	utxos = p.spendable(utxos)
+	sort.Slice(utxos, func(i, j int) bool {
+		return utxos[i].Amount > utxos[j].Amount
+	})
+	exact := target + p.base
+	withChange := exact + p.change + DustThreshold(fee.change())
+
+	var smaller UTXOs
+	var values []uint64
+	var smallerSum uint64
+	var larger *UTXO
+	for _, u := range utxos {
+		v, _ := p.effectiveValue(u)
+		if v == exact {
+			return k.selection(UTXOs{u}, target, fee)
+		}
+		if v < withChange {
+			smaller = append(smaller, u)
+			values = append(values, v)
+			smallerSum += v
+		} else {
+			//utxos are sorted, the last one is the smallest
+			larger = u
+		}
+	}
+	if smallerSum == exact {
+		return k.selection(smaller, target, fee)
+	}
+	if smallerSum < exact {
+		if larger == nil {
+			return nil, insufficient(utxos, target, fee)
+		}
+		return k.selection(UTXOs{larger}, target, fee)
+	}
+
+	best, bestSum := k.bestSubset(values, exact)
+	if bestSum != exact && smallerSum >= withChange {
+		best, bestSum = k.bestSubset(values, withChange)
+	}
+	if larger != nil && bestSum != exact {
+		if v, _ := p.effectiveValue(larger); v <= bestSum {
+			return k.selection(UTXOs{larger}, target, fee)
+		}
+	}
+	var chosen UTXOs
+	for i, ok := range best {
+		if ok {
+			chosen = append(chosen, smaller[i])
+		}
+	}
+	return k.selection(chosen, target, fee)
+}
+
+//selection prices utxos, which the approximate pricing found enough.
+func (k Knapsack) selection(utxos UTXOs, target uint64, fee *TXFee) (*Selection, error) {
+	if s := newSelection(utxos, target, fee); s != nil {
+		return s, nil
+	}
+	return nil, insufficient(utxos, target, fee)
+}
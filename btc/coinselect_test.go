@@ -0,0 +1,84 @@
+package btc
+
+import (
+	"math/rand"
+	"testing"
+)
+
+//at 10 satoshi per byte an input costs 1480, a tx without inputs paying one
+//output 440 and a change output 340
+func selectUTXOs() UTXOs {
+	return UTXOs{
+		{Hash: []byte{1}, Amount: 100000, Age: 1},
+		{Hash: []byte{2}, Amount: 50000, Age: 10},
+		{Hash: []byte{3}, Amount: 30000, Age: 5},
+		//costs more to spend than it is worth
+		{Hash: []byte{4}, Amount: 1000, Age: 20},
+	}
+}
+
+func checkSelection(t *testing.T, name string, s *Selection, err error, amounts []uint64, fee, change uint64) {
+	if err != nil {
+		t.Errorf("%s: %v", name, err)
+		return
+	}
+	if len(s.UTXOs) != len(amounts) {
+		t.Errorf("%s: selected %d utxos, expected %d", name, len(s.UTXOs), len(amounts))
+		return
+	}
+	for i, u := range s.UTXOs {
+		if u.Amount != amounts[i] {
+			t.Errorf("%s: utxo %d is %d, expected %d", name, i, u.Amount, amounts[i])
+		}
+	}
+	if s.Fee != fee || s.Change != change {
+		t.Errorf("%s: fee %d change %d, expected %d %d", name, s.Fee, s.Change, fee, change)
+	}
+}
+
+func TestCoinSelectors(t *testing.T) {
+	fee := &TXFee{Rate: 10}
+	s, err := LargestFirst{}.Select(selectUTXOs(), 60000, fee)
+	checkSelection(t, "largest first", s, err, []uint64{100000}, 2260, 37740)
+	//change below the dust limit goes to the fee
+	s, err = LargestFirst{}.Select(selectUTXOs(), 97780, fee)
+	checkSelection(t, "largest first dust change", s, err, []uint64{100000}, 2220, 0)
+
+	s, err = OldestFirst{}.Select(selectUTXOs(), 60000, fee)
+	checkSelection(t, "oldest first", s, err, []uint64{50000, 30000}, 3740, 16260)
+
+	//two inputs pay the target exactly, largest first would make change
+	s, err = BranchAndBound{}.Select(selectUTXOs(), 76600, fee)
+	checkSelection(t, "branch and bound", s, err, []uint64{50000, 30000}, 3400, 0)
+	//no changeless match, the fallback is used
+	s, err = BranchAndBound{Fallback: LargestFirst{}}.Select(selectUTXOs(), 60000, fee)
+	checkSelection(t, "branch and bound fallback", s, err, []uint64{100000}, 2260, 37740)
+
+	knapsack := Knapsack{Rand: rand.New(rand.NewSource(1))}
+	s, err = knapsack.Select(selectUTXOs(), 20000, fee)
+	checkSelection(t, "knapsack larger", s, err, []uint64{30000}, 2260, 7740)
+	s, err = knapsack.Select(selectUTXOs(), 48080, fee)
+	checkSelection(t, "knapsack exact", s, err, []uint64{50000}, 1920, 0)
+	//several utxos below the target
+	utxos := UTXOs{
+		{Hash: []byte{1}, Amount: 11480},
+		{Hash: []byte{2}, Amount: 26480},
+		{Hash: []byte{3}, Amount: 37480},
+		{Hash: []byte{4}, Amount: 48480},
+	}
+	s, err = knapsack.Select(utxos, 56560, fee)
+	checkSelection(t, "knapsack subset", s, err, []uint64{48480, 11480}, 3400, 0)
+
+	selectors := map[string]CoinSelector{
+		"largest first":    LargestFirst{},
+		"oldest first":     OldestFirst{},
+		"branch and bound": BranchAndBound{},
+		"knapsack":         Knapsack{},
+	}
+	for name, selector := range selectors {
+		_, err := selector.Select(selectUTXOs(), 200000, fee)
+		if e, ok := err.(*InsufficientFundsError); !ok || e.Have != 180000 || e.Need != 204880 {
+			t.Errorf("%s: expected insufficient funds, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package btc
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+)
+
+//electrumProtocolVersion is the protocol version negotiated with the server
+const electrumProtocolVersion = "1.4"
+
+//ElectrumService is a service using an electrum server (ElectrumX, electrs,
+//Fulcrum) over its newline delimited JSON-RPC protocol.
+type ElectrumService struct {
+	addr    string
+	useTLS  bool
+	timeout time.Duration
+}
+
+//ElectrumError is an error returned by the electrum server.
+type ElectrumError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ElectrumError) Error() string {
+	return fmt.Sprintf("electrum error %d: %s", e.Code, e.Message)
+}
+
+//NewElectrumService creates ElectrumService for the server at addr, a
+//host:port, over TLS if useTLS is set.
+func NewElectrumService(addr string, useTLS bool) *ElectrumService {
+	e := &ElectrumService{}
+	e.addr = addr
+	e.useTLS = useTLS
+	e.timeout = 30 * time.Second
+	return e
+}
+
+//GetServiceName return service name.
+func (e *ElectrumService) GetServiceName() string {
+	return "ElectrumService"
+}
+
+//electrumConn is one session with the server, requests are answered in order.
+type electrumConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	id     uint64
+}
+
+//dial opens a session and negotiates the protocol version.
+func (e *ElectrumService) dial() (*electrumConn, error) {
+	dialer := &net.Dialer{Timeout: e.timeout}
+	var conn net.Conn
+	var err error
+	if e.useTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", e.addr, nil)
+	} else {
+		conn, err = dialer.Dial("tcp", e.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(e.timeout))
+	c := &electrumConn{conn: conn, reader: bufio.NewReader(conn)}
+	if err := c.call(nil, "server.version", "trade-addr-tx-service", electrumProtocolVersion); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+//call sends a request and decodes the result into result, notifications the
+//server sends in between are skipped.
+func (c *electrumConn) call(result interface{}, method string, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	c.id++
+	req, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      c.id,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := c.conn.Write(append(req, '\n')); err != nil {
+		return err
+	}
+	for {
+		line, err := c.reader.ReadBytes('\n')
+		if err != nil {
+			return err
+		}
+		var resp struct {
+			ID     *uint64         `json:"id"`
+			Result json.RawMessage `json:"result"`
+			Error  *ElectrumError  `json:"error"`
+		}
+		if err := json.Unmarshal(line, &resp); err != nil {
+			return err
+		}
+		if resp.ID == nil || *resp.ID != c.id {
+			continue
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(resp.Result, result)
+	}
+}
+
+func (c *electrumConn) close() {
+	c.conn.Close()
+}
+
+//scriptHash returns the electrum script hash of script, its sha256 reversed
+//in hex.
+func scriptHash(script []byte) string {
+	hash := sha256.Sum256(script)
+	for i, j := 0, len(hash)-1; i < j; i, j = i+1, j-1 {
+		hash[i], hash[j] = hash[j], hash[i]
+	}
+	return hex.EncodeToString(hash[:])
+}
+
+//GetUTXO gets unspent transaction outputs of addr by its script hash.
+func (e *ElectrumService) GetUTXO(addr string, key *Key) (UTXOs, error) {
+	script, err := AddressScript(addr)
+	if err != nil {
+		return nil, err
+	}
+	c, err := e.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer c.close()
+	var header struct {
+		Height uint64 `json:"height"`
+	}
+	if err := c.call(&header, "blockchain.headers.subscribe"); err != nil {
+		return nil, err
+	}
+	var unspents []struct {
+		TXHash string `json:"tx_hash"`
+		TXPos  uint32 `json:"tx_pos"`
+		Height uint64 `json:"height"`
+		Value  uint64 `json:"value"`
+	}
+	if err := c.call(&unspents, "blockchain.scripthash.listunspent", scriptHash(script)); err != nil {
+		return nil, err
+	}
+
+	utxos := make(UTXOs, 0, len(unspents))
+	for _, u := range unspents {
+		utxo := &UTXO{}
+		utxo.Addr = addr
+		if utxo.Hash, err = hex.DecodeString(u.TXHash); err != nil {
+			return nil, err
+		}
+		utxo.Index = u.TXPos
+		utxo.Amount = u.Value
+		utxo.Script = script
+		//height is 0 for unconfirmed outputs
+		if u.Height > 0 && header.Height >= u.Height {
+			utxo.Age = header.Height - u.Height + 1
+		}
+		utxo.Key = key
+		utxos = append(utxos, utxo)
+	}
+	return utxos, nil
+}
+
+//BlockHeight returns the height of the chain tip.
+func (e *ElectrumService) BlockHeight() (uint64, error) {
+	c, err := e.dial()
+	if err != nil {
+		return 0, err
+	}
+	defer c.close()
+	var header struct {
+		Height uint64 `json:"height"`
+	}
+	if err := c.call(&header, "blockchain.headers.subscribe"); err != nil {
+		return 0, err
+	}
+	return header.Height, nil
+}
+
+//EstimateFeeRate estimates the fee rate with blockchain.estimatefee.
+func (e *ElectrumService) EstimateFeeRate(blocks int) (uint64, error) {
+	c, err := e.dial()
+	if err != nil {
+		return 0, err
+	}
+	defer c.close()
+	var rate float64
+	if err := c.call(&rate, "blockchain.estimatefee", blocks); err != nil {
+		return 0, err
+	}
+	//the server answers -1 without enough data
+	if rate < 0 {
+		return 0, fmt.Errorf("no fee estimate for %d blocks", blocks)
+	}
+	return feeRateOfBTCPerKB(rate), nil
+}
+
+//GetRawTX returns the raw tx of txid with blockchain.transaction.get.
+func (e *ElectrumService) GetRawTX(txid []byte) ([]byte, error) {
+	c, err := e.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer c.close()
+	var rawtx string
+	if err := c.call(&rawtx, "blockchain.transaction.get", hex.EncodeToString(txid)); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(rawtx)
+}
+
+//SendTX broadcasts a transaction through the electrum server.
+func (e *ElectrumService) SendTX(data []byte) ([]byte, error) {
+	c, err := e.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer c.close()
+	var txid string
+	if err := c.call(&txid, "blockchain.transaction.broadcast", hex.EncodeToString(data)); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(txid)
+}
@@ -0,0 +1,170 @@
+package btc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//EsploraService is a service using the REST API of an esplora server, such
+//as https://blockstream.info/api.
+type EsploraService struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+//NewEsploraService creates EsploraService for the API at baseURL.
+func NewEsploraService(baseURL string) *EsploraService {
+	e := &EsploraService{}
+	e.baseURL = strings.TrimRight(baseURL, "/")
+	e.httpClient = &http.Client{Timeout: 30 * time.Second}
+	return e
+}
+
+//GetServiceName return service name.
+func (e *EsploraService) GetServiceName() string {
+	return "EsploraService"
+}
+
+//do sends a request to path and returns the body of a 200 response.
+func (e *EsploraService) do(method, path string, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequest(method, e.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("esplora returns %s: %s", resp.Status, data)
+	}
+	return data, nil
+}
+
+//GetUTXO gets unspent transaction outputs of addr.
+func (e *EsploraService) GetUTXO(addr string, key *Key) (UTXOs, error) {
+	tip, err := e.BlockHeight()
+	if err != nil {
+		return nil, err
+	}
+	return e.GetUTXOAtTip(addr, key, tip)
+}
+
+//GetUTXOAtTip gets unspent transaction outputs of addr, aged from tip.
+func (e *EsploraService) GetUTXOAtTip(addr string, key *Key, tip uint64) (UTXOs, error) {
+	script, err := AddressScript(addr)
+	if err != nil {
+		return nil, err
+	}
+	data, err := e.do("GET", "/address/"+addr+"/utxo", nil)
+	if err != nil {
+		return nil, err
+	}
+	var unspents []struct {
+		TXID   string `json:"txid"`
+		Vout   uint32 `json:"vout"`
+		Value  uint64 `json:"value"`
+		Status struct {
+			Confirmed   bool   `json:"confirmed"`
+			BlockHeight uint64 `json:"block_height"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(data, &unspents); err != nil {
+		return nil, err
+	}
+
+	utxos := make(UTXOs, 0, len(unspents))
+	for _, u := range unspents {
+		utxo := &UTXO{}
+		utxo.Addr = addr
+		if utxo.Hash, err = hex.DecodeString(u.TXID); err != nil {
+			return nil, err
+		}
+		utxo.Index = u.Vout
+		utxo.Amount = u.Value
+		utxo.Script = script
+		if u.Status.Confirmed && tip >= u.Status.BlockHeight {
+			utxo.Age = tip - u.Status.BlockHeight + 1
+		}
+		utxo.Key = key
+		utxos = append(utxos, utxo)
+	}
+	return utxos, nil
+}
+
+//BlockHeight returns the height of the chain tip.
+func (e *EsploraService) BlockHeight() (uint64, error) {
+	data, err := e.do("GET", "/blocks/tip/height", nil)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+//EstimateFeeRate returns the fee estimate of the largest confirmation target
+//esplora knows within blocks, or of its smallest target.
+func (e *EsploraService) EstimateFeeRate(blocks int) (uint64, error) {
+	data, err := e.do("GET", "/fee-estimates", nil)
+	if err != nil {
+		return 0, err
+	}
+	var estimates map[string]float64
+	if err := json.Unmarshal(data, &estimates); err != nil {
+		return 0, err
+	}
+	best, smallest := -1, -1
+	for key := range estimates {
+		target, err := strconv.Atoi(key)
+		if err != nil {
+			return 0, err
+		}
+		if target <= blocks && target > best {
+			best = target
+		}
+		if smallest < 0 || target < smallest {
+			smallest = target
+		}
+	}
+	if best < 0 {
+		best = smallest
+	}
+	if best < 0 {
+		return 0, fmt.Errorf("no fee estimates")
+	}
+	rate := uint64(math.Ceil(estimates[strconv.Itoa(best)]))
+	if rate < MinFeeRate {
+		rate = MinFeeRate
+	}
+	return rate, nil
+}
+
+//GetRawTX returns the raw tx of txid.
+func (e *EsploraService) GetRawTX(txid []byte) ([]byte, error) {
+	data, err := e.do("GET", "/tx/"+hex.EncodeToString(txid)+"/hex", nil)
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(strings.TrimSpace(string(data)))
+}
+
+//SendTX posts a transaction to the esplora server.
+func (e *EsploraService) SendTX(data []byte) ([]byte, error) {
+	body, err := e.do("POST", "/tx", strings.NewReader(hex.EncodeToString(data)))
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,783 @@
+package btc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+//key types of BIP-174 and BIP-371 PSBT fields
+const (
+	psbtGlobalUnsignedTX = 0x00
+
+	psbtInNonWitnessUTXO     = 0x00
+	psbtInWitnessUTXO        = 0x01
+	psbtInPartialSig         = 0x02
+	psbtInSigHashType        = 0x03
+	psbtInRedeemScript       = 0x04
+	psbtInWitnessScript      = 0x05
+	psbtInBIP32Derivation    = 0x06
+	psbtInFinalScriptSig     = 0x07
+	psbtInFinalScriptWitness = 0x08
+	psbtInTapKeySig          = 0x13
+	psbtInTapBIP32Derivation = 0x16
+	psbtInTapInternalKey     = 0x17
+
+	psbtOutRedeemScript       = 0x00
+	psbtOutWitnessScript      = 0x01
+	psbtOutBIP32Derivation    = 0x02
+	psbtOutTapInternalKey     = 0x05
+	psbtOutTapBIP32Derivation = 0x07
+
+	psbtProprietary = 0xfc
+)
+
+var psbtMagic = []byte{0x70, 0x73, 0x62, 0x74, 0xff}
+
+//the key types parsed, keys of them other than the parsers expect are invalid
+var (
+	psbtInKeyTypes = map[byte]bool{
+		psbtInNonWitnessUTXO: true, psbtInWitnessUTXO: true, psbtInPartialSig: true, psbtInSigHashType: true,
+		psbtInRedeemScript: true, psbtInWitnessScript: true, psbtInBIP32Derivation: true,
+		psbtInFinalScriptSig: true, psbtInFinalScriptWitness: true, psbtInTapKeySig: true,
+		psbtInTapBIP32Derivation: true, psbtInTapInternalKey: true,
+		psbtInPreviousTXID: true, psbtInOutputIndex: true, psbtInSequence: true,
+		psbtInRequiredTimeLocktime: true, psbtInRequiredHeightLocktime: true,
+	}
+	psbtOutKeyTypes = map[byte]bool{
+		psbtOutRedeemScript: true, psbtOutWitnessScript: true, psbtOutBIP32Derivation: true,
+		psbtOutTapInternalKey: true, psbtOutTapBIP32Derivation: true,
+		psbtOutAmount: true, psbtOutScript: true,
+	}
+)
+
+//psbtScriptTypeKey is the key of the proprietary input field holding the
+//script type of the input, its identifier is "addrtx" and subtype 0
+var psbtScriptTypeKey = append([]byte{psbtProprietary, 6}, "addrtx\x00"...)
+
+//psbtSigHashKey is the key of the proprietary input field holding the hash
+//the input signs by its sighash type, subtype 1
+var psbtSigHashKey = append([]byte{psbtProprietary, 6}, "addrtx\x01"...)
+
+//KeyDerivation is the BIP-32 origin of a public key: the fingerprint of the
+//master key and the path from it. LeafHashes are the taproot script leaves
+//the key signs, none for the key path.
+type KeyDerivation struct {
+	PubKey      []byte
+	Fingerprint []byte
+	Path        []uint32
+	LeafHashes  [][]byte
+}
+
+//PSBTInput holds what signers need to know about an input of a PSBT, and
+//their signatures.
+type PSBTInput struct {
+	NonWitnessUTXO []byte
+	WitnessUTXO    *TXout
+	RedeemScript   []byte
+	WitnessScript  []byte
+	Derivations    []*KeyDerivation
+	//ScriptType names the kind of script spent: p2pkh, p2wpkh, p2sh-p2wpkh,
+	//p2tr and so on
+	ScriptType string
+	//SigHashType is the sighash type signatures must use, nil for any
+	SigHashType *SigHashType
+	//SigHash is the hash to sign by SigHashType, for signers that do not
+	//compute it
+	SigHash []byte
+
+	//PartialSigs are the signatures by public key
+	PartialSigs map[string][]byte
+
+	FinalScriptSig     []byte
+	FinalScriptWitness [][]byte
+
+	TapKeySig      []byte
+	TapInternalKey []byte
+	TapDerivations []*KeyDerivation
+
+	//RequiredTimeLocktime and RequiredHeightLocktime are the locktimes the
+	//input needs of version 2 PSBTs
+	RequiredTimeLocktime   *uint32
+	RequiredHeightLocktime *uint32
+
+	//Unknown are the other fields, by key
+	Unknown map[string][]byte
+}
+
+//PSBTOutput holds what signers need to know about an output of a PSBT, to
+//recognize change.
+type PSBTOutput struct {
+	RedeemScript   []byte
+	WitnessScript  []byte
+	Derivations    []*KeyDerivation
+	TapInternalKey []byte
+	TapDerivations []*KeyDerivation
+
+	//Unknown are the other fields, by key
+	Unknown map[string][]byte
+}
+
+//PSBT is a BIP-174 partially signed transaction of version 0, or of BIP-370
+//version 2 where the unsigned tx is spread over the global, input and output
+//fields. The outputs of TX are all in Txout, OP_RETURN ones included. The
+//locktime of TX is the one BIP-370 determines, written back as the fallback.
+type PSBT struct {
+	//Version is 0 or 2
+	Version uint32
+	TX      *TX
+	Inputs  []*PSBTInput
+	Outputs []*PSBTOutput
+
+	//Unknown are the global fields other than the unsigned tx, by key
+	Unknown map[string][]byte
+}
+
+//NewPSBT returns a PSBT of the unsigned tx. Segwit inputs get their witness
+//utxo from Amount and PrevScriptPubkey, P2SH ones their RedeemScript.
+func NewPSBT(tx *TX) (*PSBT, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	p := &PSBT{TX: &TX{Locktime: tx.Locktime, Version: tx.Version}}
+	for i, txin := range tx.Txin {
+		if len(txin.scriptSig) != 0 || len(txin.Witness) != 0 {
+			return nil, fmt.Errorf("input %d is signed", i)
+		}
+		in := *txin
+		in.CreateScriptSig = nil
+		in.CreateWitness = nil
+		p.TX.Txin = append(p.TX.Txin, &in)
+		p.Inputs = append(p.Inputs, &PSBTInput{ScriptType: in.scriptType()})
+	}
+	p.TX.Txout = append([]*TXout{}, tx.outputs()...)
+	for range p.TX.Txout {
+		p.Outputs = append(p.Outputs, &PSBTOutput{})
+	}
+	for i, in := range p.TX.Txin {
+		switch GetScriptType(in.PrevScriptPubkey) {
+		case ScriptP2WPKH, ScriptP2WSH, ScriptP2TR:
+			p.Inputs[i].WitnessUTXO = &TXout{Value: in.Amount, ScriptPubkey: in.PrevScriptPubkey}
+		case ScriptP2SH:
+			p.Inputs[i].RedeemScript = in.RedeemScript
+			if in.isNestedWitness() {
+				p.Inputs[i].WitnessUTXO = &TXout{Value: in.Amount, ScriptPubkey: in.PrevScriptPubkey}
+			}
+		}
+	}
+	return p, nil
+}
+
+//scriptType names the script in spends, telling nested segwit from other P2SH.
+func (in *TXin) scriptType() string {
+	if in.isNestedWitness() {
+		return "p2sh-" + GetScriptType(in.RedeemScript).String()
+	}
+	return GetScriptType(in.PrevScriptPubkey).String()
+}
+
+//SetNonWitnessUTXO sets the previous tx of input i, rawtx, after checking
+//its hash and the spent output if the input has one.
+func (p *PSBT) SetNonWitnessUTXO(i int, rawtx []byte) error {
+	if i < 0 || i >= len(p.Inputs) {
+		return fmt.Errorf("input %d out of range", i)
+	}
+	prev, err := ParseTX(rawtx)
+	if err != nil {
+		return err
+	}
+	in := p.TX.Txin[i]
+	if !bytes.Equal(prev.TXID(), in.Hash) {
+		return fmt.Errorf("tx %x is not the previous tx %x of input %d", prev.TXID(), in.Hash, i)
+	}
+	if int(in.Index) >= len(prev.Txout) {
+		return fmt.Errorf("previous tx of input %d has no output %d", i, in.Index)
+	}
+	//a parsed PSBT without utxos gets the spent output of the previous tx
+	out := prev.Txout[in.Index]
+	if in.PrevScriptPubkey != nil && (out.Value != in.Amount || !bytes.Equal(out.ScriptPubkey, in.PrevScriptPubkey)) {
+		return fmt.Errorf("output %d of previous tx of input %d does not match", in.Index, i)
+	}
+	p.Inputs[i].NonWitnessUTXO = rawtx
+	in.Amount = out.Value
+	in.PrevScriptPubkey = out.ScriptPubkey
+	return nil
+}
+
+//SetInputKey records the origin of the key signing input i, as a taproot
+//internal key for P2TR inputs. The key must be the one the spent script pays.
+func (p *PSBT) SetInputKey(i int, key *KeyDerivation) error {
+	if i < 0 || i >= len(p.Inputs) {
+		return fmt.Errorf("input %d out of range", i)
+	}
+	in := p.TX.Txin[i]
+	redeemScript, err := keyScript(in.PrevScriptPubkey, key.PubKey)
+	if err != nil {
+		return fmt.Errorf("input %d: %v", i, err)
+	}
+	if redeemScript != nil && !bytes.Equal(redeemScript, in.RedeemScript) {
+		return fmt.Errorf("input %d: redeem script %x does not match", i, in.RedeemScript)
+	}
+	input := p.Inputs[i]
+	if GetScriptType(in.PrevScriptPubkey) == ScriptP2TR {
+		tapKey := xOnly(key)
+		input.TapInternalKey = tapKey.PubKey
+		input.TapDerivations = append(input.TapDerivations, tapKey)
+		return nil
+	}
+	input.Derivations = append(input.Derivations, key)
+	return nil
+}
+
+//SetSigHashes sets the sighash type of every input, SIGHASH_DEFAULT for P2TR
+//and SIGHASH_ALL otherwise, and the hash each input signs by it, see
+//TX.SigHashes. The spent outputs of all inputs must be known.
+func (p *PSBT) SetSigHashes() error {
+	if len(p.Inputs) != len(p.TX.Txin) {
+		return errors.New("psbt inputs do not match its tx")
+	}
+	sigHashes, err := p.TX.SigHashes(SigHashDefault)
+	if err != nil {
+		return err
+	}
+	for i, in := range p.TX.Txin {
+		t := SigHashAll
+		if GetScriptType(in.PrevScriptPubkey) == ScriptP2TR {
+			t = SigHashDefault
+		}
+		p.Inputs[i].SigHashType = &t
+		p.Inputs[i].SigHash = sigHashes[i]
+	}
+	return nil
+}
+
+//SetOutputKey records the origin of the key output i pays, so signers can
+//tell change. The redeem script of a P2SH-P2WPKH output is set too.
+func (p *PSBT) SetOutputKey(i int, key *KeyDerivation) error {
+	if i < 0 || i >= len(p.Outputs) {
+		return fmt.Errorf("output %d out of range", i)
+	}
+	script := p.TX.Txout[i].ScriptPubkey
+	redeemScript, err := keyScript(script, key.PubKey)
+	if err != nil {
+		return fmt.Errorf("output %d: %v", i, err)
+	}
+	output := p.Outputs[i]
+	if GetScriptType(script) == ScriptP2TR {
+		tapKey := xOnly(key)
+		output.TapInternalKey = tapKey.PubKey
+		output.TapDerivations = append(output.TapDerivations, tapKey)
+		return nil
+	}
+	output.RedeemScript = redeemScript
+	output.Derivations = append(output.Derivations, key)
+	return nil
+}
+
+//keyScript checks script pays the compressed pubKey alone and returns the
+//redeem script of a P2SH-P2WPKH one.
+func keyScript(script, pubKey []byte) ([]byte, error) {
+	if len(pubKey) != 33 {
+		return nil, fmt.Errorf("invalid public key length %d", len(pubKey))
+	}
+	hash := Hash160(pubKey)
+	var expected, redeemScript []byte
+	switch GetScriptType(script) {
+	case ScriptP2PKH:
+		expected = append([]byte{opDUP, opHASH160, 20}, hash...)
+		expected = append(expected, opEQUALVERIFY, opCHECKSIG)
+	case ScriptP2WPKH:
+		expected = P2WPKHRedeemScript(hash)
+	case ScriptP2SH:
+		redeemScript = P2WPKHRedeemScript(hash)
+		expected = append([]byte{opHASH160, 20}, Hash160(redeemScript)...)
+		expected = append(expected, opEQUAL)
+	case ScriptP2TR:
+		outputKey, err := TaprootOutputKey(pubKey)
+		if err != nil {
+			return nil, err
+		}
+		expected = P2TRScript(outputKey)
+	}
+	if !bytes.Equal(script, expected) {
+		return nil, fmt.Errorf("script %x does not pay key %x", script, pubKey)
+	}
+	return redeemScript, nil
+}
+
+//xOnly returns key with its public key as a BIP-340 x-only key.
+func xOnly(key *KeyDerivation) *KeyDerivation {
+	tapKey := *key
+	tapKey.PubKey = key.PubKey[1:]
+	return &tapKey
+}
+
+//Serialize returns the binary PSBT.
+func (p *PSBT) Serialize() ([]byte, error) {
+	if len(p.Inputs) != len(p.TX.Txin) || len(p.Outputs) != len(p.TX.Txout) {
+		return nil, errors.New("psbt inputs or outputs do not match its tx")
+	}
+	var buffer bytes.Buffer
+	buffer.Write(psbtMagic)
+	switch p.Version {
+	case 0:
+		writePSBTEntry(&buffer, []byte{psbtGlobalUnsignedTX}, p.unsignedTX())
+	case 2:
+		writeGlobalsV2(&buffer, p.TX)
+	default:
+		return nil, fmt.Errorf("psbt version %d not supported", p.Version)
+	}
+	writeUnknown(&buffer, p.Unknown)
+	buffer.WriteByte(0)
+
+	for i, in := range p.Inputs {
+		if p.Version == 2 {
+			writeInputV2(&buffer, p.TX.Txin[i], in)
+		}
+		if in.NonWitnessUTXO != nil {
+			writePSBTEntry(&buffer, []byte{psbtInNonWitnessUTXO}, in.NonWitnessUTXO)
+		}
+		if in.WitnessUTXO != nil {
+			var out bytes.Buffer
+			writeTXout(&out, in.WitnessUTXO)
+			writePSBTEntry(&buffer, []byte{psbtInWitnessUTXO}, out.Bytes())
+		}
+		for _, pubKey := range sortedKeys(in.PartialSigs) {
+			writePSBTEntry(&buffer, append([]byte{psbtInPartialSig}, pubKey...), in.PartialSigs[pubKey])
+		}
+		if in.SigHashType != nil {
+			value := make([]byte, 4)
+			binary.LittleEndian.PutUint32(value, uint32(*in.SigHashType))
+			writePSBTEntry(&buffer, []byte{psbtInSigHashType}, value)
+		}
+		if in.RedeemScript != nil {
+			writePSBTEntry(&buffer, []byte{psbtInRedeemScript}, in.RedeemScript)
+		}
+		if in.WitnessScript != nil {
+			writePSBTEntry(&buffer, []byte{psbtInWitnessScript}, in.WitnessScript)
+		}
+		writeDerivations(&buffer, psbtInBIP32Derivation, in.Derivations, false)
+		if in.FinalScriptSig != nil {
+			writePSBTEntry(&buffer, []byte{psbtInFinalScriptSig}, in.FinalScriptSig)
+		}
+		if in.FinalScriptWitness != nil {
+			var witness bytes.Buffer
+			writeWitness(&witness, in.FinalScriptWitness)
+			writePSBTEntry(&buffer, []byte{psbtInFinalScriptWitness}, witness.Bytes())
+		}
+		if in.TapKeySig != nil {
+			writePSBTEntry(&buffer, []byte{psbtInTapKeySig}, in.TapKeySig)
+		}
+		writeDerivations(&buffer, psbtInTapBIP32Derivation, in.TapDerivations, true)
+		if in.TapInternalKey != nil {
+			writePSBTEntry(&buffer, []byte{psbtInTapInternalKey}, in.TapInternalKey)
+		}
+		if in.ScriptType != "" {
+			writePSBTEntry(&buffer, psbtScriptTypeKey, []byte(in.ScriptType))
+		}
+		if in.SigHash != nil {
+			writePSBTEntry(&buffer, psbtSigHashKey, in.SigHash)
+		}
+		writeUnknown(&buffer, in.Unknown)
+		buffer.WriteByte(0)
+	}
+
+	for i, out := range p.Outputs {
+		if p.Version == 2 {
+			writeOutputV2(&buffer, p.TX.Txout[i])
+		}
+		if out.RedeemScript != nil {
+			writePSBTEntry(&buffer, []byte{psbtOutRedeemScript}, out.RedeemScript)
+		}
+		if out.WitnessScript != nil {
+			writePSBTEntry(&buffer, []byte{psbtOutWitnessScript}, out.WitnessScript)
+		}
+		writeDerivations(&buffer, psbtOutBIP32Derivation, out.Derivations, false)
+		if out.TapInternalKey != nil {
+			writePSBTEntry(&buffer, []byte{psbtOutTapInternalKey}, out.TapInternalKey)
+		}
+		writeDerivations(&buffer, psbtOutTapBIP32Derivation, out.TapDerivations, true)
+		writeUnknown(&buffer, out.Unknown)
+		buffer.WriteByte(0)
+	}
+	return buffer.Bytes(), nil
+}
+
+//unsignedTX returns the raw tx of the PSBT without signatures.
+func (p *PSBT) unsignedTX() []byte {
+	unsigned := *p.TX
+	unsigned.CustomData = nil
+	unsigned.Txin = nil
+	for _, txin := range p.TX.Txin {
+		in := *txin
+		in.scriptSig = nil
+		in.Witness = nil
+		unsigned.Txin = append(unsigned.Txin, &in)
+	}
+	return unsigned.createRawTransaction(false)
+}
+
+//Base64 returns the PSBT in base64, as wallets exchange it.
+func (p *PSBT) Base64() (string, error) {
+	b, err := p.Serialize()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+func writePSBTEntry(buffer *bytes.Buffer, key, value []byte) {
+	writeScript(buffer, key)
+	writeScript(buffer, value)
+}
+
+func writeWitness(buffer *bytes.Buffer, witness [][]byte) {
+	buffer.Write(toVI(uint64(len(witness))))
+	for _, item := range witness {
+		writeScript(buffer, item)
+	}
+}
+
+//writeDerivations writes the derivations of keys, with the leaf hashes
+//before the origin for taproot.
+func writeDerivations(buffer *bytes.Buffer, keyType byte, keys []*KeyDerivation, taproot bool) {
+	for _, key := range keys {
+		var value bytes.Buffer
+		if taproot {
+			value.Write(toVI(uint64(len(key.LeafHashes))))
+			for _, h := range key.LeafHashes {
+				value.Write(h)
+			}
+		}
+		value.Write(key.Fingerprint)
+		for _, i := range key.Path {
+			writeUint32(&value, i)
+		}
+		writePSBTEntry(buffer, append([]byte{keyType}, key.PubKey...), value.Bytes())
+	}
+}
+
+func writeUnknown(buffer *bytes.Buffer, unknown map[string][]byte) {
+	for _, key := range sortedKeys(unknown) {
+		writePSBTEntry(buffer, []byte(key), unknown[key])
+	}
+}
+
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//ParsePSBTBase64 parses a base64 PSBT.
+func ParsePSBTBase64(s string) (*PSBT, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePSBT(b)
+}
+
+//ParsePSBT parses a binary PSBT of version 0 or 2. The inputs of its TX get
+//their Amount and PrevScriptPubkey from the utxos and RedeemScript from the
+//input fields.
+func ParsePSBT(b []byte) (*PSBT, error) {
+	if !bytes.HasPrefix(b, psbtMagic) {
+		return nil, errors.New("not a psbt")
+	}
+	r := &txReader{b: b[len(psbtMagic):]}
+	p := &PSBT{Unknown: make(map[string][]byte)}
+	global, err := readPSBTMap(r)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := global[string([]byte{psbtGlobalVersion})]; ok {
+		if len(v) != 4 {
+			return nil, fmt.Errorf("invalid psbt version %x", v)
+		}
+		p.Version = binary.LittleEndian.Uint32(v)
+		delete(global, string([]byte{psbtGlobalVersion}))
+	}
+	var nIn, nOut uint64
+	switch p.Version {
+	case 0:
+		for key, value := range global {
+			switch {
+			case key == string([]byte{psbtGlobalUnsignedTX}):
+				if p.TX, err = ParseTX(value); err != nil {
+					return nil, fmt.Errorf("psbt unsigned tx: %v", err)
+				}
+			case key[0] == psbtGlobalUnsignedTX || psbtV2GlobalKeyTypes[key[0]]:
+				return nil, fmt.Errorf("invalid psbt key %x", key)
+			}
+		}
+		delete(global, string([]byte{psbtGlobalUnsignedTX}))
+		if p.TX == nil {
+			return nil, errors.New("psbt without unsigned tx")
+		}
+		for i, in := range p.TX.Txin {
+			if len(in.scriptSig) != 0 || len(in.Witness) != 0 {
+				return nil, fmt.Errorf("psbt unsigned tx has signed input %d", i)
+			}
+		}
+		nIn, nOut = uint64(len(p.TX.Txin)), uint64(len(p.TX.Txout))
+	case 2:
+		if _, ok := global[string([]byte{psbtGlobalUnsignedTX})]; ok {
+			return nil, errors.New("psbt v2 with an unsigned tx")
+		}
+		if p.TX, nIn, nOut, err = parseGlobalsV2(global); err != nil {
+			return nil, err
+		}
+		//each input and output map takes at least its separator
+		if nIn > uint64(len(r.b)) || nOut > uint64(len(r.b))-nIn {
+			return nil, fmt.Errorf("psbt of %d inputs and %d outputs exceeds its length", nIn, nOut)
+		}
+	default:
+		return nil, fmt.Errorf("psbt version %d not supported", p.Version)
+	}
+	for key, value := range global {
+		p.Unknown[key] = value
+	}
+
+	for i := uint64(0); i < nIn; i++ {
+		m, err := readPSBTMap(r)
+		if err != nil {
+			return nil, err
+		}
+		var in *TXin
+		var timeLock, heightLock *uint32
+		if p.Version == 2 {
+			if in, timeLock, heightLock, err = takeInputV2(m); err != nil {
+				return nil, fmt.Errorf("psbt input %d: %v", i, err)
+			}
+			p.TX.Txin = append(p.TX.Txin, in)
+		} else {
+			in = p.TX.Txin[i]
+		}
+		input, err := parsePSBTInput(m)
+		if err != nil {
+			return nil, fmt.Errorf("psbt input %d: %v", i, err)
+		}
+		input.RequiredTimeLocktime = timeLock
+		input.RequiredHeightLocktime = heightLock
+		if err := input.fill(in); err != nil {
+			return nil, fmt.Errorf("psbt input %d: %v", i, err)
+		}
+		p.Inputs = append(p.Inputs, input)
+	}
+	for i := uint64(0); i < nOut; i++ {
+		m, err := readPSBTMap(r)
+		if err != nil {
+			return nil, err
+		}
+		if p.Version == 2 {
+			out, err := takeOutputV2(m)
+			if err != nil {
+				return nil, fmt.Errorf("psbt output %d: %v", i, err)
+			}
+			p.TX.Txout = append(p.TX.Txout, out)
+		}
+		output, err := parsePSBTOutput(m)
+		if err != nil {
+			return nil, fmt.Errorf("psbt output %d: %v", i, err)
+		}
+		p.Outputs = append(p.Outputs, output)
+	}
+	if len(r.b) != 0 {
+		return nil, fmt.Errorf("%d bytes after psbt", len(r.b))
+	}
+	if p.Version == 2 {
+		if p.TX.Locktime, err = locktimeV2(p.TX.Locktime, p.Inputs); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+//readPSBTMap reads key value pairs up to the 0x00 separator.
+func readPSBTMap(r *txReader) (map[string][]byte, error) {
+	m := make(map[string][]byte)
+	for {
+		key := r.readVarBytes()
+		if r.err != nil {
+			return nil, fmt.Errorf("psbt: %v", r.err)
+		}
+		if len(key) == 0 {
+			return m, nil
+		}
+		value := r.readVarBytes()
+		if r.err != nil {
+			return nil, fmt.Errorf("psbt: %v", r.err)
+		}
+		if _, ok := m[string(key)]; ok {
+			return nil, fmt.Errorf("duplicate psbt key %x", key)
+		}
+		m[string(key)] = value
+	}
+}
+
+func parsePSBTInput(m map[string][]byte) (*PSBTInput, error) {
+	in := &PSBTInput{PartialSigs: make(map[string][]byte), Unknown: make(map[string][]byte)}
+	for k, value := range m {
+		key := []byte(k)
+		var err error
+		switch {
+		case k == string(psbtScriptTypeKey):
+			in.ScriptType = string(value)
+		case k == string(psbtSigHashKey):
+			if len(value) != 32 {
+				err = fmt.Errorf("invalid sighash length %d", len(value))
+			}
+			in.SigHash = value
+		case key[0] == psbtInSigHashType && len(key) == 1:
+			if len(value) != 4 {
+				err = fmt.Errorf("invalid sighash type length %d", len(value))
+			}
+			t := SigHashType(binary.LittleEndian.Uint32(append(value, 0, 0, 0, 0)))
+			in.SigHashType = &t
+		case key[0] == psbtInNonWitnessUTXO && len(key) == 1:
+			in.NonWitnessUTXO = value
+		case key[0] == psbtInWitnessUTXO && len(key) == 1:
+			r := &txReader{b: value}
+			in.WitnessUTXO = &TXout{Value: r.uint64(), ScriptPubkey: r.readVarBytes()}
+			if r.err != nil || len(r.b) != 0 {
+				err = errors.New("invalid witness utxo")
+			}
+		case key[0] == psbtInPartialSig && (len(key) == 34 || len(key) == 66):
+			in.PartialSigs[string(key[1:])] = value
+		case key[0] == psbtInRedeemScript && len(key) == 1:
+			in.RedeemScript = value
+		case key[0] == psbtInWitnessScript && len(key) == 1:
+			in.WitnessScript = value
+		case key[0] == psbtInBIP32Derivation && (len(key) == 34 || len(key) == 66):
+			var d *KeyDerivation
+			d, err = parseDerivation(key[1:], value, false)
+			in.Derivations = append(in.Derivations, d)
+		case key[0] == psbtInFinalScriptSig && len(key) == 1:
+			in.FinalScriptSig = value
+		case key[0] == psbtInFinalScriptWitness && len(key) == 1:
+			r := &txReader{b: value}
+			in.FinalScriptWitness = r.readWitness()
+			if r.err != nil || len(r.b) != 0 {
+				err = errors.New("invalid final script witness")
+			}
+		case key[0] == psbtInTapKeySig && len(key) == 1:
+			if len(value) != 64 && len(value) != 65 {
+				err = fmt.Errorf("invalid taproot key signature length %d", len(value))
+			}
+			in.TapKeySig = value
+		case key[0] == psbtInTapBIP32Derivation && len(key) == 33:
+			var d *KeyDerivation
+			d, err = parseDerivation(key[1:], value, true)
+			in.TapDerivations = append(in.TapDerivations, d)
+		case key[0] == psbtInTapInternalKey && len(key) == 1:
+			if len(value) != 32 {
+				err = fmt.Errorf("invalid taproot internal key length %d", len(value))
+			}
+			in.TapInternalKey = value
+		case psbtInKeyTypes[key[0]]:
+			err = fmt.Errorf("invalid key %x", key)
+		default:
+			in.Unknown[k] = value
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	sortDerivations(in.Derivations)
+	sortDerivations(in.TapDerivations)
+	return in, nil
+}
+
+func parsePSBTOutput(m map[string][]byte) (*PSBTOutput, error) {
+	out := &PSBTOutput{Unknown: make(map[string][]byte)}
+	for k, value := range m {
+		key := []byte(k)
+		var err error
+		switch {
+		case key[0] == psbtOutRedeemScript && len(key) == 1:
+			out.RedeemScript = value
+		case key[0] == psbtOutWitnessScript && len(key) == 1:
+			out.WitnessScript = value
+		case key[0] == psbtOutBIP32Derivation && (len(key) == 34 || len(key) == 66):
+			var d *KeyDerivation
+			d, err = parseDerivation(key[1:], value, false)
+			out.Derivations = append(out.Derivations, d)
+		case key[0] == psbtOutTapInternalKey && len(key) == 1:
+			if len(value) != 32 {
+				err = fmt.Errorf("invalid taproot internal key length %d", len(value))
+			}
+			out.TapInternalKey = value
+		case key[0] == psbtOutTapBIP32Derivation && len(key) == 33:
+			var d *KeyDerivation
+			d, err = parseDerivation(key[1:], value, true)
+			out.TapDerivations = append(out.TapDerivations, d)
+		case psbtOutKeyTypes[key[0]]:
+			err = fmt.Errorf("invalid key %x", key)
+		default:
+			out.Unknown[k] = value
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	sortDerivations(out.Derivations)
+	sortDerivations(out.TapDerivations)
+	return out, nil
+}
+
+func parseDerivation(pubKey, value []byte, taproot bool) (*KeyDerivation, error) {
+	r := &txReader{b: value}
+	d := &KeyDerivation{PubKey: pubKey}
+	if taproot {
+		n := r.count()
+		for i := uint64(0); i < n && r.err == nil; i++ {
+			d.LeafHashes = append(d.LeafHashes, r.read(32))
+		}
+	}
+	d.Fingerprint = r.read(4)
+	if r.err != nil || len(r.b)%4 != 0 {
+		return nil, fmt.Errorf("invalid derivation of key %x", pubKey)
+	}
+	for len(r.b) > 0 {
+		d.Path = append(d.Path, r.uint32())
+	}
+	return d, nil
+}
+
+//sortDerivations orders derivations by public key, parsed maps have none.
+func sortDerivations(keys []*KeyDerivation) {
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i].PubKey, keys[j].PubKey) < 0
+	})
+}
+
+//fill sets the spent output and redeem script of txin from the input fields.
+func (input *PSBTInput) fill(txin *TXin) error {
+	if input.NonWitnessUTXO != nil {
+		prev, err := ParseTX(input.NonWitnessUTXO)
+		if err != nil {
+			return fmt.Errorf("non witness utxo: %v", err)
+		}
+		if !bytes.Equal(prev.TXID(), txin.Hash) {
+			return fmt.Errorf("non witness utxo %x is not the previous tx %x", prev.TXID(), txin.Hash)
+		}
+		if int(txin.Index) >= len(prev.Txout) {
+			return fmt.Errorf("non witness utxo has no output %d", txin.Index)
+		}
+		txin.Amount = prev.Txout[txin.Index].Value
+		txin.PrevScriptPubkey = prev.Txout[txin.Index].ScriptPubkey
+	}
+	if input.WitnessUTXO != nil {
+		txin.Amount = input.WitnessUTXO.Value
+		txin.PrevScriptPubkey = input.WitnessUTXO.ScriptPubkey
+	}
+	txin.RedeemScript = input.RedeemScript
+	return nil
+}
@@ -0,0 +1,248 @@
+package btc
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+//psbtTestTX spends a P2PKH, a P2WPKH, a P2SH-P2WPKH and a P2TR output of
+//pubKey and returns change to its P2SH-P2WPKH script, with the previous tx of
+//the P2PKH input.
+func psbtTestTX(pubKey []byte) (*TX, []byte) {
+	hash := Hash160(pubKey)
+	p2pkh := append(append([]byte{opDUP, opHASH160, 20}, hash...), opEQUALVERIFY, opCHECKSIG)
+	redeemScript := P2WPKHRedeemScript(hash)
+	p2sh := append(append([]byte{opHASH160, 20}, Hash160(redeemScript)...), opEQUAL)
+	outputKey, _ := TaprootOutputKey(pubKey)
+
+	prev := &TX{
+		Txin:  []*TXin{{Hash: make([]byte, 32), Sequence: 0xffffffff}},
+		Txout: []*TXout{{Value: 1000, ScriptPubkey: P2TRScript(outputKey)}, {Value: 50000, ScriptPubkey: p2pkh}},
+	}
+	prevTX := prev.createRawTransaction(false)
+	tx := &TX{
+		Txin: []*TXin{
+			{Hash: prev.TXID(), Index: 1, Sequence: 0xffffffff, PrevScriptPubkey: p2pkh, Amount: 50000},
+			{Hash: bytes.Repeat([]byte{1}, 32), Sequence: 0xffffffff, PrevScriptPubkey: P2WPKHRedeemScript(hash), Amount: 60000},
+			{Hash: bytes.Repeat([]byte{2}, 32), Sequence: 0xffffffff, PrevScriptPubkey: p2sh, Amount: 70000, RedeemScript: redeemScript},
+			{Hash: bytes.Repeat([]byte{3}, 32), Index: 2, Sequence: 0xfffffffd, PrevScriptPubkey: P2TRScript(outputKey), Amount: 80000},
+		},
+		Txout: []*TXout{
+			{Value: 200000, ScriptPubkey: mustDecodeHex("76a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac")},
+			{Value: 50000, ScriptPubkey: p2sh},
+		},
+		Version: 2,
+	}
+	return tx, prevTX
+}
+
+func TestPSBT(t *testing.T) {
+	pubKey := mustDecodeHex("025476c2e83188368da1ff3e292e7acafcdb3566bb0ad253f62fc70f07aeee6357")
+	tx, prevTX := psbtTestTX(pubKey)
+	tx.AttachCustomData([]byte("memo"))
+	p, err := NewPSBT(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//custom data is the first output
+	if len(p.Outputs) != 3 || GetScriptType(p.TX.Txout[0].ScriptPubkey) != ScriptNullData {
+		t.Fatalf("psbt outputs %+v", p.TX.Txout)
+	}
+	for i, scriptType := range []string{"p2pkh", "p2wpkh", "p2sh-p2wpkh", "p2tr"} {
+		in := p.Inputs[i]
+		if in.ScriptType != scriptType {
+			t.Errorf("input %d script type %s, expected %s", i, in.ScriptType, scriptType)
+		}
+		if (in.WitnessUTXO != nil) != (i > 0) {
+			t.Errorf("input %d witness utxo %+v", i, in.WitnessUTXO)
+		}
+	}
+	if !bytes.Equal(p.Inputs[2].RedeemScript, tx.Txin[2].RedeemScript) {
+		t.Errorf("p2sh input without redeem script")
+	}
+
+	if err := p.SetNonWitnessUTXO(1, prevTX); err == nil {
+		t.Errorf("previous tx of another input accepted")
+	}
+	if err := p.SetNonWitnessUTXO(0, prevTX); err != nil {
+		t.Errorf("set non witness utxo: %v", err)
+	}
+	key := &KeyDerivation{PubKey: pubKey, Fingerprint: []byte{0xde, 0xad, 0xbe, 0xef}, Path: []uint32{0x80000054, 0x80000000, 0x80000000, 0, 7}}
+	for i := range p.Inputs {
+		if err := p.SetInputKey(i, key); err != nil {
+			t.Errorf("set key of input %d: %v", i, err)
+		}
+	}
+	if err := p.SetOutputKey(2, key); err != nil || !bytes.Equal(p.Outputs[2].RedeemScript, tx.Txin[2].RedeemScript) {
+		t.Errorf("set key of change: %v", err)
+	}
+	otherKey := &KeyDerivation{PubKey: mustDecodeHex("03c9f4836b9a4f77fc0d81f7bcb01b7f1b35916864b9476c241ce9fc198bd25432")}
+	if err := p.SetOutputKey(1, otherKey); err == nil {
+		t.Errorf("key of another script accepted")
+	}
+	if !bytes.Equal(p.Inputs[3].TapInternalKey, pubKey[1:]) || len(p.Inputs[3].Derivations) != 0 || len(p.Inputs[3].TapDerivations) != 1 {
+		t.Errorf("taproot input keys %+v", p.Inputs[3])
+	}
+	p.Inputs[1].Unknown = map[string][]byte{"\x0a": {1, 0, 0, 0}}
+	if err := p.SetSigHashes(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := p.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(b, []byte("psbt\xff")) {
+		t.Errorf("psbt without magic %x", b)
+	}
+	s, _ := p.Base64()
+	parsed, err := ParsePSBTBase64(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := parsed.Serialize(); !bytes.Equal(again, b) {
+		t.Errorf("psbt round trip %x, expected %x", again, b)
+	}
+	//the spent outputs come from the utxo fields
+	for i, in := range parsed.TX.Txin {
+		if in.Amount != tx.Txin[i].Amount || !bytes.Equal(in.PrevScriptPubkey, tx.Txin[i].PrevScriptPubkey) || !bytes.Equal(in.RedeemScript, tx.Txin[i].RedeemScript) {
+			t.Errorf("parsed input %d %+v", i, in)
+		}
+	}
+	if !reflect.DeepEqual(parsed.Inputs[0].Derivations, []*KeyDerivation{key}) || !reflect.DeepEqual(parsed.Outputs[2].Derivations, []*KeyDerivation{key}) {
+		t.Errorf("parsed derivations %+v", parsed.Inputs[0].Derivations[0])
+	}
+	if !bytes.Equal(parsed.Inputs[1].Unknown["\x0a"], []byte{1, 0, 0, 0}) {
+		t.Errorf("unknown field lost")
+	}
+	sigHashes, err := parsed.TX.SigHashes(SigHashDefault)
+	if expected, _ := tx.SigHashes(SigHashDefault); err != nil || !reflect.DeepEqual(sigHashes, expected) {
+		t.Errorf("sighashes of parsed psbt differ: %v", err)
+	}
+	//the hashes to sign travel with the psbt, taproot ones by SIGHASH_DEFAULT
+	for i, in := range parsed.Inputs {
+		expected := SigHashAll
+		if i == 3 {
+			expected = SigHashDefault
+		}
+		if in.SigHashType == nil || *in.SigHashType != expected || !bytes.Equal(in.SigHash, sigHashes[i]) {
+			t.Errorf("input %d sighash %x of type %v", i, in.SigHash, in.SigHashType)
+		}
+	}
+
+	for _, invalid := range [][]byte{
+		b[1:],
+		b[:len(b)-1],
+		append(b, 0),
+		//a second unsigned tx key
+		append(append([]byte("psbt\xff"), 0x02, 0x00, 0x00, 0x01, 0x00), b[5:]...),
+	} {
+		if _, err := ParsePSBT(invalid); err == nil {
+			t.Errorf("invalid psbt %x parsed", invalid)
+		}
+	}
+	tx.Txin[0].scriptSig = []byte{0}
+	if _, err := NewPSBT(tx); err == nil {
+		t.Errorf("psbt of a signed tx")
+	}
+}
+
+func TestPSBTV2(t *testing.T) {
+	pubKey := mustDecodeHex("025476c2e83188368da1ff3e292e7acafcdb3566bb0ad253f62fc70f07aeee6357")
+	tx, prevTX := psbtTestTX(pubKey)
+	tx.Locktime = 100
+	p, err := NewPSBT(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetNonWitnessUTXO(0, prevTX); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetSigHashes(); err != nil {
+		t.Fatal(err)
+	}
+	v0, _ := p.Serialize()
+	p.Version = 2
+	b, err := p.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParsePSBT(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Version != 2 || len(parsed.Unknown) != 0 {
+		t.Errorf("parsed version %d, unknown %x", parsed.Version, parsed.Unknown)
+	}
+	//the same tx as version 0
+	if !bytes.Equal(parsed.unsignedTX(), p.unsignedTX()) {
+		t.Errorf("v2 unsigned tx %x, expected %x", parsed.unsignedTX(), p.unsignedTX())
+	}
+	for i, in := range parsed.Inputs {
+		if !bytes.Equal(in.SigHash, p.Inputs[i].SigHash) || parsed.TX.Txin[i].Amount != tx.Txin[i].Amount {
+			t.Errorf("v2 input %d %+v", i, in)
+		}
+	}
+	if again, _ := parsed.Serialize(); !bytes.Equal(again, b) {
+		t.Errorf("psbt v2 round trip %x, expected %x", again, b)
+	}
+	if p0, err := ParsePSBT(v0); err != nil || p0.Version != 0 || p0.Combine(parsed) == nil {
+		t.Errorf("psbt v0 combined with v2: %v", err)
+	}
+
+	height := uint32(700000)
+	p.Inputs[1].RequiredHeightLocktime = &height
+	b, _ = p.Serialize()
+	if parsed, err = ParsePSBT(b); err != nil || parsed.TX.Locktime != height || *parsed.Inputs[1].RequiredHeightLocktime != height {
+		t.Errorf("required height locktime not applied: %v", err)
+	}
+
+	entry := func(key, value []byte) []byte {
+		var buffer bytes.Buffer
+		writePSBTEntry(&buffer, key, value)
+		return buffer.Bytes()
+	}
+	withGlobal := func(b, entry []byte) []byte {
+		return append(append([]byte("psbt\xff"), entry...), b[5:]...)
+	}
+	for _, invalid := range [][]byte{
+		//an unsigned tx in version 2
+		withGlobal(b, entry([]byte{psbtGlobalUnsignedTX}, p.unsignedTX())),
+		//a version 2 field in version 0
+		withGlobal(v0, entry([]byte{psbtGlobalInputCount}, []byte{4})),
+		//version 1
+		withGlobal(v0, entry([]byte{psbtGlobalVersion}, []byte{1, 0, 0, 0})),
+		b[:len(b)-1],
+	} {
+		if _, err := ParsePSBT(invalid); err == nil {
+			t.Errorf("invalid psbt %x parsed", invalid)
+		}
+	}
+	p.Version = 1
+	if _, err := p.Serialize(); err == nil {
+		t.Errorf("psbt of version 1 serialized")
+	}
+}
+
+func TestLocktimeV2(t *testing.T) {
+	height, laterHeight, time := uint32(700000), uint32(700010), uint32(1700000000)
+	for i, test := range []struct {
+		inputs   []*PSBTInput
+		locktime uint32
+		ok       bool
+	}{
+		{[]*PSBTInput{{}, {}}, 100, true},
+		{[]*PSBTInput{{RequiredHeightLocktime: &height}, {RequiredHeightLocktime: &laterHeight}}, laterHeight, true},
+		{[]*PSBTInput{{RequiredTimeLocktime: &time}, {}}, time, true},
+		//heights are preferred when every input accepts one
+		{[]*PSBTInput{{RequiredTimeLocktime: &time, RequiredHeightLocktime: &height}}, height, true},
+		{[]*PSBTInput{{RequiredTimeLocktime: &time, RequiredHeightLocktime: &height}, {RequiredTimeLocktime: &time}}, time, true},
+		{[]*PSBTInput{{RequiredTimeLocktime: &time}, {RequiredHeightLocktime: &height}}, 0, false},
+	} {
+		locktime, err := locktimeV2(100, test.inputs)
+		if (err == nil) != test.ok || locktime != test.locktime {
+			t.Errorf("test %d: locktime %d, %v", i, locktime, err)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package btc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+//Combine merges into p the fields of others, PSBTs of the same unsigned tx
+//from other signers, as the BIP-174 combiner. Fields p already has are kept.
+func (p *PSBT) Combine(others ...*PSBT) error {
+	unsigned := p.unsignedTX()
+	for i, other := range others {
+		if !bytes.Equal(other.unsignedTX(), unsigned) {
+			return fmt.Errorf("psbt %d is of another tx", i)
+		}
+		if other.Version != p.Version {
+			return fmt.Errorf("psbt %d is of version %d", i, other.Version)
+		}
+		if len(other.Inputs) != len(p.Inputs) || len(other.Outputs) != len(p.Outputs) {
+			return fmt.Errorf("psbt %d inputs or outputs do not match its tx", i)
+		}
+	}
+	for _, other := range others {
+		p.Unknown = mergeFields(p.Unknown, other.Unknown)
+		for i, input := range p.Inputs {
+			input.merge(other.Inputs[i])
+		}
+		for i, output := range p.Outputs {
+			output.merge(other.Outputs[i])
+		}
+	}
+	//the utxos may come from the others
+	for i, input := range p.Inputs {
+		if err := input.fill(p.TX.Txin[i]); err != nil {
+			return fmt.Errorf("psbt input %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func (input *PSBTInput) merge(other *PSBTInput) {
+	if input.NonWitnessUTXO == nil {
+		input.NonWitnessUTXO = other.NonWitnessUTXO
+	}
+	if input.WitnessUTXO == nil {
+		input.WitnessUTXO = other.WitnessUTXO
+	}
+	if input.RedeemScript == nil {
+		input.RedeemScript = other.RedeemScript
+	}
+	if input.WitnessScript == nil {
+		input.WitnessScript = other.WitnessScript
+	}
+	if input.ScriptType == "" {
+		input.ScriptType = other.ScriptType
+	}
+	if input.SigHashType == nil {
+		input.SigHashType = other.SigHashType
+	}
+	if input.SigHash == nil {
+		input.SigHash = other.SigHash
+	}
+	if input.RequiredTimeLocktime == nil {
+		input.RequiredTimeLocktime = other.RequiredTimeLocktime
+	}
+	if input.RequiredHeightLocktime == nil {
+		input.RequiredHeightLocktime = other.RequiredHeightLocktime
+	}
+	if input.FinalScriptSig == nil {
+		input.FinalScriptSig = other.FinalScriptSig
+	}
+	if input.FinalScriptWitness == nil {
+		input.FinalScriptWitness = other.FinalScriptWitness
+	}
+	if input.TapKeySig == nil {
+		input.TapKeySig = other.TapKeySig
+	}
+	if input.TapInternalKey == nil {
+		input.TapInternalKey = other.TapInternalKey
+	}
+	input.Derivations = mergeDerivations(input.Derivations, other.Derivations)
+	input.TapDerivations = mergeDerivations(input.TapDerivations, other.TapDerivations)
+	input.PartialSigs = mergeFields(input.PartialSigs, other.PartialSigs)
+	input.Unknown = mergeFields(input.Unknown, other.Unknown)
+}
+
+func (output *PSBTOutput) merge(other *PSBTOutput) {
+	if output.RedeemScript == nil {
+		output.RedeemScript = other.RedeemScript
+	}
+	if output.WitnessScript == nil {
+		output.WitnessScript = other.WitnessScript
+	}
+	if output.TapInternalKey == nil {
+		output.TapInternalKey = other.TapInternalKey
+	}
+	output.Derivations = mergeDerivations(output.Derivations, other.Derivations)
+	output.TapDerivations = mergeDerivations(output.TapDerivations, other.TapDerivations)
+	output.Unknown = mergeFields(output.Unknown, other.Unknown)
+}
+
+//mergeFields adds the fields of src missing from dst.
+func mergeFields(dst, src map[string][]byte) map[string][]byte {
+	if dst == nil && len(src) > 0 {
+		dst = make(map[string][]byte, len(src))
+	}
+	for key, value := range src {
+		if _, ok := dst[key]; !ok {
+			dst[key] = value
+		}
+	}
+	return dst
+}
+
+//mergeDerivations adds the derivations of src whose key dst has none of.
+func mergeDerivations(dst, src []*KeyDerivation) []*KeyDerivation {
+	for _, d := range src {
+		found := false
+		for _, known := range dst {
+			if bytes.Equal(known.PubKey, d.PubKey) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, d)
+		}
+	}
+	return dst
+}
+
+//Finalize builds the final scriptSig and witness of every input from its
+//signatures, as the BIP-174 input finalizer, once they are checked against
+//the spent script. P2PKH, P2WPKH, P2SH-P2WPKH and P2TR key path inputs are
+//supported, final inputs are kept. Finalized inputs keep only their utxos,
+//script type, required locktimes and unknown fields. Nothing is finalized if
+//an input fails.
+func (p *PSBT) Finalize() error {
+	if len(p.Inputs) != len(p.TX.Txin) {
+		return errors.New("psbt inputs do not match its tx")
+	}
+	for i, in := range p.TX.Txin {
+		if in.PrevScriptPubkey == nil {
+			return fmt.Errorf("psbt input %d has no utxo", i)
+		}
+	}
+	hashes := NewTXSigHashes(p.TX)
+	scriptSigs := make([][]byte, len(p.Inputs))
+	witnesses := make([][][]byte, len(p.Inputs))
+	for i, input := range p.Inputs {
+		if input.final() {
+			continue
+		}
+		var err error
+		if scriptSigs[i], witnesses[i], err = p.finalizeInput(hashes, i); err != nil {
+			return fmt.Errorf("psbt input %d: %v", i, err)
+		}
+	}
+	for i, input := range p.Inputs {
+		if input.final() {
+			continue
+		}
+		*input = PSBTInput{
+			NonWitnessUTXO:         input.NonWitnessUTXO,
+			WitnessUTXO:            input.WitnessUTXO,
+			ScriptType:             input.ScriptType,
+			RequiredTimeLocktime:   input.RequiredTimeLocktime,
+			RequiredHeightLocktime: input.RequiredHeightLocktime,
+			FinalScriptSig:         scriptSigs[i],
+			FinalScriptWitness:     witnesses[i],
+			Unknown:                input.Unknown,
+		}
+	}
+	return nil
+}
+
+//final reports whether the input has its final scriptSig or witness.
+func (input *PSBTInput) final() bool {
+	return input.FinalScriptSig != nil || input.FinalScriptWitness != nil
+}
+
+//finalizeInput returns the scriptSig and witness spending input i.
+func (p *PSBT) finalizeInput(hashes *TXSigHashes, i int) ([]byte, [][]byte, error) {
+	in, input := p.TX.Txin[i], p.Inputs[i]
+	scriptType := GetScriptType(in.PrevScriptPubkey)
+	if scriptType == ScriptP2TR {
+		sig := input.TapKeySig
+		if sig == nil {
+			return nil, nil, errors.New("no taproot key signature")
+		}
+		hashType := SigHashDefault
+		if len(sig) == 65 {
+			//the default type is only implied by 64 byte signatures
+			if hashType = SigHashType(sig[64]); hashType == SigHashDefault {
+				return nil, nil, errors.New("taproot signature with explicit SIGHASH_DEFAULT")
+			}
+		}
+		if err := input.checkSigHashType(hashType); err != nil {
+			return nil, nil, err
+		}
+		sigHash, err := p.TX.TaprootSigHash(hashes, i, hashType)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !VerifySchnorr(in.PrevScriptPubkey[2:], sigHash, sig[:64]) {
+			return nil, nil, errors.New("invalid taproot key signature")
+		}
+		return nil, [][]byte{sig}, nil
+	}
+	if scriptType != ScriptP2PKH && scriptType != ScriptP2WPKH && scriptType != ScriptP2SH {
+		return nil, nil, fmt.Errorf("%s inputs can not be finalized", scriptType)
+	}
+	//the single key the script pays must have signed
+	for _, pubKey := range sortedKeys(input.PartialSigs) {
+		redeemScript, err := keyScript(in.PrevScriptPubkey, []byte(pubKey))
+		if err != nil {
+			continue
+		}
+		if scriptType == ScriptP2SH && !bytes.Equal(redeemScript, in.RedeemScript) {
+			return nil, nil, fmt.Errorf("redeem script %x does not match", in.RedeemScript)
+		}
+		sig := input.PartialSigs[pubKey]
+		if len(sig) == 0 {
+			return nil, nil, fmt.Errorf("empty signature of key %x", pubKey)
+		}
+		hashType := SigHashType(sig[len(sig)-1])
+		if err := input.checkSigHashType(hashType); err != nil {
+			return nil, nil, err
+		}
+		var sigHash []byte
+		if scriptType == ScriptP2PKH {
+			sigHash, err = p.TX.LegacySigHash(i, hashType)
+		} else {
+			sigHash, err = p.TX.WitnessV0SigHash(hashes, i, hashType)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		key, err := GetPublicKey([]byte(pubKey), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !key.Verify(sigHash, sig[:len(sig)-1]) {
+			return nil, nil, fmt.Errorf("invalid signature of key %x", pubKey)
+		}
+		switch scriptType {
+		case ScriptP2PKH:
+			return append(pushData(sig), pushData([]byte(pubKey))...), nil, nil
+		case ScriptP2SH:
+			return pushData(redeemScript), [][]byte{sig, []byte(pubKey)}, nil
+		default:
+			return nil, [][]byte{sig, []byte(pubKey)}, nil
+		}
+	}
+	return nil, nil, errors.New("no signature of the key the input pays")
+}
+
+//checkSigHashType rejects signatures of another type than the input asks.
+func (input *PSBTInput) checkSigHashType(hashType SigHashType) error {
+	if input.SigHashType != nil && *input.SigHashType != hashType {
+		return fmt.Errorf("signature of sighash type %#x, expected %#x", hashType, *input.SigHashType)
+	}
+	return nil
+}
+
+//Extract returns the signed tx of a finalized PSBT serialized for the
+//network, as the BIP-174 transaction extractor.
+func (p *PSBT) Extract() ([]byte, error) {
+	if len(p.Inputs) != len(p.TX.Txin) {
+		return nil, errors.New("psbt inputs do not match its tx")
+	}
+	tx := *p.TX
+	tx.Txin = nil
+	for i, txin := range p.TX.Txin {
+		input := p.Inputs[i]
+		if !input.final() {
+			return nil, fmt.Errorf("psbt input %d is not finalized", i)
+		}
+		in := *txin
+		in.scriptSig = input.FinalScriptSig
+		in.Witness = input.FinalScriptWitness
+		tx.Txin = append(tx.Txin, &in)
+	}
+	return tx.createRawTransaction(true), nil
+}
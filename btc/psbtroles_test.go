@@ -0,0 +1,208 @@
+package btc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	btcec "github.com/btcsuite/btcd/btcec"
+)
+
+//signTaproot returns the BIP-340 signature of sigHash by the BIP-86 output key
+//of priv, with a nonce derived from the key and message.
+func signTaproot(priv *btcec.PrivateKey, sigHash []byte) []byte {
+	curve := btcec.S256()
+	d := new(big.Int).Set(priv.D)
+	if priv.PublicKey.Y.Bit(0) == 1 {
+		d.Sub(curve.N, d)
+	}
+	x := priv.PubKey().SerializeCompressed()[1:]
+	d.Add(d, new(big.Int).SetBytes(TaggedHash("TapTweak", x)))
+	d.Mod(d, curve.N)
+	qx, qy := curve.ScalarBaseMult(d.Bytes())
+	if qy.Bit(0) == 1 {
+		d.Sub(curve.N, d)
+	}
+	nonce := sha256.Sum256(append(d.Bytes(), sigHash...))
+	k := new(big.Int).Mod(new(big.Int).SetBytes(nonce[:]), curve.N)
+	rx, ry := curve.ScalarBaseMult(k.Bytes())
+	if ry.Bit(0) == 1 {
+		k.Sub(curve.N, k)
+	}
+	r := make([]byte, 32)
+	rx.FillBytes(r)
+	outputKey := make([]byte, 32)
+	qx.FillBytes(outputKey)
+	e := new(big.Int).SetBytes(TaggedHash("BIP0340/challenge", r, outputKey, sigHash))
+	s := e.Mul(e, d)
+	s.Add(s, k)
+	s.Mod(s, curve.N)
+	sig := make([]byte, 64)
+	copy(sig, r)
+	s.FillBytes(sig[32:])
+	return sig
+}
+
+//signPSBTInputs adds the signatures of priv to the inputs of p listed.
+func signPSBTInputs(t *testing.T, p *PSBT, priv *btcec.PrivateKey, inputs ...int) {
+	hashes := NewTXSigHashes(p.TX)
+	pubKey := priv.PubKey().SerializeCompressed()
+	for _, i := range inputs {
+		in := p.TX.Txin[i]
+		var sigHash []byte
+		var err error
+		switch GetScriptType(in.PrevScriptPubkey) {
+		case ScriptP2TR:
+			sigHash, err = p.TX.TaprootSigHash(hashes, i, SigHashDefault)
+			p.Inputs[i].TapKeySig = signTaproot(priv, sigHash)
+			continue
+		case ScriptP2PKH:
+			sigHash, err = p.TX.LegacySigHash(i, SigHashAll)
+		default:
+			sigHash, err = p.TX.WitnessV0SigHash(hashes, i, SigHashAll)
+		}
+		if err != nil {
+			t.Fatalf("sighash of input %d: %v", i, err)
+		}
+		sig, err := priv.Sign(sigHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Inputs[i].PartialSigs = map[string][]byte{string(pubKey): append(sig.Serialize(), byte(SigHashAll))}
+	}
+}
+
+func TestPSBTFinalize(t *testing.T) {
+	//the signed native P2WPKH example of BIP-143, its first input is P2PK and
+	//already final
+	tx := bip143P2WPKHTX()
+	p, err := NewPSBT(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Inputs[0].FinalScriptSig = mustDecodeHex("4830450221008b9d1dc26ba6a9cb62127b02742fa9d754cd3bebf337f7a55d114c8e5cdd30be022040529b194ba3f9281a99f2b1c0a19c0489bc22ede944ccf4ecbab4cc618ef3ed01")
+	pubKey := mustDecodeHex("025476c2e83188368da1ff3e292e7acafcdb3566bb0ad253f62fc70f07aeee6357")
+	sig := mustDecodeHex("304402203609e17b84f6a7d30c80bfa610b5b4542f32a8a0d5447a12fb1366d7f01cc44a0220573a954c4518331561406f90300e8f3358f51928d43c212a8caed02de67eebee01")
+	p.Inputs[1].PartialSigs = map[string][]byte{string(pubKey): sig}
+	if _, err := p.Extract(); err == nil {
+		t.Errorf("psbt extracted before it is final")
+	}
+	if err := p.Finalize(); err != nil {
+		t.Fatalf("finalize: %v", err)
+	}
+	if len(p.Inputs[1].PartialSigs) != 0 || p.Inputs[1].WitnessUTXO == nil {
+		t.Errorf("finalized input %+v", p.Inputs[1])
+	}
+	rawtx, err := p.Extract()
+	if err != nil || hex.EncodeToString(rawtx) != bip143SignedTX {
+		t.Errorf("extracted tx %x %v", rawtx, err)
+	}
+
+	//a signature of another tx is rejected
+	p, _ = NewPSBT(tx)
+	p.Inputs[0].FinalScriptSig = []byte{0}
+	p.TX.Locktime++
+	p.Inputs[1].PartialSigs = map[string][]byte{string(pubKey): sig}
+	if err := p.Finalize(); err == nil {
+		t.Errorf("invalid signature finalized")
+	}
+	//or of another sighash type than the input asks
+	p.TX.Locktime--
+	single := SigHashSingle
+	p.Inputs[1].SigHashType = &single
+	if err := p.Finalize(); err == nil {
+		t.Errorf("signature of another sighash type finalized")
+	}
+	//as is the signature of another key
+	p.Inputs[1].SigHashType = nil
+	p.Inputs[1].PartialSigs = map[string][]byte{string(mustDecodeHex("03c9f4836b9a4f77fc0d81f7bcb01b7f1b35916864b9476c241ce9fc198bd25432")): sig}
+	if err := p.Finalize(); err == nil {
+		t.Errorf("signature of another key finalized")
+	}
+}
+
+func TestPSBTCombine(t *testing.T) {
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{7}, 32))
+	pubKey := priv.PubKey().SerializeCompressed()
+	tx, prevTX := psbtTestTX(pubKey)
+	p, err := NewPSBT(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := &KeyDerivation{PubKey: pubKey, Fingerprint: []byte{1, 2, 3, 4}, Path: []uint32{0}}
+	for i := range p.Inputs {
+		p.SetInputKey(i, key)
+	}
+	b, _ := p.Serialize()
+
+	//two signers get the PSBT, the first without the utxo of the P2PKH input
+	first, _ := ParsePSBT(b)
+	second, _ := ParsePSBT(b)
+	if err := second.SetNonWitnessUTXO(0, prevTX); err != nil {
+		t.Fatal(err)
+	}
+	//taproot signatures commit to all spent outputs
+	signPSBTInputs(t, first, priv, 1, 2)
+	signPSBTInputs(t, second, priv, 0, 3)
+	second.Unknown["\x0a"] = []byte{1}
+	if err := second.Finalize(); err == nil {
+		t.Errorf("psbt finalized without all signatures")
+	}
+	if second.Inputs[0].final() {
+		t.Errorf("input finalized although another failed")
+	}
+	if err := first.Combine(second); err != nil {
+		t.Fatalf("combine: %v", err)
+	}
+	if first.TX.Txin[0].Amount != 50000 || !bytes.Equal(first.Unknown["\x0a"], []byte{1}) {
+		t.Errorf("combined psbt lacks the fields of the second")
+	}
+	//the combined PSBT serializes as the one of the other order
+	second.Combine(first)
+	b1, _ := first.Serialize()
+	b2, _ := second.Serialize()
+	if !bytes.Equal(b1, b2) {
+		t.Errorf("combined psbts differ\n%x\n%x", b1, b2)
+	}
+
+	if err := first.Finalize(); err != nil {
+		t.Fatalf("finalize: %v", err)
+	}
+	for i, input := range first.Inputs {
+		if len(input.PartialSigs) != 0 || input.TapKeySig != nil || input.Derivations != nil || input.TapDerivations != nil || input.RedeemScript != nil {
+			t.Errorf("finalized input %d keeps %+v", i, input)
+		}
+	}
+	if len(first.Inputs[0].FinalScriptSig) == 0 || first.Inputs[0].FinalScriptWitness != nil {
+		t.Errorf("p2pkh input is not spent by scriptSig")
+	}
+	if !bytes.Equal(first.Inputs[2].FinalScriptSig, pushData(tx.Txin[2].RedeemScript)) || len(first.Inputs[2].FinalScriptWitness) != 2 {
+		t.Errorf("p2sh-p2wpkh input %+v", first.Inputs[2])
+	}
+	if len(first.Inputs[3].FinalScriptWitness) != 1 || len(first.Inputs[3].FinalScriptWitness[0]) != 64 {
+		t.Errorf("p2tr input %+v", first.Inputs[3])
+	}
+	rawtx, err := first.Extract()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := ParseTX(rawtx)
+	if err != nil || len(signed.Txin) != 4 || bytes.Equal(signed.TXID(), signed.WTXID()) {
+		t.Errorf("extracted tx %x %v", rawtx, err)
+	}
+
+	//PSBTs of other txs are not combined
+	tx.Locktime = 1
+	other, _ := NewPSBT(tx)
+	if err := first.Combine(other); err == nil {
+		t.Errorf("psbt of another tx combined")
+	}
+	//a taproot signature tampered with is rejected
+	signPSBTInputs(t, other, priv, 0, 1, 2, 3)
+	other.Inputs[3].TapKeySig[0] ^= 1
+	if err := other.Finalize(); err == nil {
+		t.Errorf("invalid taproot signature finalized")
+	}
+}
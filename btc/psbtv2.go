@@ -0,0 +1,216 @@
+package btc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+//key types of BIP-370 PSBT version 2 fields, where the unsigned tx is spread
+//over the global, input and output maps
+const (
+	psbtGlobalTXVersion        = 0x02
+	psbtGlobalFallbackLocktime = 0x03
+	psbtGlobalInputCount       = 0x04
+	psbtGlobalOutputCount      = 0x05
+	psbtGlobalVersion          = 0xfb
+
+	psbtInPreviousTXID           = 0x0e
+	psbtInOutputIndex            = 0x0f
+	psbtInSequence               = 0x10
+	psbtInRequiredTimeLocktime   = 0x11
+	psbtInRequiredHeightLocktime = 0x12
+
+	psbtOutAmount = 0x03
+	psbtOutScript = 0x04
+
+	//locktimeThreshold separates block heights from unix times in locktimes
+	locktimeThreshold = 500000000
+)
+
+//psbtV2GlobalKeyTypes are the global fields only version 2 has
+var psbtV2GlobalKeyTypes = map[byte]bool{
+	psbtGlobalTXVersion: true, psbtGlobalFallbackLocktime: true,
+	psbtGlobalInputCount: true, psbtGlobalOutputCount: true,
+}
+
+//writeGlobalsV2 writes the tx fields of the global map of version 2, the
+//locktime of tx as the fallback one.
+func writeGlobalsV2(buffer *bytes.Buffer, tx *TX) {
+	var value bytes.Buffer
+	writeUint32(&value, tx.Version)
+	writePSBTEntry(buffer, []byte{psbtGlobalTXVersion}, value.Bytes())
+	value.Reset()
+	writeUint32(&value, tx.Locktime)
+	writePSBTEntry(buffer, []byte{psbtGlobalFallbackLocktime}, value.Bytes())
+	writePSBTEntry(buffer, []byte{psbtGlobalInputCount}, toVI(uint64(len(tx.Txin))))
+	writePSBTEntry(buffer, []byte{psbtGlobalOutputCount}, toVI(uint64(len(tx.Txout))))
+	value.Reset()
+	writeUint32(&value, 2)
+	writePSBTEntry(buffer, []byte{psbtGlobalVersion}, value.Bytes())
+}
+
+//writeInputV2 writes the outpoint, sequence and required locktimes of an
+//input of version 2.
+func writeInputV2(buffer *bytes.Buffer, txin *TXin, in *PSBTInput) {
+	writePSBTEntry(buffer, []byte{psbtInPreviousTXID}, reverseBytes(txin.Hash))
+	var value bytes.Buffer
+	writeUint32(&value, txin.Index)
+	writePSBTEntry(buffer, []byte{psbtInOutputIndex}, value.Bytes())
+	value.Reset()
+	writeUint32(&value, txin.Sequence)
+	writePSBTEntry(buffer, []byte{psbtInSequence}, value.Bytes())
+	if in.RequiredTimeLocktime != nil {
+		value.Reset()
+		writeUint32(&value, *in.RequiredTimeLocktime)
+		writePSBTEntry(buffer, []byte{psbtInRequiredTimeLocktime}, value.Bytes())
+	}
+	if in.RequiredHeightLocktime != nil {
+		value.Reset()
+		writeUint32(&value, *in.RequiredHeightLocktime)
+		writePSBTEntry(buffer, []byte{psbtInRequiredHeightLocktime}, value.Bytes())
+	}
+}
+
+//writeOutputV2 writes the amount and script of an output of version 2.
+func writeOutputV2(buffer *bytes.Buffer, out *TXout) {
+	var value bytes.Buffer
+	writeUint64(&value, out.Value)
+	writePSBTEntry(buffer, []byte{psbtOutAmount}, value.Bytes())
+	writePSBTEntry(buffer, []byte{psbtOutScript}, out.ScriptPubkey)
+}
+
+//parseGlobalsV2 makes the tx of a version 2 PSBT from its global fields,
+//without inputs and outputs yet, and returns their counts. The fields are
+//removed from global.
+func parseGlobalsV2(global map[string][]byte) (tx *TX, nIn, nOut uint64, err error) {
+	tx = &TX{}
+	for _, keyType := range []byte{psbtGlobalTXVersion, psbtGlobalInputCount, psbtGlobalOutputCount} {
+		if _, ok := global[string([]byte{keyType})]; !ok {
+			return nil, 0, 0, fmt.Errorf("psbt v2 without global field %#x", keyType)
+		}
+	}
+	for key, value := range global {
+		if !psbtV2GlobalKeyTypes[key[0]] {
+			continue
+		}
+		r := &txReader{b: value}
+		switch {
+		case len(key) != 1:
+			return nil, 0, 0, fmt.Errorf("invalid psbt key %x", key)
+		case key[0] == psbtGlobalTXVersion:
+			tx.Version = r.uint32()
+		case key[0] == psbtGlobalFallbackLocktime:
+			tx.Locktime = r.uint32()
+		case key[0] == psbtGlobalInputCount:
+			nIn = r.readVI()
+		case key[0] == psbtGlobalOutputCount:
+			nOut = r.readVI()
+		}
+		if r.err != nil || len(r.b) != 0 {
+			return nil, 0, 0, fmt.Errorf("invalid psbt global field %x", key)
+		}
+		delete(global, key)
+	}
+	return tx, nIn, nOut, nil
+}
+
+//takeInputV2 removes the version 2 fields from the map of an input and
+//returns the tx input they make, with the required locktimes.
+func takeInputV2(m map[string][]byte) (txin *TXin, timeLock, heightLock *uint32, err error) {
+	txin = &TXin{Sequence: 0xffffffff}
+	hash, ok := m[string([]byte{psbtInPreviousTXID})]
+	if !ok || len(hash) != 32 {
+		return nil, nil, nil, errors.New("no 32 byte previous txid")
+	}
+	txin.Hash = reverseBytes(hash)
+	delete(m, string([]byte{psbtInPreviousTXID}))
+	if _, ok := m[string([]byte{psbtInOutputIndex})]; !ok {
+		return nil, nil, nil, errors.New("no output index")
+	}
+	for _, keyType := range []byte{psbtInOutputIndex, psbtInSequence, psbtInRequiredTimeLocktime, psbtInRequiredHeightLocktime} {
+		value, ok := m[string([]byte{keyType})]
+		if !ok {
+			continue
+		}
+		if len(value) != 4 {
+			return nil, nil, nil, fmt.Errorf("invalid field %#x length %d", keyType, len(value))
+		}
+		n := (&txReader{b: value}).uint32()
+		switch keyType {
+		case psbtInOutputIndex:
+			txin.Index = n
+		case psbtInSequence:
+			txin.Sequence = n
+		case psbtInRequiredTimeLocktime:
+			if n < locktimeThreshold {
+				return nil, nil, nil, fmt.Errorf("required time locktime %d is a height", n)
+			}
+			timeLock = &n
+		case psbtInRequiredHeightLocktime:
+			if n == 0 || n >= locktimeThreshold {
+				return nil, nil, nil, fmt.Errorf("invalid required height locktime %d", n)
+			}
+			heightLock = &n
+		}
+		delete(m, string([]byte{keyType}))
+	}
+	return txin, timeLock, heightLock, nil
+}
+
+//takeOutputV2 removes the amount and script from the map of a version 2
+//output and returns the tx output they make.
+func takeOutputV2(m map[string][]byte) (*TXout, error) {
+	amount, ok := m[string([]byte{psbtOutAmount})]
+	if !ok || len(amount) != 8 {
+		return nil, errors.New("no 8 byte amount")
+	}
+	script, ok := m[string([]byte{psbtOutScript})]
+	if !ok {
+		return nil, errors.New("no script")
+	}
+	delete(m, string([]byte{psbtOutAmount}))
+	delete(m, string([]byte{psbtOutScript}))
+	return &TXout{Value: (&txReader{b: amount}).uint64(), ScriptPubkey: script}, nil
+}
+
+//locktimeV2 determines the locktime of a version 2 PSBT as BIP-370 does: the
+//fallback if no input requires one, otherwise the largest required locktime
+//of the kind all inputs accept, heights over times.
+func locktimeV2(fallback uint32, inputs []*PSBTInput) (uint32, error) {
+	required, allHeights, allTimes := false, true, true
+	var height, time uint32
+	for _, in := range inputs {
+		if in.RequiredHeightLocktime == nil && in.RequiredTimeLocktime == nil {
+			continue
+		}
+		required = true
+		if in.RequiredHeightLocktime == nil {
+			allHeights = false
+		} else if *in.RequiredHeightLocktime > height {
+			height = *in.RequiredHeightLocktime
+		}
+		if in.RequiredTimeLocktime == nil {
+			allTimes = false
+		} else if *in.RequiredTimeLocktime > time {
+			time = *in.RequiredTimeLocktime
+		}
+	}
+	switch {
+	case !required:
+		return fallback, nil
+	case allHeights:
+		return height, nil
+	case allTimes:
+		return time, nil
+	}
+	return 0, errors.New("psbt inputs require both a height and a time locktime")
+}
+
+func reverseBytes(b []byte) []byte {
+	ret := make([]byte, len(b))
+	for i, c := range b {
+		ret[len(b)-i-1] = c
+	}
+	return ret
+}
@@ -0,0 +1,318 @@
+package btc
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+const (
+	testAddr   = "1BoatSLRHtKNngkdXEeobR76b53LETtpyT"
+	testScript = "76a914" + "7680adec8eabcabac676be9e83854ade0bd22cdb" + "88ac"
+	testTXID   = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+)
+
+func TestParseAmount(t *testing.T) {
+	cases := map[string]uint64{
+		"0":           0,
+		"1":           100000000,
+		"0.00000001":  1,
+		"0.1":         10000000,
+		"20999999.99": 2099999999000000,
+		"1.23456789":  123456789,
+	}
+	for amount, expected := range cases {
+		satoshi, err := parseAmount(amount)
+		if err != nil || satoshi != expected {
+			t.Errorf("amount %s: got %d %v, expected %d", amount, satoshi, err, expected)
+		}
+	}
+	for _, amount := range []string{"", "0.000000001", "-1", "1e-8", "abc"} {
+		if _, err := parseAmount(amount); err == nil {
+			t.Errorf("amount %q should be rejected", amount)
+		}
+	}
+}
+
+func TestAddressScript(t *testing.T) {
+	script, err := AddressScript(testAddr)
+	if err != nil || hex.EncodeToString(script) != testScript {
+		t.Errorf("p2pkh script not matched: %x %v", script, err)
+	}
+	script, err = AddressScript("3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy")
+	if err != nil || hex.EncodeToString(script) != "a914b472a266d0bd89c13706a4132ccfb16f7c3b9fcb87" {
+		t.Errorf("p2sh script not matched: %x %v", script, err)
+	}
+	for addr, expected := range map[string]string{
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4":                     "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+		"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7": "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262",
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0": "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+	} {
+		script, err := AddressScript(addr)
+		if err != nil || hex.EncodeToString(script) != expected {
+			t.Errorf("segwit script of %s not matched: %x %v", addr, script, err)
+		}
+	}
+	script, err = CreateP2SHScriptPubkey("3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy")
+	if err != nil || hex.EncodeToString(script) != "a914b472a266d0bd89c13706a4132ccfb16f7c3b9fcb87" {
+		t.Errorf("p2sh script not matched: %x %v", script, err)
+	}
+	if _, err := CreateP2SHScriptPubkey(testAddr); err == nil {
+		t.Errorf("p2pkh address accepted as p2sh")
+	}
+	hash, _ := hex.DecodeString("751e76e8199196d454941c45d1b3a323f1433bd6")
+	if script := P2WPKHRedeemScript(hash); hex.EncodeToString(script) != "0014751e76e8199196d454941c45d1b3a323f1433bd6" {
+		t.Errorf("p2wpkh redeem script not matched: %x", script)
+	}
+	//a v0 program with the bech32m checksum
+	if _, err := AddressScript("bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh"); err == nil {
+		t.Errorf("invalid segwit address accepted")
+	}
+}
+
+func TestNewService(t *testing.T) {
+	for _, backend := range []string{BackendBitcoind, BackendElectrum, BackendEsplora} {
+		if _, err := NewService(ServiceConfig{Backend: backend, URL: "127.0.0.1:1"}); err != nil {
+			t.Errorf("create %s service error: %v", backend, err)
+		}
+	}
+	if _, err := NewService(ServiceConfig{Backend: "blockr", URL: "http://btc.blockr.io"}); err == nil {
+		t.Errorf("unknown backend should be rejected")
+	}
+	if _, err := NewService(ServiceConfig{Backend: BackendEsplora}); err == nil {
+		t.Errorf("missing url should be rejected")
+	}
+}
+
+func checkUTXO(t *testing.T, name string, utxos UTXOs, age uint64) {
+	if len(utxos) != 1 {
+		t.Fatalf("%s: expected 1 utxo, got %d", name, len(utxos))
+	}
+	u := utxos[0]
+	if hex.EncodeToString(u.Hash) != testTXID || u.Index != 1 || u.Amount != 150000 ||
+		hex.EncodeToString(u.Script) != testScript || u.Age != age || u.Addr != testAddr {
+		t.Errorf("%s: unexpected utxo %+v", name, u)
+	}
+}
+
+func TestEsploraService(t *testing.T) {
+	var tips int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/address/"+testAddr+"/utxo":
+			w.Write([]byte(`[{"txid":"` + testTXID + `","vout":1,"value":150000,"status":{"confirmed":true,"block_height":100}}]`))
+		case r.Method == "GET" && r.URL.Path == "/api/blocks/tip/height":
+			atomic.AddInt32(&tips, 1)
+			w.Write([]byte("105"))
+		case r.Method == "GET" && r.URL.Path == "/api/tx/"+testTXID+"/hex":
+			w.Write([]byte("0100\n"))
+		case r.Method == "GET" && r.URL.Path == "/api/fee-estimates":
+			w.Write([]byte(`{"1":20.5,"3":12.1,"6":8.0,"144":0.8}`))
+		case r.Method == "POST" && r.URL.Path == "/api/tx":
+			body, _ := ioutil.ReadAll(r.Body)
+			if string(body) != "0100" {
+				http.Error(w, "bad tx", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(testTXID))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	service := NewEsploraService(server.URL + "/api/")
+	utxos, err := service.GetUTXO(testAddr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkUTXO(t, "esplora", utxos, 6)
+	//the cache passes the tip it checked instead of fetching it twice
+	atomic.StoreInt32(&tips, 0)
+	utxos, err = NewUTXOCache(service, 0, 0).GetUTXO(testAddr, nil)
+	if err != nil || atomic.LoadInt32(&tips) != 1 {
+		t.Errorf("cached esplora utxos fetched the tip %d times: %v", tips, err)
+	}
+	checkUTXO(t, "cached esplora", utxos, 6)
+	for blocks, expected := range map[int]uint64{1: 21, 2: 21, 6: 8, 10: 8, 1000: 1} {
+		if rate, err := service.EstimateFeeRate(blocks); err != nil || rate != expected {
+			t.Errorf("fee rate for %d blocks: got %d %v, expected %d", blocks, rate, err, expected)
+		}
+	}
+	txid, err := service.SendTX([]byte{1, 0})
+	if err != nil || hex.EncodeToString(txid) != testTXID {
+		t.Errorf("send tx: %x %v", txid, err)
+	}
+	if rawtx, err := service.GetRawTX(txid); err != nil || hex.EncodeToString(rawtx) != "0100" {
+		t.Errorf("get raw tx: %x %v", rawtx, err)
+	}
+	if _, err := service.SendTX([]byte{2}); err == nil {
+		t.Errorf("rejected tx should return an error")
+	}
+}
+
+func TestBitcoindService(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pwd, ok := r.BasicAuth(); !ok || user != "user" || pwd != "pwd" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			ID     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Error(err)
+			return
+		}
+		unspent := `{"txid":"` + testTXID + `","vout":1,"scriptPubKey":"` + testScript + `","amount":0.0015,"height":100,"confirmations":3}`
+		switch req.Method {
+		case "scantxoutset":
+			if string(req.Params[1]) != `["addr(`+testAddr+`)"]` {
+				t.Errorf("unexpected scan descriptor: %s", req.Params[1])
+			}
+			w.Write([]byte(`{"result":{"success":true,"height":105,"unspents":[` + unspent + `]},"error":null,"id":1}`))
+		case "listunspent":
+			w.Write([]byte(`{"result":[` + unspent + `],"error":null,"id":1}`))
+		case "estimatesmartfee":
+			if string(req.Params[0]) == "1" {
+				w.Write([]byte(`{"result":{"errors":["Insufficient data or no feerate found"],"blocks":2},"error":null,"id":1}`))
+				return
+			}
+			w.Write([]byte(`{"result":{"feerate":0.00012345,"blocks":6},"error":null,"id":1}`))
+		case "getrawtransaction":
+			w.Write([]byte(`{"result":"0100","error":null,"id":1}`))
+		case "gettransaction":
+			w.Write([]byte(`{"result":{"txid":` + string(req.Params[0]) + `,"hex":"0200"},"error":null,"id":1}`))
+		case "sendrawtransaction":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"result":null,"error":{"code":-25,"message":"bad-txns-inputs-missingorspent"},"id":1}`))
+		}
+	}))
+	defer server.Close()
+
+	service := NewBitcoindService(server.URL, "user", "pwd", false)
+	utxos, err := service.GetUTXO(testAddr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkUTXO(t, "bitcoind scan", utxos, 6)
+	service = NewBitcoindService(server.URL, "user", "pwd", true)
+	utxos, err = service.GetUTXO(testAddr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkUTXO(t, "bitcoind wallet", utxos, 3)
+	//0.00012345 BTC/kvB is 12.345 sat/vB
+	if rate, err := service.EstimateFeeRate(6); err != nil || rate != 13 {
+		t.Errorf("fee rate: got %d %v, expected 13", rate, err)
+	}
+	if _, err := service.EstimateFeeRate(1); err == nil {
+		t.Errorf("missing fee estimate should be an error")
+	}
+	txid, _ := hex.DecodeString(testTXID)
+	//the wallet knows its own txs without -txindex
+	if rawtx, err := service.GetRawTX(txid); err != nil || hex.EncodeToString(rawtx) != "0200" {
+		t.Errorf("get wallet tx: %x %v", rawtx, err)
+	}
+	if rawtx, err := NewBitcoindService(server.URL, "user", "pwd", false).GetRawTX(txid); err != nil || hex.EncodeToString(rawtx) != "0100" {
+		t.Errorf("get raw tx: %x %v", rawtx, err)
+	}
+	_, err = service.SendTX([]byte{1, 0})
+	if e, ok := err.(*BitcoindError); !ok || e.Code != -25 {
+		t.Errorf("expected bitcoind error, got %v", err)
+	}
+	if _, err := NewBitcoindService(server.URL, "user", "wrong", false).GetUTXO(testAddr, nil); err == nil {
+		t.Errorf("unauthorized request should fail")
+	}
+}
+
+// serveElectrum answers electrum requests on l until it is closed
+func serveElectrum(t *testing.T, l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			r := bufio.NewReader(conn)
+			for {
+				line, err := r.ReadBytes('\n')
+				if err != nil {
+					return
+				}
+				var req struct {
+					ID     uint64        `json:"id"`
+					Method string        `json:"method"`
+					Params []interface{} `json:"params"`
+				}
+				if err := json.Unmarshal(line, &req); err != nil {
+					t.Error(err)
+					return
+				}
+				var result string
+				switch req.Method {
+				case "server.version":
+					result = `["fake", "1.4"]`
+				case "blockchain.headers.subscribe":
+					result = `{"height":105,"hex":""}`
+				case "blockchain.scripthash.listunspent":
+					//sha256 of the p2pkh script, reversed
+					if req.Params[0] != "ce9302be003e28b6a7b711c4694263d88bfacf576fed1c663149b75b00016e3b" {
+						conn.Write([]byte(`{"jsonrpc":"2.0","id":` + strconv.FormatUint(req.ID, 10) + `,"error":{"code":1,"message":"unknown script hash"}}` + "\n"))
+						continue
+					}
+					//a notification arrives before the answer
+					conn.Write([]byte(`{"jsonrpc":"2.0","method":"blockchain.headers.subscribe","params":[{"height":106}]}` + "\n"))
+					result = `[{"tx_hash":"` + testTXID + `","tx_pos":1,"height":100,"value":150000}]`
+				case "blockchain.estimatefee":
+					result = "0.00002"
+				case "blockchain.transaction.get":
+					result = `"0100"`
+				case "blockchain.transaction.broadcast":
+					result = `"` + testTXID + `"`
+				}
+				conn.Write([]byte(`{"jsonrpc":"2.0","id":` + strconv.FormatUint(req.ID, 10) + `,"result":` + result + "}\n"))
+			}
+		}(conn)
+	}
+}
+
+func TestElectrumService(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go serveElectrum(t, l)
+
+	service := NewElectrumService(l.Addr().String(), false)
+	utxos, err := service.GetUTXO(testAddr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkUTXO(t, "electrum", utxos, 6)
+	if rate, err := service.EstimateFeeRate(6); err != nil || rate != 2 {
+		t.Errorf("fee rate: got %d %v, expected 2", rate, err)
+	}
+	txid, err := service.SendTX([]byte{1, 0})
+	if err != nil || hex.EncodeToString(txid) != testTXID {
+		t.Errorf("send tx: %x %v", txid, err)
+	}
+	if rawtx, err := service.GetRawTX(txid); err != nil || hex.EncodeToString(rawtx) != "0100" {
+		t.Errorf("get raw tx: %x %v", rawtx, err)
+	}
+	if _, err := service.GetUTXO("3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", nil); err == nil {
+		t.Errorf("electrum error should be returned")
+	}
+}
@@ -0,0 +1,357 @@
+package btc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+//SigHashType selects the parts of a tx a signature commits to.
+type SigHashType uint32
+
+//sighash flags, SigHashAnyoneCanPay is combined with one of the others
+const (
+	//SigHashDefault is the taproot default, committing to the same as
+	//SigHashAll without a type byte appended to the signature
+	SigHashDefault      SigHashType = 0x00
+	SigHashAll          SigHashType = 0x01
+	SigHashNone         SigHashType = 0x02
+	SigHashSingle       SigHashType = 0x03
+	SigHashAnyoneCanPay SigHashType = 0x80
+)
+
+func (t SigHashType) outputs() SigHashType {
+	return t & 0x03
+}
+
+func (t SigHashType) anyoneCanPay() bool {
+	return t&SigHashAnyoneCanPay != 0
+}
+
+//checkSigHashType rejects the types not standard for the kind of input,
+//SigHashDefault is only defined for taproot.
+func checkSigHashType(t SigHashType, taproot bool) error {
+	switch t &^ SigHashAnyoneCanPay {
+	case SigHashAll, SigHashNone, SigHashSingle:
+		return nil
+	case SigHashDefault:
+		if taproot && t == SigHashDefault {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid sighash type 0x%02x", uint32(t))
+}
+
+//TXSigHashes holds the hashes of a tx shared by the signature hashes of all
+//its inputs. Computing them once makes hashing every input of a tx linear in
+//its size instead of quadratic.
+type TXSigHashes struct {
+	//BIP-143, double SHA256
+	HashPrevouts []byte
+	HashSequence []byte
+	HashOutputs  []byte
+
+	//BIP-341, single SHA256
+	SHAPrevouts      []byte
+	SHAAmounts       []byte
+	SHAScriptPubkeys []byte
+	SHASequences     []byte
+	SHAOutputs       []byte
+}
+
+//NewTXSigHashes computes the shared hashes of tx, which must not change
+//afterwards.
+func NewTXSigHashes(tx *TX) *TXSigHashes {
+	var prevouts, amounts, scripts, sequences, outputs bytes.Buffer
+	for _, in := range tx.Txin {
+		writeOutPoint(&prevouts, in)
+		writeUint64(&amounts, in.Amount)
+		writeScript(&scripts, in.PrevScriptPubkey)
+		writeUint32(&sequences, in.Sequence)
+	}
+	for _, out := range tx.outputs() {
+		writeTXout(&outputs, out)
+	}
+	h := &TXSigHashes{
+		SHAPrevouts:      sha256Sum(prevouts.Bytes()),
+		SHAAmounts:       sha256Sum(amounts.Bytes()),
+		SHAScriptPubkeys: sha256Sum(scripts.Bytes()),
+		SHASequences:     sha256Sum(sequences.Bytes()),
+		SHAOutputs:       sha256Sum(outputs.Bytes()),
+	}
+	//a double SHA256 is the SHA256 of the single one
+	h.HashPrevouts = sha256Sum(h.SHAPrevouts)
+	h.HashSequence = sha256Sum(h.SHASequences)
+	h.HashOutputs = sha256Sum(h.SHAOutputs)
+	return h
+}
+
+//SigHashes returns the hash to sign of every input of tx by hashType, computed
+//by the algorithm of the script the input spends: BIP-143 for P2WPKH and
+//P2SH-P2WPKH, BIP-341 key path for P2TR and legacy otherwise. SigHashDefault
+//means SigHashAll for the inputs other than taproot.
+func (tx *TX) SigHashes(hashType SigHashType) ([][]byte, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	hashes := NewTXSigHashes(tx)
+	ret := make([][]byte, len(tx.Txin))
+	for i, in := range tx.Txin {
+		t := hashType
+		var err error
+		switch GetScriptType(in.PrevScriptPubkey) {
+		case ScriptP2TR:
+			ret[i], err = tx.TaprootSigHash(hashes, i, t)
+		case ScriptP2WPKH:
+			if t == SigHashDefault {
+				t = SigHashAll
+			}
+			ret[i], err = tx.WitnessV0SigHash(hashes, i, t)
+		default:
+			if t == SigHashDefault {
+				t = SigHashAll
+			}
+			if in.isNestedWitness() {
+				ret[i], err = tx.WitnessV0SigHash(hashes, i, t)
+			} else {
+				ret[i], err = tx.LegacySigHash(i, t)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("sighash of input %d: %v", i, err)
+		}
+	}
+	return ret, nil
+}
+
+//LegacySigHash returns the pre-segwit hash to sign of input i, the double
+//SHA256 of a copy of tx trimmed by hashType with the script the input spends
+//in place of its scriptSig. Unlike the segwit hashes it serializes the tx for
+//every input.
+func (tx *TX) LegacySigHash(i int, hashType SigHashType) ([]byte, error) {
+	if i < 0 || i >= len(tx.Txin) {
+		return nil, fmt.Errorf("input %d out of range", i)
+	}
+	if err := checkSigHashType(hashType, false); err != nil {
+		return nil, err
+	}
+	outputs := tx.outputs()
+	if hashType.outputs() == SigHashSingle && i >= len(outputs) {
+		//consensus signs the number one when there is no matching output
+		one := make([]byte, 32)
+		one[0] = 0x01
+		return one, nil
+	}
+	script := tx.Txin[i].PrevScriptPubkey
+	if GetScriptType(script) == ScriptP2SH && tx.Txin[i].RedeemScript != nil {
+		script = tx.Txin[i].RedeemScript
+	}
+
+	var buffer bytes.Buffer
+	writeUint32(&buffer, tx.version())
+	ins := tx.Txin
+	if hashType.anyoneCanPay() {
+		ins = tx.Txin[i : i+1]
+	}
+	buffer.Write(toVI(uint64(len(ins))))
+	for _, in := range ins {
+		writeOutPoint(&buffer, in)
+		sequence := in.Sequence
+		if in == tx.Txin[i] {
+			writeScript(&buffer, script)
+		} else {
+			buffer.WriteByte(0)
+			//other inputs may be replaced unless all outputs are signed
+			if hashType.outputs() != SigHashAll {
+				sequence = 0
+			}
+		}
+		writeUint32(&buffer, sequence)
+	}
+	switch hashType.outputs() {
+	case SigHashNone:
+		buffer.Write(toVI(0))
+	case SigHashSingle:
+		buffer.Write(toVI(uint64(i + 1)))
+		for n := 0; n < i; n++ {
+			writeUint64(&buffer, ^uint64(0))
+			buffer.WriteByte(0)
+		}
+		writeTXout(&buffer, outputs[i])
+	default:
+		buffer.Write(toVI(uint64(len(outputs))))
+		for _, out := range outputs {
+			writeTXout(&buffer, out)
+		}
+	}
+	writeUint32(&buffer, tx.Locktime)
+	writeUint32(&buffer, uint32(hashType))
+	return sha256Sum(sha256Sum(buffer.Bytes())), nil
+}
+
+//WitnessV0SigHash returns the BIP-143 hash to sign of input i, which spends
+//a P2WPKH output or a P2SH-P2WPKH one with its RedeemScript set. hashes may
+//be nil, to hash more than one input they should be shared.
+func (tx *TX) WitnessV0SigHash(hashes *TXSigHashes, i int, hashType SigHashType) ([]byte, error) {
+	if i < 0 || i >= len(tx.Txin) {
+		return nil, fmt.Errorf("input %d out of range", i)
+	}
+	if err := checkSigHashType(hashType, false); err != nil {
+		return nil, err
+	}
+	in := tx.Txin[i]
+	scriptCode, err := in.witnessV0ScriptCode()
+	if err != nil {
+		return nil, err
+	}
+	if hashes == nil {
+		hashes = NewTXSigHashes(tx)
+	}
+	zero := make([]byte, 32)
+
+	var buffer bytes.Buffer
+	writeUint32(&buffer, tx.version())
+	if hashType.anyoneCanPay() {
+		buffer.Write(zero)
+	} else {
+		buffer.Write(hashes.HashPrevouts)
+	}
+	if hashType.anyoneCanPay() || hashType.outputs() != SigHashAll {
+		buffer.Write(zero)
+	} else {
+		buffer.Write(hashes.HashSequence)
+	}
+	writeOutPoint(&buffer, in)
+	writeScript(&buffer, scriptCode)
+	writeUint64(&buffer, in.Amount)
+	writeUint32(&buffer, in.Sequence)
+	outputs := tx.outputs()
+	switch {
+	case hashType.outputs() == SigHashAll:
+		buffer.Write(hashes.HashOutputs)
+	case hashType.outputs() == SigHashSingle && i < len(outputs):
+		var out bytes.Buffer
+		writeTXout(&out, outputs[i])
+		buffer.Write(sha256Sum(sha256Sum(out.Bytes())))
+	default:
+		buffer.Write(zero)
+	}
+	writeUint32(&buffer, tx.Locktime)
+	writeUint32(&buffer, uint32(hashType))
+	return sha256Sum(sha256Sum(buffer.Bytes())), nil
+}
+
+//TaprootSigHash returns the BIP-341 hash to sign of input i spending a P2TR
+//output by key path. It commits to the amounts and scripts of all inputs, so
+//every input must have them set. hashes may be nil, to hash more than one
+//input they should be shared.
+func (tx *TX) TaprootSigHash(hashes *TXSigHashes, i int, hashType SigHashType) ([]byte, error) {
+	if i < 0 || i >= len(tx.Txin) {
+		return nil, fmt.Errorf("input %d out of range", i)
+	}
+	if err := checkSigHashType(hashType, true); err != nil {
+		return nil, err
+	}
+	outputs := tx.outputs()
+	if hashType.outputs() == SigHashSingle && i >= len(outputs) {
+		return nil, fmt.Errorf("no output %d for SIGHASH_SINGLE", i)
+	}
+	if hashes == nil {
+		hashes = NewTXSigHashes(tx)
+	}
+	in := tx.Txin[i]
+
+	var buffer bytes.Buffer
+	//sighash epoch
+	buffer.WriteByte(0)
+	buffer.WriteByte(byte(hashType))
+	writeUint32(&buffer, tx.version())
+	writeUint32(&buffer, tx.Locktime)
+	if !hashType.anyoneCanPay() {
+		buffer.Write(hashes.SHAPrevouts)
+		buffer.Write(hashes.SHAAmounts)
+		buffer.Write(hashes.SHAScriptPubkeys)
+		buffer.Write(hashes.SHASequences)
+	}
+	if hashType.outputs() != SigHashNone && hashType.outputs() != SigHashSingle {
+		buffer.Write(hashes.SHAOutputs)
+	}
+	//spend type: key path, no annex
+	buffer.WriteByte(0)
+	if hashType.anyoneCanPay() {
+		writeOutPoint(&buffer, in)
+		writeUint64(&buffer, in.Amount)
+		writeScript(&buffer, in.PrevScriptPubkey)
+		writeUint32(&buffer, in.Sequence)
+	} else {
+		writeUint32(&buffer, uint32(i))
+	}
+	if hashType.outputs() == SigHashSingle {
+		var out bytes.Buffer
+		writeTXout(&out, outputs[i])
+		buffer.Write(sha256Sum(out.Bytes()))
+	}
+	return TaggedHash("TapSighash", buffer.Bytes()), nil
+}
+
+//witnessV0ScriptCode returns the BIP-143 scriptCode of a P2WPKH program, the
+//P2PKH script of its key hash.
+func (in *TXin) witnessV0ScriptCode() ([]byte, error) {
+	program := in.PrevScriptPubkey
+	if GetScriptType(program) == ScriptP2SH {
+		program = in.RedeemScript
+	}
+	if GetScriptType(program) != ScriptP2WPKH {
+		return nil, fmt.Errorf("unsupported witness v0 script %x", program)
+	}
+	script := []byte{opDUP, opHASH160, 20}
+	script = append(script, program[2:]...)
+	return append(script, opEQUALVERIFY, opCHECKSIG), nil
+}
+
+//outputs returns the outputs in the order createRawTransaction writes them,
+//the custom data one first.
+func (tx *TX) outputs() []*TXout {
+	if len(tx.CustomData) == 0 {
+		return tx.Txout
+	}
+	outs := []*TXout{{Value: 0, ScriptPubkey: customDataScript(tx.CustomData)}}
+	return append(outs, tx.Txout...)
+}
+
+//writeOutPoint writes the previous tx hash, little-endian, and output index
+//of in.
+func writeOutPoint(buffer *bytes.Buffer, in *TXin) {
+	for i := len(in.Hash) - 1; i >= 0; i-- {
+		buffer.WriteByte(in.Hash[i])
+	}
+	writeUint32(buffer, in.Index)
+}
+
+func writeTXout(buffer *bytes.Buffer, out *TXout) {
+	writeUint64(buffer, out.Value)
+	writeScript(buffer, out.ScriptPubkey)
+}
+
+func writeScript(buffer *bytes.Buffer, script []byte) {
+	buffer.Write(toVI(uint64(len(script))))
+	buffer.Write(script)
+}
+
+func writeUint32(buffer *bytes.Buffer, n uint32) {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, n)
+	buffer.Write(b)
+}
+
+func writeUint64(buffer *bytes.Buffer, n uint64) {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, n)
+	buffer.Write(b)
+}
+
+func sha256Sum(b []byte) []byte {
+	h := sha256.Sum256(b)
+	return h[:]
+}
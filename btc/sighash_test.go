@@ -0,0 +1,166 @@
+package btc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+//native P2WPKH example of BIP-143
+func bip143P2WPKHTX() *TX {
+	return &TX{
+		Txin: []*TXin{
+			{
+				Hash:             mustDecodeHex("9f96ade4b41d5433f4eda31e1738ec2b36f6e7d1420d94a6af99801a88f7f7ff"),
+				Index:            0,
+				Sequence:         0xffffffee,
+				PrevScriptPubkey: mustDecodeHex("2103c9f4836b9a4f77fc0d81f7bcb01b7f1b35916864b9476c241ce9fc198bd25432ac"),
+				Amount:           625000000,
+			},
+			{
+				Hash:             mustDecodeHex("8ac60eb9575db5b2d987e29f301b5b819ea83a5c6579d282d189cc04b8e151ef"),
+				Index:            1,
+				Sequence:         0xffffffff,
+				PrevScriptPubkey: mustDecodeHex("00141d0f172a0ecb48aee1be1f2687d2963ae33f71a1"),
+				Amount:           600000000,
+			},
+		},
+		Txout: []*TXout{
+			{Value: 112340000, ScriptPubkey: mustDecodeHex("76a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac")},
+			{Value: 223450000, ScriptPubkey: mustDecodeHex("76a9143bde42dbee7e4dbe6a21b2d50ce2f0167faa815988ac")},
+		},
+		Locktime: 17,
+	}
+}
+
+func TestWitnessV0SigHash(t *testing.T) {
+	tx := bip143P2WPKHTX()
+	raw, _ := tx.MakeUnsignedTX()
+	if hex.EncodeToString(raw) != "0100000002fff7f7881a8099afa6940d42d1e7f6362bec38171ea3edf433541db4e4ad969f0000000000eeffffffef51e1b804cc89d182d279655c3aa89e815b1b309fe287d9b2b55d57b90ec68a0100000000ffffffff02202cb206000000001976a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac9093510d000000001976a9143bde42dbee7e4dbe6a21b2d50ce2f0167faa815988ac11000000" {
+		t.Fatalf("unexpected unsigned tx %x", raw)
+	}
+	hashes := NewTXSigHashes(tx)
+	for _, c := range []struct {
+		name, got, expected string
+	}{
+		{"hashPrevouts", hex.EncodeToString(hashes.HashPrevouts), "96b827c8483d4e9b96712b6713a7b68d6e8003a781feba36c31143470b4efd37"},
+		{"hashSequence", hex.EncodeToString(hashes.HashSequence), "52b0a642eea2fb7ae638c36f6252b6750293dbe574a806984b8e4d8548339a3b"},
+		{"hashOutputs", hex.EncodeToString(hashes.HashOutputs), "863ef3e1a92afbfdb97f31ad0fc7683ee943e9abcf2501590ff8f6551f47e5e5"},
+	} {
+		if c.got != c.expected {
+			t.Errorf("%s %s, expected %s", c.name, c.got, c.expected)
+		}
+	}
+	sigHash, err := tx.WitnessV0SigHash(hashes, 1, SigHashAll)
+	if err != nil || hex.EncodeToString(sigHash) != "c37af31116d1b27caf68aae9e3ac82f1477929014d5b917657d0eb49478cb670" {
+		t.Errorf("p2wpkh sighash %x %v", sigHash, err)
+	}
+	//the first input is P2PK, not a witness program
+	if _, err := tx.WitnessV0SigHash(hashes, 0, SigHashAll); err == nil {
+		t.Errorf("witness sighash of a legacy input")
+	}
+	for _, hashType := range []SigHashType{SigHashDefault, 0x04, 0x40, SigHashAnyoneCanPay} {
+		if _, err := tx.WitnessV0SigHash(hashes, 1, hashType); err == nil {
+			t.Errorf("sighash type 0x%02x accepted", uint32(hashType))
+		}
+	}
+
+	//P2SH-P2WPKH example of BIP-143
+	tx = &TX{
+		Txin: []*TXin{{
+			Hash:             mustDecodeHex("77541aeb3c4dac9260b68f74f44c973081a9d4cb2ebe8038b2d70faa201b6bdb"),
+			Index:            1,
+			Sequence:         0xfffffffe,
+			PrevScriptPubkey: mustDecodeHex("a9144733f37cf4db86fbc2efed2500b4f4e49f31202387"),
+			Amount:           1000000000,
+			RedeemScript:     mustDecodeHex("001479091972186c449eb1ded22b78e40d009bdf0089"),
+		}},
+		Txout: []*TXout{
+			{Value: 199996600, ScriptPubkey: mustDecodeHex("76a914a457b684d7f0d539a46a45bbc043f35b59d0d96388ac")},
+			{Value: 800000000, ScriptPubkey: mustDecodeHex("76a914fd270b1ee6abcaea97fea7ad0402e8bd8ad6d77c88ac")},
+		},
+		Locktime: 1170,
+	}
+	sigHashes, err := tx.SigHashes(SigHashAll)
+	if err != nil || hex.EncodeToString(sigHashes[0]) != "64f3b0f4dd2bb3aa1ce8566d220cc74dda9df97d8490cc81d89d735c92e59fb6" {
+		t.Errorf("p2sh-p2wpkh sighash %x %v", sigHashes, err)
+	}
+	//without a witness redeem script it is a legacy P2SH input
+	tx.Txin[0].RedeemScript = nil
+	if legacy, err := tx.SigHashes(SigHashAll); err != nil || bytes.Equal(legacy[0], sigHashes[0]) {
+		t.Errorf("p2sh sighash without redeem script %x %v", legacy, err)
+	}
+}
+
+func TestLegacySigHash(t *testing.T) {
+	tx := bip143P2WPKHTX()
+	//SIGHASH_ALL signs the tx with the spent script in place of the scriptSig
+	sigHash, err := tx.LegacySigHash(0, SigHashAll)
+	expected := sha256Sum(sha256Sum(mustDecodeHex("0100000002fff7f7881a8099afa6940d42d1e7f6362bec38171ea3edf433541db4e4ad969f00000000232103c9f4836b9a4f77fc0d81f7bcb01b7f1b35916864b9476c241ce9fc198bd25432aceeffffffef51e1b804cc89d182d279655c3aa89e815b1b309fe287d9b2b55d57b90ec68a0100000000ffffffff02202cb206000000001976a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac9093510d000000001976a9143bde42dbee7e4dbe6a21b2d50ce2f0167faa815988ac1100000001000000")))
+	if err != nil || !bytes.Equal(sigHash, expected) {
+		t.Errorf("legacy sighash %x %v, expected %x", sigHash, err, expected)
+	}
+	//SIGHASH_SINGLE without a matching output signs one
+	tx.Txout = tx.Txout[:1]
+	sigHash, err = tx.LegacySigHash(1, SigHashSingle)
+	one := make([]byte, 32)
+	one[0] = 1
+	if err != nil || !bytes.Equal(sigHash, one) {
+		t.Errorf("legacy SIGHASH_SINGLE out of range %x %v", sigHash, err)
+	}
+	if _, err := tx.LegacySigHash(2, SigHashAll); err == nil {
+		t.Errorf("sighash of missing input")
+	}
+}
+
+func TestTaprootSigHash(t *testing.T) {
+	tx := bip143P2WPKHTX()
+	for _, in := range tx.Txin {
+		in.PrevScriptPubkey = P2TRScript(mustDecodeHex(taprootCases[0].outputKey))
+	}
+	hashes := NewTXSigHashes(tx)
+	types := []SigHashType{SigHashDefault, SigHashAll, SigHashNone, SigHashSingle,
+		SigHashAll | SigHashAnyoneCanPay, SigHashNone | SigHashAnyoneCanPay, SigHashSingle | SigHashAnyoneCanPay}
+	sigHashes := make(map[string]SigHashType)
+	for _, hashType := range types {
+		sigHash, err := tx.TaprootSigHash(hashes, 0, hashType)
+		if err != nil {
+			t.Fatalf("taproot sighash 0x%02x: %v", uint32(hashType), err)
+		}
+		if other, ok := sigHashes[string(sigHash)]; ok {
+			t.Errorf("sighash 0x%02x same as 0x%02x", uint32(hashType), uint32(other))
+		}
+		sigHashes[string(sigHash)] = hashType
+	}
+	for _, hashType := range []SigHashType{0x04, SigHashAnyoneCanPay} {
+		if _, err := tx.TaprootSigHash(hashes, 0, hashType); err == nil {
+			t.Errorf("sighash type 0x%02x accepted", uint32(hashType))
+		}
+	}
+
+	//taproot commits to the amounts of all inputs unless anyone can pay
+	acp := SigHashAll | SigHashAnyoneCanPay
+	all, _ := tx.TaprootSigHash(nil, 0, SigHashDefault)
+	anyone, _ := tx.TaprootSigHash(nil, 0, acp)
+	tx.Txin[1].Amount++
+	if sigHash, _ := tx.TaprootSigHash(nil, 0, SigHashDefault); bytes.Equal(sigHash, all) {
+		t.Errorf("taproot sighash does not commit to other amounts")
+	}
+	if sigHash, _ := tx.TaprootSigHash(nil, 0, acp); !bytes.Equal(sigHash, anyone) {
+		t.Errorf("anyone can pay sighash commits to other inputs")
+	}
+
+	//SIGHASH_SINGLE has no fallback in taproot
+	tx.Txout = tx.Txout[:1]
+	if _, err := tx.TaprootSigHash(nil, 1, SigHashSingle); err == nil {
+		t.Errorf("taproot SIGHASH_SINGLE without matching output")
+	}
+}
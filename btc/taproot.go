@@ -0,0 +1,110 @@
+package btc
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+
+	bech32 "github.com/GameLeLe/trade-addr-tx-service/bech32"
+	btcec "github.com/btcsuite/btcd/btcec"
+)
+
+//TaggedHash returns the BIP-340 tagged hash of msg,
+//SHA256(SHA256(tag) || SHA256(tag) || msg).
+func TaggedHash(tag string, msg ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, m := range msg {
+		h.Write(m)
+	}
+	return h.Sum(nil)
+}
+
+//TaprootOutputKey returns the x-only output key of a BIP-86 taproot output,
+//spendable by key path only, of internalKey, a compressed or x-only public
+//key. The internal key is lifted to even y and tweaked by the TapTweak hash
+//of its x coordinate.
+func TaprootOutputKey(internalKey []byte) ([]byte, error) {
+	var x []byte
+	switch len(internalKey) {
+	case 33:
+		x = internalKey[1:]
+	case 32:
+		x = internalKey
+	default:
+		return nil, fmt.Errorf("invalid taproot internal key length %d", len(internalKey))
+	}
+	curve := btcec.S256()
+	p, err := btcec.ParsePubKey(append([]byte{0x02}, x...), curve)
+	if err != nil {
+		return nil, err
+	}
+	tweak := TaggedHash("TapTweak", x)
+	if new(big.Int).SetBytes(tweak).Cmp(curve.N) >= 0 {
+		return nil, errors.New("taproot tweak out of range")
+	}
+	tx, ty := curve.ScalarBaseMult(tweak)
+	qx, qy := curve.Add(p.X, p.Y, tx, ty)
+	if qx.Sign() == 0 && qy.Sign() == 0 {
+		return nil, errors.New("taproot output key is infinity")
+	}
+	//x coordinates with leading zero bytes keep all 32 bytes
+	outputKey := make([]byte, 32)
+	b := qx.Bytes()
+	copy(outputKey[32-len(b):], b)
+	return outputKey, nil
+}
+
+//VerifySchnorr checks sig is a BIP-340 signature of msg by the x-only public
+//key pubKey.
+func VerifySchnorr(pubKey, msg, sig []byte) bool {
+	if len(pubKey) != 32 || len(msg) != 32 || len(sig) != 64 {
+		return false
+	}
+	curve := btcec.S256()
+	p, err := btcec.ParsePubKey(append([]byte{0x02}, pubKey...), curve)
+	if err != nil {
+		return false
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if r.Cmp(curve.P) >= 0 || s.Cmp(curve.N) >= 0 {
+		return false
+	}
+	e := new(big.Int).SetBytes(TaggedHash("BIP0340/challenge", sig[:32], pubKey, msg))
+	e.Mod(e, curve.N)
+	//R = s*G - e*P must have an even y and x r
+	sx, sy := curve.ScalarBaseMult(sig[32:])
+	ex, ey := curve.ScalarMult(p.X, p.Y, e.Sub(curve.N, e).Bytes())
+	rx, ry := curve.Add(sx, sy, ex, ey)
+	if rx.Sign() == 0 && ry.Sign() == 0 {
+		return false
+	}
+	return ry.Bit(0) == 0 && rx.Cmp(r) == 0
+}
+
+//P2TRScript returns the scriptPubkey of a taproot output key, OP_1 and the
+//32 byte key pushed.
+func P2TRScript(outputKey []byte) []byte {
+	return witnessScript(1, outputKey)
+}
+
+//CreateP2TRScriptPubkey returns the scriptPubkey paying to a bech32m taproot
+//address of mainnet or testnet.
+func CreateP2TRScriptPubkey(addr string) ([]byte, error) {
+	hrp := segwitHRP(addr)
+	if hrp == "" {
+		return nil, fmt.Errorf("%s is not a segwit address", addr)
+	}
+	version, program, err := bech32.DecodeSegwitAddress(hrp, addr)
+	if err != nil {
+		return nil, err
+	}
+	if version != 1 || len(program) != 32 {
+		return nil, fmt.Errorf("%s is not a taproot address", addr)
+	}
+	return P2TRScript(program), nil
+}
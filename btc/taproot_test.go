@@ -0,0 +1,89 @@
+package btc
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+//test vectors from BIP-86
+var taprootCases = []struct {
+	internalKey string
+	outputKey   string
+	addr        string
+}{
+	{
+		"cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115",
+		"a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c",
+		"bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr",
+	},
+	{
+		"83dfe85a3151d2517290da461fe2815591ef69f2b18a2ce63f01697a8b313145",
+		"a82f29944d65b86ae6b5e5cc75e294ead6c59391a1edc5e016e3498c67fc7bbb",
+		"bc1p4qhjn9zdvkux4e44uhx8tc55attvtyu358kutcqkudyccelu0was9fqzwh",
+	},
+	{
+		"399f1b2f4393f29a18c937859c5dd8a77350103157eb880f02e8c08214277cef",
+		"882d74e5d0572d5a816cef0041a96b6c1de832f6f9676d9605c44d5e9a97d3dc",
+		"bc1p3qkhfews2uk44qtvauqyr2ttdsw7svhkl9nkm9s9c3x4ax5h60wqwruhk7",
+	},
+}
+
+func TestTaprootOutputKey(t *testing.T) {
+	for _, c := range taprootCases {
+		internalKey, _ := hex.DecodeString(c.internalKey)
+		//the parity of a compressed internal key is dropped
+		for _, key := range [][]byte{internalKey, append([]byte{0x02}, internalKey...), append([]byte{0x03}, internalKey...)} {
+			outputKey, err := TaprootOutputKey(key)
+			if err != nil || hex.EncodeToString(outputKey) != c.outputKey {
+				t.Errorf("output key of %x: %x %v, expected %s", key, outputKey, err, c.outputKey)
+			}
+		}
+		script, err := CreateP2TRScriptPubkey(c.addr)
+		if err != nil || hex.EncodeToString(script) != "5120"+c.outputKey {
+			t.Errorf("p2tr script of %s not matched: %x %v", c.addr, script, err)
+		}
+		if GetScriptType(script) != ScriptP2TR {
+			t.Errorf("%x is not a p2tr script", script)
+		}
+	}
+	//x is not on the curve
+	if _, err := TaprootOutputKey(make([]byte, 32)); err == nil {
+		t.Errorf("invalid internal key accepted")
+	}
+	for _, addr := range []string{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", testAddr} {
+		if _, err := CreateP2TRScriptPubkey(addr); err == nil {
+			t.Errorf("%s accepted as taproot address", addr)
+		}
+	}
+}
+
+func TestVerifySchnorr(t *testing.T) {
+	//test vectors 0 and 1 of BIP-340
+	cases := []struct {
+		pubKey, msg, sig string
+	}{
+		{
+			"f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			"e907831f80848d1069a5371b402410364bdf1c5f8307b0084c55f1ce2dca821525f66a4a85ea8b71e482a74f382d2ce5ebeee8fdb2172f477df4900d310536c0",
+		},
+		{
+			"dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+			"243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+			"6896bd60eeae296db48a229ff71dfe071bde413e6d43f917dc8dcf8c78de33418906d11ac976abccb20b091292bff4ea897efcb639ea871cfa95f6de339e4b0a",
+		},
+	}
+	for _, c := range cases {
+		pubKey, msg, sig := mustDecodeHex(c.pubKey), mustDecodeHex(c.msg), mustDecodeHex(c.sig)
+		if !VerifySchnorr(pubKey, msg, sig) {
+			t.Errorf("signature %s of %s not verified", c.sig, c.pubKey)
+		}
+		msg[0] ^= 1
+		if VerifySchnorr(pubKey, msg, sig) {
+			t.Errorf("signature %s verified for another message", c.sig)
+		}
+		if VerifySchnorr(pubKey, msg, sig[:63]) {
+			t.Errorf("short signature verified")
+		}
+	}
+}
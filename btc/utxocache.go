@@ -0,0 +1,297 @@
+package btc
+
+import (
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	//DefaultUTXOCacheTTL is how long fetched utxos are used before fetching again.
+	DefaultUTXOCacheTTL = time.Minute
+	//DefaultUTXOReservationTTL is how long utxos spent by an unsigned
+	//transaction that is never broadcast are held back before they are
+	//considered free again.
+	DefaultUTXOReservationTTL = 10 * time.Minute
+	//tipInterval is how often UTXOCache asks a BlockHeighter for the tip.
+	tipInterval = 10 * time.Second
+)
+
+//BlockHeighter is a service that reports the height of the chain tip,
+//UTXOCache drops cached utxos once it moves.
+type BlockHeighter interface {
+	BlockHeight() (uint64, error)
+}
+
+//TipUTXOGetter is a service that ages utxos from a chain tip the caller
+//already knows instead of fetching it again.
+type TipUTXOGetter interface {
+	GetUTXOAtTip(addr string, key *Key, tip uint64) (UTXOs, error)
+}
+
+type utxoEntry struct {
+	utxos   UTXOs
+	fetched time.Time
+	height  uint64
+}
+
+//reservation holds back a utxo of addr for an unsigned transaction.
+type reservation struct {
+	addr   string
+	expire time.Time
+}
+
+//UTXOCache caches the utxos of addresses fetched from a Service and keeps
+//the utxos that pending unsigned transactions spend reserved, so concurrent
+//transactions do not spend the same outputs. Cached utxos expire after ttl
+//or when the chain tip moves if the service is a BlockHeighter, the tip is
+//fetched at most every 10 seconds. Once MarkSpent reports the transaction
+//broadcast its utxos stay spent until a fetch of their address no longer
+//returns them. Reservations of transactions never broadcast expire after
+//reservationTTL, the transaction is then assumed to be abandoned. UTXOCache
+//is itself a Service and safe for concurrent use, fetching is serialized.
+type UTXOCache struct {
+	mu             sync.Mutex
+	service        Service
+	ttl            time.Duration
+	reservationTTL time.Duration
+	entries        map[string]*utxoEntry
+	reserved       map[string]reservation
+	//spent are the addresses of utxos spent by broadcast transactions
+	spent map[string]string
+	now   func() time.Time
+
+	//tip is the last chain tip of a BlockHeighter service, guarded by tipMu
+	tipMu      sync.Mutex
+	tip        uint64
+	tipFetched time.Time
+}
+
+//NewUTXOCache creates a cache in front of service, ttl and reservationTTL <= 0
+//use DefaultUTXOCacheTTL and DefaultUTXOReservationTTL.
+func NewUTXOCache(service Service, ttl, reservationTTL time.Duration) *UTXOCache {
+	if ttl <= 0 {
+		ttl = DefaultUTXOCacheTTL
+	}
+	if reservationTTL <= 0 {
+		reservationTTL = DefaultUTXOReservationTTL
+	}
+	c := &UTXOCache{}
+	c.service = service
+	c.ttl = ttl
+	c.reservationTTL = reservationTTL
+	c.entries = make(map[string]*utxoEntry)
+	c.reserved = make(map[string]reservation)
+	c.spent = make(map[string]string)
+	c.now = time.Now
+	return c
+}
+
+//outpoint identifies a utxo by its txid and output index.
+func outpoint(u *UTXO) string {
+	return txinOutpoint(u.Hash, u.Index)
+}
+
+func txinOutpoint(hash []byte, index uint32) string {
+	return hex.EncodeToString(hash) + ":" + strconv.FormatUint(uint64(index), 10)
+}
+
+//GetServiceName return the name of the cached service.
+func (c *UTXOCache) GetServiceName() string {
+	return c.service.GetServiceName()
+}
+
+//SendTX sends a transaction through the cached service, then marks the
+//utxos it spends spent and drops the cached utxos of their addresses.
+func (c *UTXOCache) SendTX(data []byte) ([]byte, error) {
+	txid, err := c.service.SendTX(data)
+	if err != nil {
+		return nil, err
+	}
+	//the service took the tx, it parses
+	if tx, err := ParseTX(data); err == nil {
+		for _, addr := range c.MarkSpent(tx) {
+			c.Invalidate(addr)
+		}
+	}
+	return txid, nil
+}
+
+//GetUTXO returns the utxos of addr that are neither reserved nor spent.
+func (c *UTXOCache) GetUTXO(addr string, key *Key) (UTXOs, error) {
+	height, err := c.height()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.available(addr, key, height)
+}
+
+//Spend passes the unreserved utxos of addr to choose and reserves the ones it
+//returns, atomically with respect to other calls. A nil result of choose
+//reserves nothing.
+func (c *UTXOCache) Spend(addr string, key *Key, choose func(UTXOs) (UTXOs, error)) (UTXOs, error) {
+	height, err := c.height()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	utxos, err := c.available(addr, key, height)
+	if err != nil {
+		return nil, err
+	}
+	chosen, err := choose(utxos)
+	if err != nil {
+		return nil, err
+	}
+	expire := c.now().Add(c.reservationTTL)
+	for _, u := range chosen {
+		c.reserved[outpoint(u)] = reservation{addr: addr, expire: expire}
+	}
+	return chosen, nil
+}
+
+//MarkSpent turns the reservations of the utxos tx spends into spent ones once
+//it is broadcast and returns the addresses they belong to. Spent utxos are
+//not returned until a fetch of their address shows them gone, whatever
+//reservationTTL is.
+func (c *UTXOCache) MarkSpent(tx *TX) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var addrs []string
+	for _, in := range tx.Txin {
+		op := txinOutpoint(in.Hash, in.Index)
+		addr := c.addrOf(op)
+		if addr == "" {
+			continue
+		}
+		delete(c.reserved, op)
+		c.spent[op] = addr
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+//addrOf returns the address of a reserved or cached utxo, "" if the cache
+//does not know it. c.mu must be held.
+func (c *UTXOCache) addrOf(op string) string {
+	if r, ok := c.reserved[op]; ok {
+		return r.addr
+	}
+	for addr, entry := range c.entries {
+		for _, u := range entry.utxos {
+			if outpoint(u) == op {
+				return addr
+			}
+		}
+	}
+	return ""
+}
+
+//Release frees reserved utxos whose transaction will never be sent.
+func (c *UTXOCache) Release(utxos UTXOs) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, u := range utxos {
+		delete(c.reserved, outpoint(u))
+	}
+}
+
+//Invalidate drops the cached utxos of addr, they are fetched again on next use.
+func (c *UTXOCache) Invalidate(addr string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, addr)
+}
+
+//height returns the chain tip of a BlockHeighter service, fetched again once
+//it is older than tipInterval, or 0 for other services. c.mu must not be held
+//so callers do not wait behind the fetch.
+func (c *UTXOCache) height() (uint64, error) {
+	heighter, ok := c.service.(BlockHeighter)
+	if !ok {
+		return 0, nil
+	}
+	c.tipMu.Lock()
+	defer c.tipMu.Unlock()
+	now := c.now()
+	if !c.tipFetched.IsZero() && now.Sub(c.tipFetched) < tipInterval {
+		return c.tip, nil
+	}
+	tip, err := heighter.BlockHeight()
+	if err != nil {
+		return 0, err
+	}
+	c.tip = tip
+	c.tipFetched = now
+	return tip, nil
+}
+
+//available returns copies of the unreserved utxos of addr carrying key,
+//fetching them if the cached ones are stale at height. c.mu must be held.
+func (c *UTXOCache) available(addr string, key *Key, height uint64) (UTXOs, error) {
+	now := c.now()
+	for op, r := range c.reserved {
+		if now.After(r.expire) {
+			delete(c.reserved, op)
+		}
+	}
+	entry, err := c.entry(addr, now, height)
+	if err != nil {
+		return nil, err
+	}
+	utxos := make(UTXOs, 0, len(entry.utxos))
+	for _, u := range entry.utxos {
+		if _, ok := c.reserved[outpoint(u)]; ok {
+			continue
+		}
+		if _, ok := c.spent[outpoint(u)]; ok {
+			continue
+		}
+		utxo := *u
+		utxo.Key = key
+		utxos = append(utxos, &utxo)
+	}
+	return utxos, nil
+}
+
+//entry returns the cached utxos of addr, fetched again if they are older
+//than ttl or the chain tip moved from height. c.mu must be held.
+func (c *UTXOCache) entry(addr string, now time.Time, height uint64) (*utxoEntry, error) {
+	entry := c.entries[addr]
+	if entry != nil && now.Sub(entry.fetched) < c.ttl && entry.height == height {
+		return entry, nil
+	}
+	var utxos UTXOs
+	var err error
+	if getter, ok := c.service.(TipUTXOGetter); ok && height > 0 {
+		utxos, err = getter.GetUTXOAtTip(addr, nil, height)
+	} else {
+		utxos, err = c.service.GetUTXO(addr, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	entry = &utxoEntry{utxos: utxos, fetched: now, height: height}
+	c.entries[addr] = entry
+	//spent utxos the service no longer returns need not be held back
+	fetched := make(map[string]bool, len(utxos))
+	for _, u := range utxos {
+		fetched[outpoint(u)] = true
+	}
+	for op, a := range c.spent {
+		if a == addr && !fetched[op] {
+			delete(c.spent, op)
+		}
+	}
+	//drop stale entries of other addresses so the cache does not grow forever
+	for a, e := range c.entries {
+		if now.Sub(e.fetched) >= c.ttl {
+			delete(c.entries, a)
+		}
+	}
+	return entry, nil
+}
@@ -0,0 +1,213 @@
+package btc
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+//countingService serves fixed utxos, counts fetches and reports height if set
+type countingService struct {
+	mu      sync.Mutex
+	utxos   UTXOs
+	fetches int
+	height  uint64
+	tips    int
+}
+
+func (s *countingService) GetServiceName() string {
+	return "countingService"
+}
+
+func (s *countingService) GetUTXO(addr string, key *Key) (UTXOs, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetches++
+	return s.utxos, nil
+}
+
+func (s *countingService) SendTX(data []byte) ([]byte, error) {
+	return nil, nil
+}
+
+type heightService struct {
+	*countingService
+}
+
+func (s heightService) BlockHeight() (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tips++
+	return s.height, nil
+}
+
+func testUTXOs() UTXOs {
+	return UTXOs{
+		{Addr: testAddr, Hash: []byte{1}, Index: 0, Amount: 1000},
+		{Addr: testAddr, Hash: []byte{1}, Index: 1, Amount: 2000},
+		{Addr: testAddr, Hash: []byte{2}, Index: 0, Amount: 3000},
+	}
+}
+
+func TestUTXOCacheExpiry(t *testing.T) {
+	service := &countingService{utxos: testUTXOs()}
+	cache := NewUTXOCache(service, time.Minute, 0)
+	now := time.Unix(1500000000, 0)
+	cache.now = func() time.Time { return now }
+
+	key := &Key{}
+	utxos, err := cache.GetUTXO(testAddr, key)
+	if err != nil || len(utxos) != 3 || utxos[0].Key != key {
+		t.Fatalf("unexpected utxos %v %v", utxos, err)
+	}
+	if service.utxos[0].Key != nil {
+		t.Errorf("key should be set on copies only")
+	}
+	cache.GetUTXO(testAddr, nil)
+	if service.fetches != 1 {
+		t.Errorf("utxos should be cached, fetched %d times", service.fetches)
+	}
+	now = now.Add(time.Minute)
+	cache.GetUTXO(testAddr, nil)
+	if service.fetches != 2 {
+		t.Errorf("utxos should expire after ttl, fetched %d times", service.fetches)
+	}
+	cache.Invalidate(testAddr)
+	cache.GetUTXO(testAddr, nil)
+	if service.fetches != 3 {
+		t.Errorf("invalidated utxos should be fetched, fetched %d times", service.fetches)
+	}
+
+	heights := heightService{&countingService{utxos: testUTXOs(), height: 100}}
+	cache = NewUTXOCache(heights, time.Hour, 0)
+	cache.now = func() time.Time { return now }
+	cache.GetUTXO(testAddr, nil)
+	cache.GetUTXO(testAddr, nil)
+	heights.mu.Lock()
+	heights.height++
+	heights.mu.Unlock()
+	//the tip is checked again only after tipInterval
+	cache.GetUTXO(testAddr, nil)
+	if heights.fetches != 1 || heights.tips != 1 {
+		t.Errorf("tip should be checked once per interval, fetched %d utxos %d tips", heights.fetches, heights.tips)
+	}
+	now = now.Add(tipInterval)
+	cache.GetUTXO(testAddr, nil)
+	if heights.fetches != 2 || heights.tips != 2 {
+		t.Errorf("utxos should be fetched once per block, fetched %d utxos %d tips", heights.fetches, heights.tips)
+	}
+}
+
+func TestUTXOCacheReservation(t *testing.T) {
+	service := &countingService{utxos: testUTXOs()}
+	cache := NewUTXOCache(service, time.Hour, 10*time.Minute)
+	now := time.Unix(1500000000, 0)
+	cache.now = func() time.Time { return now }
+
+	first := func(utxos UTXOs) (UTXOs, error) {
+		if len(utxos) == 0 {
+			return nil, nil
+		}
+		return utxos[:1], nil
+	}
+	spent, err := cache.Spend(testAddr, nil, first)
+	if err != nil || len(spent) != 1 || spent[0].Amount != 1000 {
+		t.Fatalf("unexpected spent utxos %v %v", spent, err)
+	}
+	utxos, _ := cache.GetUTXO(testAddr, nil)
+	if len(utxos) != 2 || utxos[0].Amount != 2000 {
+		t.Errorf("reserved utxo should be skipped: %v", utxos)
+	}
+	cache.Release(spent)
+	if utxos, _ = cache.GetUTXO(testAddr, nil); len(utxos) != 3 {
+		t.Errorf("released utxo should be available: %v", utxos)
+	}
+
+	cache.Spend(testAddr, nil, first)
+	now = now.Add(10*time.Minute + time.Second)
+	if utxos, _ = cache.GetUTXO(testAddr, nil); len(utxos) != 3 {
+		t.Errorf("reservation should expire: %v", utxos)
+	}
+
+	//utxos of a broadcast tx stay spent past the reservation until a fetch
+	//shows them gone
+	spent, _ = cache.Spend(testAddr, nil, first)
+	tx := &TX{Txin: []*TXin{{Hash: spent[0].Hash, Index: spent[0].Index}, {Hash: []byte{9}}}}
+	if addrs := cache.MarkSpent(tx); len(addrs) != 1 || addrs[0] != testAddr {
+		t.Errorf("spent utxos of addresses %v", addrs)
+	}
+	now = now.Add(time.Hour + time.Second)
+	if utxos, _ = cache.GetUTXO(testAddr, nil); len(utxos) != 2 || service.fetches != 2 {
+		t.Errorf("spent utxo should be held back after a fetch still returning it: %v", utxos)
+	}
+	service.utxos = service.utxos[1:]
+	cache.Invalidate(testAddr)
+	if utxos, _ = cache.GetUTXO(testAddr, nil); len(utxos) != 2 || len(cache.spent) != 0 {
+		t.Errorf("spent utxo should be forgotten once gone: %v %v", utxos, cache.spent)
+	}
+	service.utxos = testUTXOs()
+	cache.Invalidate(testAddr)
+
+	//concurrent spends never share a utxo
+	var wg sync.WaitGroup
+	results := make(chan UTXOs, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			spent, err := cache.Spend(testAddr, nil, first)
+			if err != nil {
+				t.Error(err)
+			}
+			results <- spent
+		}()
+	}
+	wg.Wait()
+	close(results)
+	seen := make(map[string]bool)
+	for spent := range results {
+		for _, u := range spent {
+			if seen[outpoint(u)] {
+				t.Errorf("utxo %s spent twice", outpoint(u))
+			}
+			seen[outpoint(u)] = true
+		}
+	}
+	if len(seen) != 3 {
+		t.Errorf("all utxos should be spent once, got %d", len(seen))
+	}
+}
+
+func TestUTXOCacheSendTX(t *testing.T) {
+	hash := make([]byte, 32)
+	hash[0] = 1
+	service := &countingService{utxos: UTXOs{
+		{Addr: testAddr, Hash: hash, Index: 0, Amount: 1000},
+		{Addr: testAddr, Hash: hash, Index: 1, Amount: 2000},
+	}}
+	cache := NewUTXOCache(service, time.Hour, 10*time.Minute)
+	now := time.Unix(1500000000, 0)
+	cache.now = func() time.Time { return now }
+
+	spent, err := cache.Spend(testAddr, nil, func(utxos UTXOs) (UTXOs, error) {
+		return utxos[1:], nil
+	})
+	if err != nil || len(spent) != 1 {
+		t.Fatalf("unexpected spent utxos %v %v", spent, err)
+	}
+	tx := &TX{
+		Txin:    []*TXin{{Hash: spent[0].Hash, Index: spent[0].Index, Sequence: 0xffffffff}},
+		Txout:   []*TXout{{Value: 1500, ScriptPubkey: []byte{opRETURN}}},
+		Version: 2,
+	}
+	if _, err := cache.SendTX(tx.createRawTransaction(false)); err != nil {
+		t.Fatal(err)
+	}
+	//the sender's utxos are fetched again, the backend may still return the
+	//spent one as scantxoutset does
+	now = now.Add(10*time.Minute + time.Second)
+	utxos, _ := cache.GetUTXO(testAddr, nil)
+	if service.fetches != 2 || len(utxos) != 1 || utxos[0].Index != 0 {
+		t.Errorf("spent utxo returned after its reservation expired: %v, fetched %d times", utxos, service.fetches)
+	}
+}
@@ -0,0 +1,190 @@
+package btc
+
+//ScriptType is the standard form of a scriptPubKey.
+type ScriptType int
+
+//script types recognized by GetScriptType
+const (
+	ScriptNonStandard ScriptType = iota
+	ScriptP2PKH
+	ScriptP2SH
+	ScriptP2WPKH
+	ScriptP2WSH
+	ScriptP2TR
+	ScriptNullData
+)
+
+func (t ScriptType) String() string {
+	switch t {
+	case ScriptP2PKH:
+		return "p2pkh"
+	case ScriptP2SH:
+		return "p2sh"
+	case ScriptP2WPKH:
+		return "p2wpkh"
+	case ScriptP2WSH:
+		return "p2wsh"
+	case ScriptP2TR:
+		return "p2tr"
+	case ScriptNullData:
+		return "nulldata"
+	}
+	return "nonstandard"
+}
+
+//GetScriptType returns the type of scriptPubKey script.
+func GetScriptType(script []byte) ScriptType {
+	switch {
+	case len(script) == 25 && script[0] == opDUP && script[1] == opHASH160 &&
+		script[2] == 20 && script[23] == opEQUALVERIFY && script[24] == opCHECKSIG:
+		return ScriptP2PKH
+	case len(script) == 23 && script[0] == opHASH160 && script[1] == 20 && script[22] == opEQUAL:
+		return ScriptP2SH
+	case len(script) == 22 && script[0] == op0 && script[1] == 20:
+		return ScriptP2WPKH
+	case len(script) == 34 && script[0] == op0 && script[1] == 32:
+		return ScriptP2WSH
+	case len(script) == 34 && script[0] == op1 && script[1] == 32:
+		return ScriptP2TR
+	case len(script) > 0 && script[0] == opRETURN:
+		return ScriptNullData
+	}
+	return ScriptNonStandard
+}
+
+//sizes in bytes of a P2PKH transaction
+const (
+	//P2PKHInputSize is an input spending P2PKH with a compressed key: outpoint,
+	//scriptSig of a 72 byte signature and a 33 byte key, sequence
+	P2PKHInputSize = 148
+	//P2PKHOutputSize is a P2PKH output: value, 25 byte script
+	P2PKHOutputSize = 34
+	//TXOverheadSize is version, input and output counts and locktime
+	TXOverheadSize = 10
+	//DefaultFeeRate is DefaultFee in satoshi per virtual byte, used when
+	//neither the request nor the service gives a rate
+	DefaultFeeRate = DefaultFee / 1000
+)
+
+//WitnessScaleFactor is the weight of a non-witness byte, a virtual byte is
+//WitnessScaleFactor weight units.
+const WitnessScaleFactor = 4
+
+//weights of the parts of inputs spending our script types, signatures are
+//counted at their largest DER size
+const (
+	//outpoint, sequence and the scriptSig length
+	inputBaseSize = 32 + 4 + 4 + 1
+	//a 72 byte signature and a 33 byte compressed key pushed
+	p2pkhScriptSigSize = 1 + 72 + 1 + 33
+	//the push of the 22 byte P2WPKH redeem script
+	p2shP2WPKHScriptSigSize = 1 + 22
+	//item count, signature and compressed key
+	p2wpkhWitnessSize = 1 + 1 + 72 + 1 + 33
+	//item count and a 64 byte schnorr signature
+	p2trWitnessSize = 1 + 1 + 64
+)
+
+//InputWeight returns the weight of a signed input spending prevScript. P2SH
+//is assumed to wrap P2WPKH and P2TR to be spent by key path, other scripts
+//are estimated as P2PKH.
+func InputWeight(prevScript []byte) uint64 {
+	switch GetScriptType(prevScript) {
+	case ScriptP2SH:
+		return (inputBaseSize+p2shP2WPKHScriptSigSize)*WitnessScaleFactor + p2wpkhWitnessSize
+	case ScriptP2WPKH:
+		return inputBaseSize*WitnessScaleFactor + p2wpkhWitnessSize
+	case ScriptP2TR:
+		return inputBaseSize*WitnessScaleFactor + p2trWitnessSize
+	}
+	return (inputBaseSize + p2pkhScriptSigSize) * WitnessScaleFactor
+}
+
+//isWitnessSpend reports whether an input spending prevScript has a witness,
+//see InputWeight.
+func isWitnessSpend(prevScript []byte) bool {
+	switch GetScriptType(prevScript) {
+	case ScriptP2SH, ScriptP2WPKH, ScriptP2TR:
+		return true
+	}
+	return false
+}
+
+//outputSize is the size of an output paying script: value, length, script.
+func outputSize(script []byte) uint64 {
+	return 8 + uint64(len(toVI(uint64(len(script))))+len(script))
+}
+
+//OutputWeight returns the weight of an output paying script.
+func OutputWeight(script []byte) uint64 {
+	return outputSize(script) * WitnessScaleFactor
+}
+
+//DustThreshold returns the smallest value of an output paying script that
+//default relay policy accepts, the cost of creating and spending it at 3
+//satoshi per virtual byte.
+func DustThreshold(script []byte) uint64 {
+	size := outputSize(script)
+	switch GetScriptType(script) {
+	case ScriptNullData:
+		return 0
+	case ScriptP2WPKH, ScriptP2WSH, ScriptP2TR:
+		size += inputBaseSize + p2pkhScriptSigSize/WitnessScaleFactor
+	default:
+		size += inputBaseSize + p2pkhScriptSigSize
+	}
+	return 3 * size
+}
+
+//EstimateWeight returns the weight of a signed tx spending outputs paying
+//prevScripts to outputs paying scripts.
+func EstimateWeight(prevScripts, scripts [][]byte) uint64 {
+	size := uint64(4 + len(toVI(uint64(len(prevScripts)))) + len(toVI(uint64(len(scripts)))) + 4)
+	weight := size * WitnessScaleFactor
+	witness := false
+	for _, s := range prevScripts {
+		weight += InputWeight(s)
+		witness = witness || isWitnessSpend(s)
+	}
+	//segwit marker and flag
+	if witness {
+		weight += 2
+	}
+	for _, s := range scripts {
+		weight += OutputWeight(s)
+	}
+	return weight
+}
+
+//VSize returns the virtual size of weight.
+func VSize(weight uint64) uint64 {
+	return (weight + WitnessScaleFactor - 1) / WitnessScaleFactor
+}
+
+//customDataScript is the OP_RETURN script of custom data.
+func customDataScript(data []byte) []byte {
+	script := []byte{opRETURN, byte(len(data))}
+	return append(script, data...)
+}
+
+//EstimateVSize returns the virtual size of tx once its inputs are signed,
+//from the scripts they spend.
+func (tx *TX) EstimateVSize() uint64 {
+	prevScripts := make([][]byte, 0, len(tx.Txin))
+	for _, in := range tx.Txin {
+		prevScripts = append(prevScripts, in.PrevScriptPubkey)
+	}
+	scripts := make([][]byte, 0, len(tx.Txout)+1)
+	if len(tx.CustomData) != 0 {
+		scripts = append(scripts, customDataScript(tx.CustomData))
+	}
+	for _, out := range tx.Txout {
+		scripts = append(scripts, out.ScriptPubkey)
+	}
+	return VSize(EstimateWeight(prevScripts, scripts))
+}
+
+//EstimateFee returns the fee of tx at feeRate satoshi per virtual byte.
+func (tx *TX) EstimateFee(feeRate uint64) uint64 {
+	return tx.EstimateVSize() * feeRate
+}
@@ -0,0 +1,106 @@
+package btc
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func testScripts(t *testing.T) map[ScriptType][]byte {
+	scripts := make(map[ScriptType][]byte)
+	for typ, s := range map[ScriptType]string{
+		ScriptP2PKH:    testScript,
+		ScriptP2SH:     "a914b472a266d0bd89c13706a4132ccfb16f7c3b9fcb87",
+		ScriptP2WPKH:   "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+		ScriptP2WSH:    "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262",
+		ScriptP2TR:     "5120a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c",
+		ScriptNullData: "6a0568656c6c6f",
+	} {
+		script, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scripts[typ] = script
+	}
+	return scripts
+}
+
+func TestScriptType(t *testing.T) {
+	for typ, script := range testScripts(t) {
+		if got := GetScriptType(script); got != typ {
+			t.Errorf("script %x is %v, expected %v", script, got, typ)
+		}
+	}
+	if got := GetScriptType([]byte{op1}); got != ScriptNonStandard {
+		t.Errorf("expected nonstandard, got %v", got)
+	}
+}
+
+func TestEstimateVSize(t *testing.T) {
+	scripts := testScripts(t)
+	cases := []struct {
+		name  string
+		ins   []ScriptType
+		outs  []ScriptType
+		vsize uint64
+	}{
+		{"p2pkh", []ScriptType{ScriptP2PKH}, []ScriptType{ScriptP2PKH, ScriptP2PKH}, 226},
+		{"p2pkh 2 inputs", []ScriptType{ScriptP2PKH, ScriptP2PKH}, []ScriptType{ScriptP2PKH}, 340},
+		{"p2sh-p2wpkh", []ScriptType{ScriptP2SH}, []ScriptType{ScriptP2SH, ScriptP2SH}, 166},
+		{"p2wpkh", []ScriptType{ScriptP2WPKH}, []ScriptType{ScriptP2WPKH, ScriptP2WPKH}, 141},
+		{"p2tr", []ScriptType{ScriptP2TR}, []ScriptType{ScriptP2TR}, 111},
+		{"mixed", []ScriptType{ScriptP2PKH, ScriptP2WPKH}, []ScriptType{ScriptP2WSH}, 270},
+	}
+	for _, c := range cases {
+		tx := &TX{}
+		for _, typ := range c.ins {
+			tx.Txin = append(tx.Txin, &TXin{PrevScriptPubkey: scripts[typ]})
+		}
+		for _, typ := range c.outs {
+			tx.Txout = append(tx.Txout, &TXout{ScriptPubkey: scripts[typ]})
+		}
+		if vsize := tx.EstimateVSize(); vsize != c.vsize {
+			t.Errorf("%s: vsize %d, expected %d", c.name, vsize, c.vsize)
+		}
+		if fee := tx.EstimateFee(3); fee != 3*c.vsize {
+			t.Errorf("%s: fee %d, expected %d", c.name, fee, 3*c.vsize)
+		}
+	}
+
+	//custom data adds an OP_RETURN output
+	tx := &TX{Txin: []*TXin{{PrevScriptPubkey: scripts[ScriptP2PKH]}}, Txout: []*TXout{{ScriptPubkey: scripts[ScriptP2PKH]}}}
+	tx.AttachCustomData([]byte("hello"))
+	if vsize := tx.EstimateVSize(); vsize != 192+16 {
+		t.Errorf("custom data: vsize %d, expected %d", vsize, 192+16)
+	}
+}
+
+func TestDustThreshold(t *testing.T) {
+	scripts := testScripts(t)
+	for typ, dust := range map[ScriptType]uint64{
+		ScriptP2PKH:    546,
+		ScriptP2SH:     540,
+		ScriptP2WPKH:   294,
+		ScriptP2WSH:    330,
+		ScriptP2TR:     330,
+		ScriptNullData: 0,
+	} {
+		if got := DustThreshold(scripts[typ]); got != dust {
+			t.Errorf("%v: dust %d, expected %d", typ, got, dust)
+		}
+	}
+}
+
+func TestSelectWitnessInputs(t *testing.T) {
+	scripts := testScripts(t)
+	utxos := UTXOs{
+		{Hash: []byte{1}, Amount: 100000, Script: scripts[ScriptP2WPKH]},
+		{Hash: []byte{2}, Amount: 40000, Script: scripts[ScriptP2PKH]},
+	}
+	fee := &TXFee{Rate: 10, Outputs: [][]byte{scripts[ScriptP2WPKH]}, Change: scripts[ScriptP2WPKH]}
+	//one p2wpkh input, two p2wpkh outputs
+	s, err := LargestFirst{}.Select(utxos, 50000, fee)
+	checkSelection(t, "p2wpkh", s, err, []uint64{100000}, 1410, 48590)
+	//the legacy input adds its full size
+	s, err = LargestFirst{}.Select(utxos, 120000, fee)
+	checkSelection(t, "mixed", s, err, []uint64{100000, 40000}, 2890, 17110)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"container/list"
+	"log"
+	"strconv"
+	"sync"
+
+	"github.com/go-redis/redis"
+)
+
+//remoteCache is the shared cache behind the in-process lru
+type remoteCache interface {
+	Get(key string) (string, bool, error)
+	Set(key, value string) error
+}
+
+//addrCache is a read-through cache of derived addresses, an in-process lru in
+//front of an optional remote cache. Addresses never change for a key, so
+//entries are not expired. A nil *addrCache caches nothing.
+type addrCache struct {
+	mu     sync.Mutex
+	size   int
+	ll     *list.List
+	items  map[string]*list.Element
+	remote remoteCache
+}
+
+type addrCacheEntry struct {
+	key   string
+	value string
+}
+
+//newAddrCache makes a cache holding up to size addresses in process, remote may be nil
+func newAddrCache(size int, remote remoteCache) *addrCache {
+	c := &addrCache{}
+	c.size = size
+	c.ll = list.New()
+	c.items = make(map[string]*list.Element)
+	c.remote = remote
+	return c
+}
+
+//Get returns the cached address of key, falling back to the remote cache on
+//an lru miss. Remote errors are logged and treated as a miss.
+func (c *addrCache) Get(key string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		value := e.Value.(*addrCacheEntry).value
+		c.mu.Unlock()
+		return value, true
+	}
+	c.mu.Unlock()
+	if c.remote == nil {
+		return "", false
+	}
+	value, ok, err := c.remote.Get(key)
+	if err != nil {
+		log.Println("addr cache get", key, "error:", err)
+		return "", false
+	}
+	if ok {
+		c.add(key, value)
+	}
+	return value, ok
+}
+
+//Set caches value in process and in the remote cache
+func (c *addrCache) Set(key, value string) {
+	if c == nil {
+		return
+	}
+	c.add(key, value)
+	if c.remote == nil {
+		return
+	}
+	if err := c.remote.Set(key, value); err != nil {
+		log.Println("addr cache set", key, "error:", err)
+	}
+}
+
+func (c *addrCache) add(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		e.Value.(*addrCacheEntry).value = value
+		return
+	}
+	c.items[key] = c.ll.PushFront(&addrCacheEntry{key, value})
+	for c.size > 0 && c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*addrCacheEntry).key)
+	}
+}
+
+//redisCache stores addresses in the configured redis
+type redisCache struct {
+	client *redis.Client
+}
+
+//newRedisCache connects to the configured redis
+func newRedisCache(config redisConfig) (*redisCache, error) {
+	opt := &redis.Options{}
+	opt.Addr = config.Host + ":" + strconv.Itoa(config.Port)
+	opt.Password = config.Pwd
+	opt.DB = config.DB
+	client := redis.NewClient(opt)
+	if err := client.Ping().Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &redisCache{client: client}, nil
+}
+
+func (r *redisCache) Get(key string) (string, bool, error) {
+	value, err := r.client.Get(key).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+func (r *redisCache) Set(key, value string) error {
+	return r.client.Set(key, value, 0).Err()
+}
+
+//Close closes the redis connections
+func (r *redisCache) Close() error {
+	return r.client.Close()
+}
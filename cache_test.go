@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	hdwallet "github.com/GameLeLe/trade-addr-tx-service/hdwallet"
+	addrtx "github.com/GameLeLe/trade-addr-tx-service/thrift/addrtx"
+	"github.com/stretchr/testify/assert"
+)
+
+//memRemoteCache is a remote cache in memory for tests
+type memRemoteCache struct {
+	mu     sync.Mutex
+	values map[string]string
+	err    error
+}
+
+func newMemRemoteCache() *memRemoteCache {
+	return &memRemoteCache{values: make(map[string]string)}
+}
+
+func (m *memRemoteCache) Get(key string) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return "", false, m.err
+	}
+	value, ok := m.values[key]
+	return value, ok, nil
+}
+
+func (m *memRemoteCache) Set(key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.values[key] = value
+	return nil
+}
+
+func TestAddrCacheLRU(t *testing.T) {
+	c := newAddrCache(2, nil)
+	c.Set("a", "1")
+	c.Set("b", "2")
+	//touch a so b is the oldest
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+	c.Set("c", "3")
+
+	_, ok = c.Get("b")
+	assert.False(t, ok, "oldest entry should be evicted")
+	value, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", value)
+	value, ok = c.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, "3", value)
+
+	var nilCache *addrCache
+	nilCache.Set("a", "1")
+	_, ok = nilCache.Get("a")
+	assert.False(t, ok)
+}
+
+func TestAddrCacheRemote(t *testing.T) {
+	remote := newMemRemoteCache()
+	c := newAddrCache(10, remote)
+	c.Set("a", "1")
+	assert.Equal(t, "1", remote.values["a"])
+
+	//a fresh process reads through to the remote cache
+	c = newAddrCache(10, remote)
+	value, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", value)
+
+	//remote errors are a miss, not a failure
+	remote.err = errors.New("connection refused")
+	value, ok = c.Get("a")
+	assert.True(t, ok, "lru should still hold the entry")
+	assert.Equal(t, "1", value)
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+}
+
+func TestGetAddrCached(t *testing.T) {
+	daConfig, err := ParseConfig("config.toml")
+	if err != nil {
+		t.Fatalf("parse config file error: %v", err)
+	}
+	btcPubKey, _ := hdwallet.ReadWalletFromFile(daConfig.BTCMasterPubKeyFile)
+	remote := newMemRemoteCache()
+	handler := &rpcThrift{btcPubKey: btcPubKey, cache: newAddrCache(10, remote)}
+
+	msg := &addrtx.GetAddrMsg{CoinType: "BTC", UID: 6}
+	addr, err := handler.GetAddr(msg)
+	if err != nil {
+		t.Fatalf("get addr error: %v", err)
+	}
+	assert.Equal(t, "1Be99wrYzyztKGVPkxAuJcLyZGArcUcdVg", addr)
+	key := addrCacheKey("BTC", btcPubKey, 6)
+	assert.Equal(t, addr, remote.values[key])
+
+	//served from the remote cache instead of derived again
+	remote.values[key] = "cached"
+	handler.cache = newAddrCache(10, remote)
+	addr, err = handler.GetAddr(msg)
+	assert.Nil(t, err)
+	assert.Equal(t, "cached", addr)
+
+	//invalid uids are rejected before the cache is consulted
+	_, err = handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: -1})
+	e, ok := err.(*addrtx.AddrTXException)
+	if !ok || e.Code != addrtx.ErrorCode_INVALID_UID {
+		t.Errorf("expected INVALID_UID, got %v", err)
+	}
+}
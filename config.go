@@ -1,9 +1,15 @@
 package main
 
 import (
+	"encoding/hex"
+	"fmt"
 	"io/ioutil"
+	"strings"
 
 	"github.com/BurntSushi/toml"
+	"github.com/GameLeLe/trade-addr-tx-service/btc"
+	"github.com/GameLeLe/trade-addr-tx-service/eth"
+	addrtx "github.com/GameLeLe/trade-addr-tx-service/thrift/addrtx"
 )
 
 //DigitalAssetsConfig config
@@ -11,11 +17,60 @@ type DigitalAssetsConfig struct {
 	Title               string      `toml:"title"`
 	BTCMasterPubKeyFile string      `toml:"btc_master_pub_key_file"`
 	ETHMasterPubKeyFile string      `toml:"eth_master_pub_key_file"`
+	BTCAccountPath      string      `toml:"btc_account_path"`
+	ETHAccountPath      string      `toml:"eth_account_path"`
+	UIDScanLimit        int64       `toml:"uid_scan_limit"`
+	AddrCacheSize       int         `toml:"addr_cache_size"`
 	RPCConfig           rpcConfig   `toml:"rpc"`
 	DBConfig            mysqlConfig `toml:"mysql"`
 	RedisConfig         redisConfig `toml:"redis"`
+	ETHConfig           ethConfig   `toml:"eth"`
+	BTCConfig           btcConfig   `toml:"btc"`
+
+	//BTCP2WPKHMasterPubKeyFile holds the BIP-84 account key P2WPKH addresses
+	//derive from, unset disables them
+	BTCP2WPKHMasterPubKeyFile string `toml:"btc_p2wpkh_master_pub_key_file"`
+	BTCP2WPKHAccountPath      string `toml:"btc_p2wpkh_account_path"`
+	//BTCP2SHP2WPKHMasterPubKeyFile holds the BIP-49 account key P2SH-P2WPKH
+	//addresses derive from, unset disables them
+	BTCP2SHP2WPKHMasterPubKeyFile string `toml:"btc_p2sh_p2wpkh_master_pub_key_file"`
+	BTCP2SHP2WPKHAccountPath      string `toml:"btc_p2sh_p2wpkh_account_path"`
+	//BTCP2TRMasterPubKeyFile holds the BIP-86 account key P2TR addresses
+	//derive from, unset disables them
+	BTCP2TRMasterPubKeyFile string `toml:"btc_p2tr_master_pub_key_file"`
+	BTCP2TRAccountPath      string `toml:"btc_p2tr_account_path"`
 }
 
+//derivation paths of the master public key files, uid is the next index
+const (
+	defaultBTCAccountPath = "m/44'/0'/0'/0"
+	defaultETHAccountPath = "m/44'/60'/0'"
+	//defaultBTCP2WPKHAccountPath is the BIP-84 receive chain of account 0
+	defaultBTCP2WPKHAccountPath = "m/84'/0'/0'/0"
+	//defaultBTCP2SHP2WPKHAccountPath is the BIP-49 receive chain of account 0
+	defaultBTCP2SHP2WPKHAccountPath = "m/49'/0'/0'/0"
+	//defaultBTCP2TRAccountPath is the BIP-86 receive chain of account 0
+	defaultBTCP2TRAccountPath = "m/86'/0'/0'/0"
+	//defaultBTCAddrType keeps issuing legacy addresses
+	defaultBTCAddrType = "p2pkh"
+	//defaultUIDScanLimit is how many uids GetUIDByAddr derives when an address
+	//is missing from the store
+	defaultUIDScanLimit = 1000
+	//defaultAddrCacheSize is how many addresses are cached in process
+	defaultAddrCacheSize = 100000
+	//defaultETHChainID is the ethereum mainnet, the chain id of handlers not
+	//configured otherwise; configs must set theirs
+	defaultETHChainID = 1
+	//defaultBTCCoinSelection avoids change outputs when it can
+	defaultBTCCoinSelection = "branch_and_bound"
+	//defaultBTCFeeTargetBlocks is about an hour
+	defaultBTCFeeTargetBlocks = 6
+	//defaultETHFeeStrategy asks the node for the gas price
+	defaultETHFeeStrategy = "node_suggested"
+	//defaultETHFeePercentile is the median priority fee of recent blocks
+	defaultETHFeePercentile = 50
+)
+
 type mysqlConfig struct {
 	Host        string `toml:"host"`
 	Port        int    `toml:"port"`
@@ -33,6 +88,158 @@ type redisConfig struct {
 	Pwd  string `toml:"password"`
 }
 
+type ethConfig struct {
+	//ChainID is the EIP-155 chain id transactions are signed for
+	ChainID int64 `toml:"chain_id"`
+	//RPCURL is the JSON-RPC endpoint of the eth node
+	RPCURL string `toml:"rpc_url"`
+	//NonceReservationTTL is how many seconds a nonce handed out by GetTX stays
+	//reserved before it is considered abandoned
+	NonceReservationTTL int64 `toml:"nonce_reservation_ttl"`
+	//FeeStrategy is how GetTX prices gas when the request does not say,
+	//one of node_suggested, fixed and percentile
+	FeeStrategy string `toml:"fee_strategy"`
+	//MaxFeePerGas and MaxPriorityFeePerGas are the prices in wei of the fixed
+	//strategy, MaxFeePerGas is also the gas price of legacy txs
+	MaxFeePerGas         int64 `toml:"max_fee_per_gas"`
+	MaxPriorityFeePerGas int64 `toml:"max_priority_fee_per_gas"`
+	//FeePercentile of the priority fees of the last FeeHistoryBlocks blocks
+	//is the tip of the percentile strategy
+	FeePercentile    int    `toml:"fee_percentile"`
+	FeeHistoryBlocks uint64 `toml:"fee_history_blocks"`
+	//LegacyTX builds pre EIP-1559 txs, for chains without dynamic fees
+	LegacyTX bool `toml:"legacy_tx"`
+	//Tokens are the ERC-20 tokens GetTX can transfer
+	Tokens []tokenConfig `toml:"tokens"`
+}
+
+type tokenConfig struct {
+	Symbol   string `toml:"symbol"`
+	Contract string `toml:"contract"`
+	Decimals int    `toml:"decimals"`
+}
+
+//tokens returns the configured ERC-20 tokens by upper case symbol
+func (c ethConfig) tokens() (map[string]*eth.Token, error) {
+	tokens := make(map[string]*eth.Token, len(c.Tokens))
+	for _, t := range c.Tokens {
+		token, err := eth.NewToken(t.Symbol, t.Contract, t.Decimals)
+		if err != nil {
+			return nil, fmt.Errorf("eth token %q: %v", t.Symbol, err)
+		}
+		if _, ok := tokens[token.Symbol]; ok {
+			return nil, fmt.Errorf("eth token %q configured twice", t.Symbol)
+		}
+		tokens[token.Symbol] = token
+	}
+	return tokens, nil
+}
+
+//feeStrategy returns the configured fee strategy
+func (c ethConfig) feeStrategy() (addrtx.FeeStrategy, error) {
+	strategy, err := addrtx.FeeStrategyFromString(strings.ToUpper(c.FeeStrategy))
+	if err != nil {
+		return 0, fmt.Errorf("unknown eth fee strategy %q", c.FeeStrategy)
+	}
+	return strategy, nil
+}
+
+type btcConfig struct {
+	//Backend is the service utxos are fetched from and txs broadcast through,
+	//one of bitcoind, electrum and esplora
+	Backend string `toml:"backend"`
+	//URL is the bitcoind JSON-RPC endpoint, the host:port of the electrum
+	//server or the esplora API base URL
+	URL string `toml:"url"`
+	//RPCUser and RPCPassword authenticate to bitcoind
+	RPCUser     string `toml:"rpc_user"`
+	RPCPassword string `toml:"rpc_password"`
+	//Wallet lists utxos of the bitcoind wallet instead of scanning the utxo set
+	Wallet bool `toml:"wallet"`
+	//UTXOCacheTTL is how many seconds fetched utxos are reused, the cache is
+	//also dropped when a new block arrives
+	UTXOCacheTTL int64 `toml:"utxo_cache_ttl"`
+	//UTXOReservationTTL is how many seconds utxos spent by a tx handed out by
+	//GetTX are held back if the tx is never broadcast
+	UTXOReservationTTL int64 `toml:"utxo_reservation_ttl"`
+	//CoinSelection is how GetTX picks utxos when the request does not say,
+	//one of branch_and_bound, largest_first, oldest_first and knapsack
+	CoinSelection string `toml:"coin_selection"`
+	//FeeRate in satoshi per virtual byte is used when the request does not
+	//say, 0 asks the backend for an estimate
+	FeeRate int64 `toml:"fee_rate"`
+	//FeeTargetBlocks is the confirmation target of fee estimates
+	FeeTargetBlocks int `toml:"fee_target_blocks"`
+	//TLS connects to the electrum server over TLS
+	TLS bool `toml:"tls"`
+	//AddrType is the address type of requests that do not say, one of p2pkh,
+	//p2wpkh, p2sh_p2wpkh and p2tr
+	AddrType string `toml:"addr_type"`
+
+	//MasterFingerprint is the hex BIP-32 fingerprint of the master key the
+	//account keys derive from along their account paths, the key origin
+	//PSBT signers find their keys by. Unset makes each account key the master
+	MasterFingerprint string `toml:"master_fingerprint"`
+	//PSBTVersion is the version of the PSBTs GetTX returns, 0 of BIP-174 or 2
+	//of BIP-370
+	PSBTVersion uint32 `toml:"psbt_version"`
+}
+
+//service creates the configured btc service
+func (c btcConfig) service() (btc.Service, error) {
+	conf := btc.ServiceConfig{}
+	conf.Backend = c.Backend
+	conf.URL = c.URL
+	conf.User = c.RPCUser
+	conf.Password = c.RPCPassword
+	conf.Wallet = c.Wallet
+	conf.TLS = c.TLS
+	return btc.NewService(conf)
+}
+
+//coinSelection returns the configured coin selection
+func (c btcConfig) coinSelection() (addrtx.CoinSelection, error) {
+	selection, err := addrtx.CoinSelectionFromString(strings.ToUpper(c.CoinSelection))
+	if err != nil {
+		return 0, fmt.Errorf("unknown btc coin selection %q", c.CoinSelection)
+	}
+	return selection, nil
+}
+
+//addrType returns the configured address type
+func (c btcConfig) addrType() (addrtx.BTCAddrType, error) {
+	addrType, err := addrtx.BTCAddrTypeFromString(strings.ToUpper(c.AddrType))
+	if err != nil {
+		return 0, fmt.Errorf("unknown btc address type %q", c.AddrType)
+	}
+	return addrType, nil
+}
+
+//masterFingerprint returns the configured master fingerprint, nil if unset
+func (c btcConfig) masterFingerprint() ([]byte, error) {
+	if c.MasterFingerprint == "" {
+		return nil, nil
+	}
+	fingerprint, err := hex.DecodeString(c.MasterFingerprint)
+	if err != nil || len(fingerprint) != 4 {
+		return nil, fmt.Errorf("btc master fingerprint %q is not 4 hex bytes", c.MasterFingerprint)
+	}
+	return fingerprint, nil
+}
+
+//btcMasterPubKeyFile returns the file of the account key of addrType
+func (c *DigitalAssetsConfig) btcMasterPubKeyFile(addrType addrtx.BTCAddrType) string {
+	switch addrType {
+	case addrtx.BTCAddrType_P2WPKH:
+		return c.BTCP2WPKHMasterPubKeyFile
+	case addrtx.BTCAddrType_P2SH_P2WPKH:
+		return c.BTCP2SHP2WPKHMasterPubKeyFile
+	case addrtx.BTCAddrType_P2TR:
+		return c.BTCP2TRMasterPubKeyFile
+	}
+	return c.BTCMasterPubKeyFile
+}
+
 type rpcConfig struct {
 	Host string `toml:"host"`
 	Port int    `toml:"port"`
@@ -45,9 +252,93 @@ func ParseConfig(filename string) (*DigitalAssetsConfig, error) {
 	if err != nil {
 		return nil, err
 	}
-	_, err = toml.Decode(string(data), &config)
+	meta, err := toml.Decode(string(data), &config)
+	if err != nil {
+		return nil, err
+	}
+	if config.BTCAccountPath == "" {
+		config.BTCAccountPath = defaultBTCAccountPath
+	}
+	if config.ETHAccountPath == "" {
+		config.ETHAccountPath = defaultETHAccountPath
+	}
+	if config.BTCP2WPKHAccountPath == "" {
+		config.BTCP2WPKHAccountPath = defaultBTCP2WPKHAccountPath
+	}
+	if config.BTCP2SHP2WPKHAccountPath == "" {
+		config.BTCP2SHP2WPKHAccountPath = defaultBTCP2SHP2WPKHAccountPath
+	}
+	if config.BTCP2TRAccountPath == "" {
+		config.BTCP2TRAccountPath = defaultBTCP2TRAccountPath
+	}
+	if config.UIDScanLimit == 0 {
+		config.UIDScanLimit = defaultUIDScanLimit
+	}
+	if config.AddrCacheSize == 0 {
+		config.AddrCacheSize = defaultAddrCacheSize
+	}
+	//a forgotten chain id must not sign txs replayable on mainnet
+	if (config.ETHMasterPubKeyFile != "" || config.ETHConfig.RPCURL != "") && config.ETHConfig.ChainID <= 0 {
+		return nil, fmt.Errorf("eth chain_id %d must be set to the chain txs are signed for", config.ETHConfig.ChainID)
+	}
+	if config.ETHConfig.FeeStrategy == "" {
+		config.ETHConfig.FeeStrategy = defaultETHFeeStrategy
+	}
+	if _, err := config.ETHConfig.feeStrategy(); err != nil {
+		return nil, err
+	}
+	//0 is the cheapest tip, only an absent percentile defaults
+	if !meta.IsDefined("eth", "fee_percentile") {
+		config.ETHConfig.FeePercentile = defaultETHFeePercentile
+	}
+	if config.BTCConfig.CoinSelection == "" {
+		config.BTCConfig.CoinSelection = defaultBTCCoinSelection
+	}
+	if _, err := config.BTCConfig.coinSelection(); err != nil {
+		return nil, err
+	}
+	if config.BTCConfig.AddrType == "" {
+		config.BTCConfig.AddrType = defaultBTCAddrType
+	}
+	addrType, err := config.BTCConfig.addrType()
 	if err != nil {
 		return nil, err
 	}
+	if config.btcMasterPubKeyFile(addrType) == "" {
+		return nil, fmt.Errorf("btc address type %s needs its master public key file", config.BTCConfig.AddrType)
+	}
+	if config.BTCConfig.FeeTargetBlocks == 0 {
+		config.BTCConfig.FeeTargetBlocks = defaultBTCFeeTargetBlocks
+	}
+	if config.BTCConfig.FeeRate < 0 || config.BTCConfig.FeeTargetBlocks < 0 {
+		return nil, fmt.Errorf("btc fee rate %d and target blocks %d must not be negative", config.BTCConfig.FeeRate, config.BTCConfig.FeeTargetBlocks)
+	}
+	if config.BTCConfig.FeeRate > btc.MaxFeeRate {
+		return nil, fmt.Errorf("btc fee rate %d above %d satoshi per vbyte", config.BTCConfig.FeeRate, btc.MaxFeeRate)
+	}
+	if fingerprint, err := config.BTCConfig.masterFingerprint(); err != nil {
+		return nil, err
+	} else if fingerprint != nil {
+		//the account paths are then part of the key origins
+		for _, path := range []string{config.BTCAccountPath, config.BTCP2WPKHAccountPath, config.BTCP2SHP2WPKHAccountPath, config.BTCP2TRAccountPath} {
+			if _, err := parseBIP32Path(path); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if config.BTCConfig.PSBTVersion != 0 && config.BTCConfig.PSBTVersion != 2 {
+		return nil, fmt.Errorf("btc psbt version %d is not 0 or 2", config.BTCConfig.PSBTVersion)
+	}
+	if config.BTCConfig.Backend != "" {
+		if _, err := config.BTCConfig.service(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := config.ETHConfig.tokens(); err != nil {
+		return nil, err
+	}
+	if config.ETHConfig.FeePercentile < 0 || config.ETHConfig.FeePercentile > 100 {
+		return nil, fmt.Errorf("eth fee percentile %d not in [0, 100]", config.ETHConfig.FeePercentile)
+	}
 	return &config, nil
 }
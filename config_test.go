@@ -5,6 +5,7 @@ import (
 	"os"
 	"testing"
 
+	addrtx "github.com/GameLeLe/trade-addr-tx-service/thrift/addrtx"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -13,6 +14,7 @@ func TestDAConfig(t *testing.T) {
 	title = "digital assets service"
 	btc_master_pub_key_file = "btc_master_pubkey"
 	eth_master_pub_key_file = "eth_master_pubkey"
+	btc_p2wpkh_master_pub_key_file = "btc_p2wpkh_master_pubkey"
 	
 	[rpc]
 	host = "0.0.0.0"
@@ -32,6 +34,33 @@ func TestDAConfig(t *testing.T) {
 	user = "root"
 	password = ""
 	db = 0
+
+	[btc]
+	backend = "bitcoind"
+	url = "http://127.0.0.1:8332"
+	rpc_user = "user"
+	rpc_password = "pwd"
+	utxo_cache_ttl = 30
+	utxo_reservation_ttl = 900
+	coin_selection = "oldest_first"
+	fee_rate = 12
+	addr_type = "p2wpkh"
+	master_fingerprint = "73c5da0a"
+
+	[eth]
+	chain_id = 3
+	rpc_url = "http://127.0.0.1:8545"
+	nonce_reservation_ttl = 600
+	fee_strategy = "percentile"
+	max_fee_per_gas = 50000000000
+	max_priority_fee_per_gas = 2000000000
+	fee_history_blocks = 10
+	legacy_tx = true
+
+	[[eth.tokens]]
+	symbol = "usdt"
+	contract = "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+	decimals = 6
 	`
 
 	tmpFileName := "./config_tmp.toml"
@@ -46,6 +75,15 @@ func TestDAConfig(t *testing.T) {
 	//check pub key file
 	assert.Equal(t, "btc_master_pubkey", config.BTCMasterPubKeyFile, "config btc master pub key file not matched")
 	assert.Equal(t, "eth_master_pubkey", config.ETHMasterPubKeyFile, "config eth master pub key file not matched")
+	//check account paths default to the bip44 paths of the pub key files
+	assert.Equal(t, "m/44'/0'/0'/0", config.BTCAccountPath, "btc account path not matched")
+	assert.Equal(t, "m/44'/60'/0'", config.ETHAccountPath, "eth account path not matched")
+	assert.Equal(t, "btc_p2wpkh_master_pubkey", config.BTCP2WPKHMasterPubKeyFile, "config btc p2wpkh master pub key file not matched")
+	assert.Equal(t, "m/84'/0'/0'/0", config.BTCP2WPKHAccountPath, "btc p2wpkh account path should default to bip84")
+	assert.Equal(t, "m/49'/0'/0'/0", config.BTCP2SHP2WPKHAccountPath, "btc p2sh-p2wpkh account path should default to bip49")
+	assert.Equal(t, "m/86'/0'/0'/0", config.BTCP2TRAccountPath, "btc p2tr account path should default to bip86")
+	assert.Equal(t, int64(1000), config.UIDScanLimit, "uid scan limit not matched")
+	assert.Equal(t, 100000, config.AddrCacheSize, "addr cache size not matched")
 	//check rpc config
 	assert.Equal(t, "0.0.0.0", config.RPCConfig.Host, "rpc host not matched")
 	assert.Equal(t, 8090, config.RPCConfig.Port, "rpc port not matched")
@@ -62,4 +100,95 @@ func TestDAConfig(t *testing.T) {
 	assert.Equal(t, 0, config.RedisConfig.DB, "redis db not matched")
 	assert.Equal(t, "root", config.RedisConfig.User, "redis user not matched")
 	assert.Equal(t, "", config.RedisConfig.Pwd, "redis password not matched")
+	//check btc config
+	assert.Equal(t, "bitcoind", config.BTCConfig.Backend, "btc backend not matched")
+	assert.Equal(t, "http://127.0.0.1:8332", config.BTCConfig.URL, "btc url not matched")
+	assert.Equal(t, "user", config.BTCConfig.RPCUser, "btc rpc user not matched")
+	assert.Equal(t, "pwd", config.BTCConfig.RPCPassword, "btc rpc password not matched")
+	assert.Equal(t, int64(30), config.BTCConfig.UTXOCacheTTL, "btc utxo cache ttl not matched")
+	assert.Equal(t, int64(900), config.BTCConfig.UTXOReservationTTL, "btc utxo reservation ttl not matched")
+	assert.Equal(t, int64(12), config.BTCConfig.FeeRate, "btc fee rate not matched")
+	assert.Equal(t, 6, config.BTCConfig.FeeTargetBlocks, "btc fee target blocks should default to 6")
+	selection, err := config.BTCConfig.coinSelection()
+	assert.Nil(t, err)
+	assert.Equal(t, addrtx.CoinSelection_OLDEST_FIRST, selection, "btc coin selection not matched")
+	addrType, err := config.BTCConfig.addrType()
+	assert.Nil(t, err)
+	assert.Equal(t, addrtx.BTCAddrType_P2WPKH, addrType, "btc address type not matched")
+	fingerprint, err := config.BTCConfig.masterFingerprint()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x73, 0xc5, 0xda, 0x0a}, fingerprint, "btc master fingerprint not matched")
+	service, err := config.BTCConfig.service()
+	assert.Nil(t, err)
+	assert.Equal(t, "BitcoindService", service.GetServiceName(), "btc service not matched")
+	//check eth config
+	assert.Equal(t, int64(3), config.ETHConfig.ChainID, "eth chain id not matched")
+	assert.Equal(t, "http://127.0.0.1:8545", config.ETHConfig.RPCURL, "eth rpc url not matched")
+	assert.Equal(t, int64(600), config.ETHConfig.NonceReservationTTL, "eth nonce reservation ttl not matched")
+	strategy, err := config.ETHConfig.feeStrategy()
+	assert.Nil(t, err)
+	assert.Equal(t, addrtx.FeeStrategy_PERCENTILE, strategy, "eth fee strategy not matched")
+	assert.Equal(t, int64(50000000000), config.ETHConfig.MaxFeePerGas, "eth max fee per gas not matched")
+	assert.Equal(t, int64(2000000000), config.ETHConfig.MaxPriorityFeePerGas, "eth max priority fee per gas not matched")
+	assert.Equal(t, 50, config.ETHConfig.FeePercentile, "eth fee percentile should default to the median")
+	assert.Equal(t, uint64(10), config.ETHConfig.FeeHistoryBlocks, "eth fee history blocks not matched")
+	assert.True(t, config.ETHConfig.LegacyTX, "eth legacy tx not matched")
+
+	tokens, err := config.ETHConfig.tokens()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(tokens), "eth tokens not matched")
+	assert.Equal(t, uint8(6), tokens["USDT"].Decimals, "eth token decimals not matched")
+
+	//unknown fee strategies are rejected
+	ioutil.WriteFile(tmpFileName, []byte("[eth]\nfee_strategy = \"cheapest\"\n"), 0666)
+	_, err = ParseConfig(tmpFileName)
+	assert.NotNil(t, err, "unknown fee strategy should be rejected")
+	//eth needs its chain id
+	for _, eth := range []string{"eth_master_pub_key_file = \"eth_master_pubkey\"\n", "[eth]\nrpc_url = \"http://127.0.0.1:8545\"\n", "eth_master_pub_key_file = \"eth_master_pubkey\"\n[eth]\nchain_id = 0\n"} {
+		ioutil.WriteFile(tmpFileName, []byte("btc_master_pub_key_file = \"btc_master_pubkey\"\n"+eth), 0666)
+		_, err = ParseConfig(tmpFileName)
+		assert.NotNil(t, err, "eth without chain id should be rejected: %s", eth)
+	}
+	//a percentile of 0 asks for the cheapest tip
+	ioutil.WriteFile(tmpFileName, []byte("btc_master_pub_key_file = \"btc_master_pubkey\"\n[eth]\nfee_strategy = \"percentile\"\nfee_percentile = 0\n"), 0666)
+	config, err = ParseConfig(tmpFileName)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 0, config.ETHConfig.FeePercentile, "eth fee percentile 0 should be kept")
+	}
+	//and btc backends that do not exist
+	ioutil.WriteFile(tmpFileName, []byte("[btc]\nbackend = \"blockr\"\nurl = \"http://btc.blockr.io\"\n"), 0666)
+	_, err = ParseConfig(tmpFileName)
+	assert.NotNil(t, err, "unknown btc backend should be rejected")
+	ioutil.WriteFile(tmpFileName, []byte("[btc]\ncoin_selection = \"random\"\n"), 0666)
+	_, err = ParseConfig(tmpFileName)
+	assert.NotNil(t, err, "unknown btc coin selection should be rejected")
+	ioutil.WriteFile(tmpFileName, []byte("[btc]\nfee_rate = 100000\n"), 0666)
+	_, err = ParseConfig(tmpFileName)
+	assert.NotNil(t, err, "btc fee rate above the maximum should be rejected")
+	ioutil.WriteFile(tmpFileName, []byte("[btc]\naddr_type = \"p2wsh\"\n"), 0666)
+	_, err = ParseConfig(tmpFileName)
+	assert.NotNil(t, err, "unknown btc address type should be rejected")
+	//p2wpkh addresses need their account key
+	ioutil.WriteFile(tmpFileName, []byte("[btc]\naddr_type = \"p2wpkh\"\n"), 0666)
+	_, err = ParseConfig(tmpFileName)
+	assert.NotNil(t, err, "p2wpkh without an account key should be rejected")
+	ioutil.WriteFile(tmpFileName, []byte("btc_p2sh_p2wpkh_master_pub_key_file = \"btc_master_pubkey\"\n[btc]\naddr_type = \"p2sh_p2wpkh\"\n"), 0666)
+	config, err = ParseConfig(tmpFileName)
+	assert.Nil(t, err)
+	addrType, _ = config.BTCConfig.addrType()
+	assert.Equal(t, addrtx.BTCAddrType_P2SH_P2WPKH, addrType, "btc address type not matched")
+	//key origins need a 4 byte fingerprint and account paths that parse
+	ioutil.WriteFile(tmpFileName, []byte("[btc]\nmaster_fingerprint = \"73c5da\"\n"), 0666)
+	_, err = ParseConfig(tmpFileName)
+	assert.NotNil(t, err, "short btc master fingerprint should be rejected")
+	ioutil.WriteFile(tmpFileName, []byte("btc_account_path = \"44'/0'/0'/0\"\n[btc]\nmaster_fingerprint = \"73c5da0a\"\n"), 0666)
+	_, err = ParseConfig(tmpFileName)
+	assert.NotNil(t, err, "btc account path without m should be rejected")
+	ioutil.WriteFile(tmpFileName, []byte("[btc]\npsbt_version = 1\n"), 0666)
+	_, err = ParseConfig(tmpFileName)
+	assert.NotNil(t, err, "btc psbt version 1 should be rejected")
+	//so are tokens with a bad contract address
+	ioutil.WriteFile(tmpFileName, []byte("[[eth.tokens]]\nsymbol = \"USDT\"\ncontract = \"0x1234\"\n"), 0666)
+	_, err = ParseConfig(tmpFileName)
+	assert.NotNil(t, err, "bad token contract should be rejected")
 }
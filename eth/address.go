@@ -0,0 +1,69 @@
+package eth
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+//AddressLength is the byte length of an address
+const AddressLength = 20
+
+var (
+	//ErrInvalidAddress is returned for strings that are not 0x prefixed 40 hex digits
+	ErrInvalidAddress = errors.New("invalid eth address")
+	//ErrBadChecksum is returned for mixed case addresses failing the EIP-55 checksum
+	ErrBadChecksum = errors.New("eth address checksum mismatch")
+)
+
+//ChecksumAddress returns the 0x prefixed EIP-55 form of a 20 byte address
+func ChecksumAddress(addr []byte) string {
+	lower := hex.EncodeToString(addr)
+	hash := crypto.Keccak256([]byte(lower))
+	result := []byte(lower)
+	for i, c := range result {
+		if c < 'a' {
+			continue
+		}
+		//upper case the letter when the matching nibble of the hash is >= 8
+		nibble := hash[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if nibble&0xf >= 8 {
+			result[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(result)
+}
+
+//ParseAddress decodes a 0x prefixed hex address, see ValidateAddress for the
+//accepted forms
+func ParseAddress(addr string) ([]byte, error) {
+	if len(addr) != 2+2*AddressLength || (addr[:2] != "0x" && addr[:2] != "0X") {
+		return nil, ErrInvalidAddress
+	}
+	digits := addr[2:]
+	b, err := hex.DecodeString(digits)
+	if err != nil {
+		return nil, ErrInvalidAddress
+	}
+	//all lower or all upper case carries no checksum, per EIP-55
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return b, nil
+	}
+	if ChecksumAddress(b)[2:] != digits {
+		return nil, ErrBadChecksum
+	}
+	return b, nil
+}
+
+//ValidateAddress checks addr is a 0x prefixed hex address. Mixed case
+//addresses must match their EIP-55 checksum, all lower or upper case ones
+//carry no checksum and are accepted.
+func ValidateAddress(addr string) error {
+	_, err := ParseAddress(addr)
+	return err
+}
@@ -0,0 +1,63 @@
+package eth
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+//test vectors from EIP-55
+var checksumCases = []string{
+	"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+	"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+	"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+	"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	"0x52908400098527886E0F7030069857D2E4169EE7",
+	"0x8617E340B3D01FA5F11F306F4090FD50E238070D",
+	"0xde709f2102306220921060314715629080e2fb77",
+	"0x27b1fdb04752bbc536007a920d24acb045561c26",
+}
+
+func TestChecksumAddress(t *testing.T) {
+	for _, c := range checksumCases {
+		b, err := hex.DecodeString(strings.ToLower(c[2:]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if addr := ChecksumAddress(b); addr != c {
+			t.Errorf("checksum address not matched: %s|%s", c, addr)
+		}
+	}
+}
+
+func TestValidateAddress(t *testing.T) {
+	for _, c := range checksumCases {
+		if err := ValidateAddress(c); err != nil {
+			t.Errorf("valid address %s rejected: %v", c, err)
+		}
+	}
+	//no checksum
+	if err := ValidateAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"); err != nil {
+		t.Errorf("lower case address rejected: %v", err)
+	}
+	if err := ValidateAddress("0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED"); err != nil {
+		t.Errorf("upper case address rejected: %v", err)
+	}
+
+	cases := []struct {
+		addr string
+		err  error
+	}{
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", ErrBadChecksum},
+		{"0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", ErrBadChecksum},
+		{"5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", ErrInvalidAddress},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", ErrInvalidAddress},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAgg", ErrInvalidAddress},
+		{"", ErrInvalidAddress},
+	}
+	for _, c := range cases {
+		if err := ValidateAddress(c.addr); err != c.err {
+			t.Errorf("address %s: expected %v, got %v", c.addr, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package eth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+//Client talks to an ethereum node over JSON-RPC.
+type Client struct {
+	url        string
+	httpClient *http.Client
+	id         uint64
+}
+
+//RPCError is an error returned by the node.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("eth rpc error %d: %s", e.Code, e.Message)
+}
+
+//ErrNotFound is returned when the node has no such object.
+var ErrNotFound = errors.New("not found")
+
+//CallMsg describes a transaction for EstimateGas, zero fields are left to the node.
+type CallMsg struct {
+	From     string
+	To       string
+	Gas      uint64
+	GasPrice *big.Int
+	Value    *big.Int
+	Data     []byte
+}
+
+func (msg CallMsg) toArg() interface{} {
+	arg := map[string]interface{}{}
+	if msg.From != "" {
+		arg["from"] = msg.From
+	}
+	if msg.To != "" {
+		arg["to"] = msg.To
+	}
+	if msg.Gas != 0 {
+		arg["gas"] = hexutil.Uint64(msg.Gas)
+	}
+	if msg.GasPrice != nil {
+		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+	if len(msg.Data) > 0 {
+		arg["data"] = hexutil.Bytes(msg.Data)
+	}
+	return arg
+}
+
+//Receipt is the receipt of a mined transaction.
+type Receipt struct {
+	TxHash          common.Hash     `json:"transactionHash"`
+	BlockHash       common.Hash     `json:"blockHash"`
+	BlockNumber     *hexutil.Big    `json:"blockNumber"`
+	GasUsed         hexutil.Uint64  `json:"gasUsed"`
+	Status          hexutil.Uint64  `json:"status"`
+	ContractAddress *common.Address `json:"contractAddress"`
+}
+
+//FeeHistory is the fee history of a range of blocks. BaseFee has one entry
+//more than the range, the base fee of the block after newest.
+type FeeHistory struct {
+	OldestBlock  *big.Int
+	BaseFee      []*big.Int
+	GasUsedRatio []float64
+	Reward       [][]*big.Int
+}
+
+//NextBaseFee returns the base fee of the next block.
+func (h *FeeHistory) NextBaseFee() (*big.Int, error) {
+	if len(h.BaseFee) == 0 || h.BaseFee[len(h.BaseFee)-1] == nil {
+		return nil, errors.New("node reports no base fee, chain does not support EIP-1559")
+	}
+	return h.BaseFee[len(h.BaseFee)-1], nil
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+//NewClient creates a client for the node at url.
+func NewClient(url string) *Client {
+	c := &Client{}
+	c.url = url
+	c.httpClient = &http.Client{Timeout: 30 * time.Second}
+	return c
+}
+
+//call invokes method with params and decodes the result into result.
+func (c *Client) call(result interface{}, method string, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	req := rpcRequest{JSONRPC: "2.0", ID: atomic.AddUint64(&c.id, 1), Method: method, Params: params}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Post(c.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("eth node returns %s: %s", resp.Status, body)
+	}
+	var r rpcResponse
+	if err = json.Unmarshal(body, &r); err != nil {
+		return err
+	}
+	if r.Error != nil {
+		return r.Error
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(r.Result, result)
+}
+
+//BalanceAt returns the balance in wei of addr at block, a block number in hex
+//or one of "latest", "pending", "earliest".
+func (c *Client) BalanceAt(addr string, block string) (*big.Int, error) {
+	var balance hexutil.Big
+	if err := c.call(&balance, "eth_getBalance", addr, block); err != nil {
+		return nil, err
+	}
+	return (*big.Int)(&balance), nil
+}
+
+//SuggestGasPrice returns the gas price in wei suggested by the node.
+func (c *Client) SuggestGasPrice() (*big.Int, error) {
+	var price hexutil.Big
+	if err := c.call(&price, "eth_gasPrice"); err != nil {
+		return nil, err
+	}
+	return (*big.Int)(&price), nil
+}
+
+//MaxPriorityFeePerGas returns the EIP-1559 tip in wei suggested by the node.
+func (c *Client) MaxPriorityFeePerGas() (*big.Int, error) {
+	var tip hexutil.Big
+	if err := c.call(&tip, "eth_maxPriorityFeePerGas"); err != nil {
+		return nil, err
+	}
+	return (*big.Int)(&tip), nil
+}
+
+//FeeHistory returns the base fees and the priority fee percentiles of the
+//blocks up to newest.
+func (c *Client) FeeHistory(blocks uint64, newest string, percentiles []float64) (*FeeHistory, error) {
+	if percentiles == nil {
+		percentiles = []float64{}
+	}
+	var r struct {
+		OldestBlock   *hexutil.Big     `json:"oldestBlock"`
+		BaseFeePerGas []*hexutil.Big   `json:"baseFeePerGas"`
+		GasUsedRatio  []float64        `json:"gasUsedRatio"`
+		Reward        [][]*hexutil.Big `json:"reward"`
+	}
+	if err := c.call(&r, "eth_feeHistory", hexutil.Uint64(blocks), newest, percentiles); err != nil {
+		return nil, err
+	}
+	history := &FeeHistory{}
+	history.OldestBlock = (*big.Int)(r.OldestBlock)
+	for _, fee := range r.BaseFeePerGas {
+		history.BaseFee = append(history.BaseFee, (*big.Int)(fee))
+	}
+	history.GasUsedRatio = r.GasUsedRatio
+	for _, rewards := range r.Reward {
+		blockRewards := make([]*big.Int, 0, len(rewards))
+		for _, reward := range rewards {
+			blockRewards = append(blockRewards, (*big.Int)(reward))
+		}
+		history.Reward = append(history.Reward, blockRewards)
+	}
+	return history, nil
+}
+
+//EstimateGas returns the gas the node expects msg to use.
+func (c *Client) EstimateGas(msg CallMsg) (uint64, error) {
+	var gas hexutil.Uint64
+	if err := c.call(&gas, "eth_estimateGas", msg.toArg()); err != nil {
+		return 0, err
+	}
+	return uint64(gas), nil
+}
+
+//TransactionCount returns the number of transactions sent from addr at block.
+func (c *Client) TransactionCount(addr string, block string) (uint64, error) {
+	var count hexutil.Uint64
+	if err := c.call(&count, "eth_getTransactionCount", addr, block); err != nil {
+		return 0, err
+	}
+	return uint64(count), nil
+}
+
+//PendingNonceAt returns the transaction count of addr including pending
+//transactions, which is the nonce of its next transaction.
+func (c *Client) PendingNonceAt(addr string) (uint64, error) {
+	return c.TransactionCount(addr, "pending")
+}
+
+//SendRawTransaction broadcasts a signed rlp encoded transaction and returns its hash.
+func (c *Client) SendRawTransaction(rawTX []byte) (common.Hash, error) {
+	var hash common.Hash
+	if err := c.call(&hash, "eth_sendRawTransaction", hexutil.Bytes(rawTX)); err != nil {
+		return common.Hash{}, err
+	}
+	return hash, nil
+}
+
+//TransactionReceipt returns the receipt of a mined transaction, ErrNotFound
+//while it is pending or unknown.
+func (c *Client) TransactionReceipt(hash common.Hash) (*Receipt, error) {
+	var receipt *Receipt
+	if err := c.call(&receipt, "eth_getTransactionReceipt", hash); err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		return nil, ErrNotFound
+	}
+	return receipt, nil
+}
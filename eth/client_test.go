@@ -0,0 +1,115 @@
+package eth
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GameLeLe/trade-addr-tx-service/eth/ethtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestPendingNonceAt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var req rpcRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Error(err)
+			return
+		}
+		if req.Method != "eth_getTransactionCount" || len(req.Params) != 2 || req.Params[1] != "pending" {
+			t.Errorf("unexpected request: %s", body)
+		}
+		if req.Params[0] == "0x0000000000000000000000000000000000000000" {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"boom"}}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1a"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL)
+	nonce, err := c.PendingNonceAt("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nonce != 26 {
+		t.Errorf("expected nonce 26, got %d", nonce)
+	}
+	_, err = c.PendingNonceAt("0x0000000000000000000000000000000000000000")
+	if e, ok := err.(*RPCError); !ok || e.Code != -32000 {
+		t.Errorf("expected rpc error, got %v", err)
+	}
+}
+
+func TestClientFakeNode(t *testing.T) {
+	node := ethtest.NewFakeNode(3)
+	defer node.Close()
+	c := NewClient(node.URL())
+
+	key, _ := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x3535353535353535353535353535353535353535")
+	node.SetBalance(from.Hex(), big.NewInt(1e18))
+	node.SetNonce(from.Hex(), 4)
+	node.SetGasPrice(big.NewInt(3e9))
+
+	balance, err := c.BalanceAt(from.Hex(), "latest")
+	if err != nil || balance.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("balance not matched: %v %v", balance, err)
+	}
+	price, err := c.SuggestGasPrice()
+	if err != nil || price.Cmp(big.NewInt(3e9)) != 0 {
+		t.Errorf("gas price not matched: %v %v", price, err)
+	}
+	gas, err := c.EstimateGas(CallMsg{From: from.Hex(), To: to.Hex(), Value: big.NewInt(1)})
+	if err != nil || gas != 21000 {
+		t.Errorf("gas estimate not matched: %v %v", gas, err)
+	}
+	nonce, err := c.PendingNonceAt(from.Hex())
+	if err != nil || nonce != 4 {
+		t.Errorf("nonce not matched: %v %v", nonce, err)
+	}
+
+	tx := types.NewTransaction(nonce, to, big.NewInt(1), big.NewInt(int64(gas)), price, nil)
+	signed, err := types.SignTx(tx, types.NewEIP155Signer(big.NewInt(3)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := c.SendRawTransaction(raw)
+	if err != nil {
+		t.Fatalf("send raw transaction error: %v", err)
+	}
+	if hash != signed.Hash() {
+		t.Errorf("tx hash not matched: %s|%s", hash.Hex(), signed.Hash().Hex())
+	}
+	//the same nonce can not be used twice
+	if _, err := c.SendRawTransaction(raw); err == nil {
+		t.Errorf("replayed transaction should be rejected")
+	}
+	count, _ := c.TransactionCount(from.Hex(), "latest")
+	if count != 5 {
+		t.Errorf("transaction count not matched: %d", count)
+	}
+
+	receipt, err := c.TransactionReceipt(hash)
+	if err != nil {
+		t.Fatalf("get receipt error: %v", err)
+	}
+	if receipt.TxHash != hash || receipt.Status != 1 || receipt.BlockNumber.ToInt().Int64() != 1 {
+		t.Errorf("unexpected receipt: %+v", receipt)
+	}
+	if _, err := c.TransactionReceipt(common.Hash{}); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package eth
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+//DynamicFeeTXType is the EIP-2718 type byte of EIP-1559 transactions.
+const DynamicFeeTXType = 0x02
+
+//AccessTuple is an entry of an EIP-2930 access list.
+type AccessTuple struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []common.Hash  `json:"storageKeys"`
+}
+
+//DynamicFeeTX is an EIP-1559 transaction, To is nil for contract creation.
+//V, R and S are nil until it is signed.
+type DynamicFeeTX struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         *common.Address
+	Value      *big.Int
+	Data       []byte
+	AccessList []AccessTuple
+	V, R, S    *big.Int
+}
+
+//NewDynamicFeeTX makes an unsigned dynamic fee transaction paying fee.
+func NewDynamicFeeTX(chainID *big.Int, nonce uint64, to *common.Address, value *big.Int, gas uint64, fee *Fee, data []byte) *DynamicFeeTX {
+	tx := &DynamicFeeTX{}
+	tx.ChainID = new(big.Int).Set(chainID)
+	tx.Nonce = nonce
+	tx.GasTipCap = new(big.Int).Set(fee.GasTipCap)
+	tx.GasFeeCap = new(big.Int).Set(fee.GasFeeCap)
+	tx.Gas = gas
+	tx.To = to
+	tx.Value = new(big.Int).Set(value)
+	tx.Data = data
+	tx.AccessList = []AccessTuple{}
+	return tx
+}
+
+//fields returns the rlp list of the unsigned transaction.
+func (tx *DynamicFeeTX) fields() []interface{} {
+	to := []byte{}
+	if tx.To != nil {
+		to = tx.To[:]
+	}
+	accessList := tx.AccessList
+	if accessList == nil {
+		accessList = []AccessTuple{}
+	}
+	return []interface{}{tx.ChainID, tx.Nonce, tx.GasTipCap, tx.GasFeeCap, tx.Gas, to, tx.Value, tx.Data, accessList}
+}
+
+//SigHash returns the hash to sign, keccak256(0x02 || rlp(fields)).
+func (tx *DynamicFeeTX) SigHash() common.Hash {
+	payload, _ := rlp.EncodeToBytes(tx.fields())
+	return common.BytesToHash(crypto.Keccak256([]byte{DynamicFeeTXType}, payload))
+}
+
+//WithSignature returns a copy of tx carrying sig, a 65 byte [R || S || V]
+//signature over SigHash with V in {0, 1}.
+func (tx *DynamicFeeTX) WithSignature(sig []byte) (*DynamicFeeTX, error) {
+	if len(sig) != 65 || sig[64] > 1 {
+		return nil, errors.New("invalid signature")
+	}
+	signed := *tx
+	signed.R = new(big.Int).SetBytes(sig[:32])
+	signed.S = new(big.Int).SetBytes(sig[32:64])
+	signed.V = big.NewInt(int64(sig[64]))
+	return &signed, nil
+}
+
+//MarshalBinary returns the signed transaction as broadcast,
+//0x02 || rlp(fields || yParity, r, s).
+func (tx *DynamicFeeTX) MarshalBinary() ([]byte, error) {
+	if tx.V == nil || tx.R == nil || tx.S == nil {
+		return nil, errors.New("transaction is not signed")
+	}
+	payload, err := rlp.EncodeToBytes(append(tx.fields(), tx.V, tx.R, tx.S))
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{DynamicFeeTXType}, payload...), nil
+}
+
+//Hash returns the hash of the signed transaction.
+func (tx *DynamicFeeTX) Hash() (common.Hash, error) {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(crypto.Keccak256(raw)), nil
+}
+
+//Sender recovers the address that signed tx.
+func (tx *DynamicFeeTX) Sender() (common.Address, error) {
+	if tx.V == nil || tx.R == nil || tx.S == nil || tx.V.BitLen() > 1 {
+		return common.Address{}, errors.New("transaction is not signed")
+	}
+	sig := make([]byte, 65)
+	rBytes, sBytes := tx.R.Bytes(), tx.S.Bytes()
+	if len(rBytes) > 32 || len(sBytes) > 32 {
+		return common.Address{}, errors.New("invalid signature")
+	}
+	copy(sig[32-len(rBytes):32], rBytes)
+	copy(sig[64-len(sBytes):64], sBytes)
+	sig[64] = byte(tx.V.Uint64())
+	hash := tx.SigHash()
+	pub, err := crypto.Ecrecover(hash[:], sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var addr common.Address
+	copy(addr[:], crypto.Keccak256(pub[1:])[12:])
+	return addr, nil
+}
+
+//DecodeDynamicFeeTX decodes a signed transaction encoded by MarshalBinary.
+func DecodeDynamicFeeTX(raw []byte) (*DynamicFeeTX, error) {
+	if len(raw) == 0 || raw[0] != DynamicFeeTXType {
+		return nil, errors.New("not a dynamic fee transaction")
+	}
+	var dec struct {
+		ChainID    *big.Int
+		Nonce      uint64
+		GasTipCap  *big.Int
+		GasFeeCap  *big.Int
+		Gas        uint64
+		To         []byte
+		Value      *big.Int
+		Data       []byte
+		AccessList []AccessTuple
+		V, R, S    *big.Int
+	}
+	if err := rlp.DecodeBytes(raw[1:], &dec); err != nil {
+		return nil, err
+	}
+	tx := &DynamicFeeTX{}
+	tx.ChainID = dec.ChainID
+	tx.Nonce = dec.Nonce
+	tx.GasTipCap = dec.GasTipCap
+	tx.GasFeeCap = dec.GasFeeCap
+	tx.Gas = dec.Gas
+	switch len(dec.To) {
+	case 0:
+	case common.AddressLength:
+		to := common.BytesToAddress(dec.To)
+		tx.To = &to
+	default:
+		return nil, errors.New("invalid recipient")
+	}
+	tx.Value = dec.Value
+	tx.Data = dec.Data
+	tx.AccessList = dec.AccessList
+	tx.V, tx.R, tx.S = dec.V, dec.R, dec.S
+	return tx, nil
+}
+
+//MarshalJSON encodes tx in the JSON-RPC form of type 2 transactions.
+func (tx *DynamicFeeTX) MarshalJSON() ([]byte, error) {
+	enc := struct {
+		Type                 hexutil.Uint64  `json:"type"`
+		ChainID              *hexutil.Big    `json:"chainId"`
+		Nonce                hexutil.Uint64  `json:"nonce"`
+		MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+		MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+		Gas                  hexutil.Uint64  `json:"gas"`
+		To                   *common.Address `json:"to"`
+		Value                *hexutil.Big    `json:"value"`
+		Input                hexutil.Bytes   `json:"input"`
+		AccessList           []AccessTuple   `json:"accessList"`
+		V                    *hexutil.Big    `json:"v,omitempty"`
+		R                    *hexutil.Big    `json:"r,omitempty"`
+		S                    *hexutil.Big    `json:"s,omitempty"`
+	}{}
+	enc.Type = DynamicFeeTXType
+	enc.ChainID = (*hexutil.Big)(tx.ChainID)
+	enc.Nonce = hexutil.Uint64(tx.Nonce)
+	enc.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap)
+	enc.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap)
+	enc.Gas = hexutil.Uint64(tx.Gas)
+	enc.To = tx.To
+	enc.Value = (*hexutil.Big)(tx.Value)
+	enc.Input = tx.Data
+	enc.AccessList = tx.AccessList
+	if enc.AccessList == nil {
+		enc.AccessList = []AccessTuple{}
+	}
+	enc.V = (*hexutil.Big)(tx.V)
+	enc.R = (*hexutil.Big)(tx.R)
+	enc.S = (*hexutil.Big)(tx.S)
+	return json.Marshal(&enc)
+}
@@ -0,0 +1,90 @@
+package eth
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+//TransferSelector is the 4 byte selector of transfer(address,uint256)
+var TransferSelector = crypto.Keccak256([]byte("transfer(address,uint256)"))[:4]
+
+//maxTokenDecimals bounds decimals so 10^decimals fits a uint256
+const maxTokenDecimals = 77
+
+//GasEstimator estimates the gas of a call, Client implements it.
+type GasEstimator interface {
+	EstimateGas(msg CallMsg) (uint64, error)
+}
+
+//Token is an ERC-20 token contract. Amounts are in its smallest unit,
+//10^-Decimals of a whole token.
+type Token struct {
+	Symbol   string
+	Contract common.Address
+	Decimals uint8
+}
+
+//NewToken checks the metadata of a token, contract is a hex address.
+func NewToken(symbol string, contract string, decimals int) (*Token, error) {
+	if symbol == "" {
+		return nil, errors.New("token symbol is empty")
+	}
+	addr, err := ParseAddress(contract)
+	if err != nil {
+		return nil, err
+	}
+	if decimals < 0 || decimals > maxTokenDecimals {
+		return nil, errors.New("token decimals out of range")
+	}
+	token := &Token{}
+	token.Symbol = strings.ToUpper(symbol)
+	token.Contract = common.BytesToAddress(addr)
+	token.Decimals = uint8(decimals)
+	return token, nil
+}
+
+//TransferData returns the calldata of transfer(to, amount).
+func (t *Token) TransferData(to common.Address, amount *big.Int) ([]byte, error) {
+	if amount.Sign() < 0 || amount.BitLen() > 256 {
+		return nil, errors.New("token amount out of range")
+	}
+	data := make([]byte, 0, 4+32+32)
+	data = append(data, TransferSelector...)
+	data = append(data, common.LeftPadBytes(to[:], 32)...)
+	data = append(data, math.PaddedBigBytes(amount, 32)...)
+	return data, nil
+}
+
+//TokenTransfer describes the token transfer an unsigned tx carries, for the
+//signer to show what the calldata does.
+type TokenTransfer struct {
+	Symbol   string         `json:"symbol"`
+	Contract common.Address `json:"contract"`
+	Decimals uint8          `json:"decimals"`
+	To       common.Address `json:"to"`
+	Amount   *hexutil.Big   `json:"amount"`
+}
+
+//NewTokenTransfer describes a transfer of amount of t to to.
+func (t *Token) NewTokenTransfer(to common.Address, amount *big.Int) *TokenTransfer {
+	transfer := &TokenTransfer{}
+	transfer.Symbol = t.Symbol
+	transfer.Contract = t.Contract
+	transfer.Decimals = t.Decimals
+	transfer.To = to
+	transfer.Amount = (*hexutil.Big)(new(big.Int).Set(amount))
+	return transfer
+}
+
+//GasWithMargin adds 20% to an estimate, a token transfer can cost more when
+//it is mined than when it was estimated, e.g. if the recipient's balance was
+//emptied in between.
+func GasWithMargin(gas uint64) uint64 {
+	return gas + gas/5
+}
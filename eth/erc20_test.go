@@ -0,0 +1,50 @@
+package eth
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestTokenTransferData(t *testing.T) {
+	token, err := NewToken("usdt", "0xdAC17F958D2ee523a2206206994597C13D831ec7", 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Symbol != "USDT" || token.Decimals != 6 {
+		t.Errorf("unexpected token: %+v", token)
+	}
+	to := common.HexToAddress("0x3535353535353535353535353535353535353535")
+	data, err := token.TransferData(to, big.NewInt(1500000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "a9059cbb" +
+		"0000000000000000000000003535353535353535353535353535353535353535" +
+		"000000000000000000000000000000000000000000000000000000000016e360"
+	if hex.EncodeToString(data) != expected {
+		t.Errorf("transfer data not matched: %x", data)
+	}
+	if _, err := token.TransferData(to, big.NewInt(-1)); err == nil {
+		t.Errorf("negative amount should be rejected")
+	}
+
+	for _, c := range []struct {
+		symbol   string
+		contract string
+		decimals int
+	}{
+		{"", "0xdAC17F958D2ee523a2206206994597C13D831ec7", 6},
+		{"USDT", "0xdac17f958d2ee523a2206206994597c13d831eC7", 6},
+		{"USDT", "0xdAC17F958D2ee523a2206206994597C13D831ec7", 78},
+	} {
+		if _, err := NewToken(c.symbol, c.contract, c.decimals); err == nil {
+			t.Errorf("token %+v should be rejected", c)
+		}
+	}
+	if GasWithMargin(50000) != 60000 {
+		t.Errorf("gas margin not matched: %d", GasWithMargin(50000))
+	}
+}
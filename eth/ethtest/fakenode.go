@@ -0,0 +1,420 @@
+//Package ethtest provides a fake ethereum node for offline tests.
+package ethtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+//FakeNode is an in-memory ethereum node served over JSON-RPC by an
+//httptest server. Transactions sent to it are mined immediately.
+type FakeNode struct {
+	mu       sync.Mutex
+	server   *httptest.Server
+	chainID  *big.Int
+	balances map[string]*big.Int
+	nonces   map[string]uint64
+	gasPrice *big.Int
+	gas      uint64
+	baseFee  *big.Int
+	tip      *big.Int
+	rewards  []*big.Int
+	sent     []*SentTX
+	calls    []string
+}
+
+//SentTX is a transaction accepted by the node. GasPrice is set for legacy
+//transactions, GasFeeCap and GasTipCap for EIP-1559 ones.
+type SentTX struct {
+	Type      byte
+	Hash      common.Hash
+	From      common.Address
+	Nonce     uint64
+	To        *common.Address
+	Value     *big.Int
+	Gas       uint64
+	Data      []byte
+	GasPrice  *big.Int
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
+}
+
+type request struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+//NewFakeNode starts a node for chainID with a gas price of 20 gwei, gas
+//estimates of 21000, a base fee of 10 gwei and priority fees of 1 gwei.
+func NewFakeNode(chainID int64) *FakeNode {
+	n := &FakeNode{}
+	n.chainID = big.NewInt(chainID)
+	n.balances = make(map[string]*big.Int)
+	n.nonces = make(map[string]uint64)
+	n.gasPrice = big.NewInt(20000000000)
+	n.gas = 21000
+	n.baseFee = big.NewInt(10000000000)
+	n.tip = big.NewInt(1000000000)
+	n.server = httptest.NewServer(http.HandlerFunc(n.serveHTTP))
+	return n
+}
+
+//URL returns the JSON-RPC endpoint of the node.
+func (n *FakeNode) URL() string {
+	return n.server.URL
+}
+
+//Close shuts the node down.
+func (n *FakeNode) Close() {
+	n.server.Close()
+}
+
+//SetBalance sets the balance in wei of addr.
+func (n *FakeNode) SetBalance(addr string, balance *big.Int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.balances[strings.ToLower(addr)] = new(big.Int).Set(balance)
+}
+
+//SetNonce sets the transaction count of addr.
+func (n *FakeNode) SetNonce(addr string, nonce uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.nonces[strings.ToLower(addr)] = nonce
+}
+
+//SetGasPrice sets the price returned by eth_gasPrice.
+func (n *FakeNode) SetGasPrice(price *big.Int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.gasPrice = new(big.Int).Set(price)
+}
+
+//SetGasEstimate sets the gas returned by eth_estimateGas.
+func (n *FakeNode) SetGasEstimate(gas uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.gas = gas
+}
+
+//SetBaseFee sets the base fee of the blocks, nil makes the node a chain
+//without EIP-1559 which rejects dynamic fee transactions.
+func (n *FakeNode) SetBaseFee(baseFee *big.Int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if baseFee == nil {
+		n.baseFee = nil
+		return
+	}
+	n.baseFee = new(big.Int).Set(baseFee)
+}
+
+//SetPriorityFee sets the tip returned by eth_maxPriorityFeePerGas.
+func (n *FakeNode) SetPriorityFee(tip *big.Int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.tip = new(big.Int).Set(tip)
+}
+
+//SetRewards sets the priority fees paid in recent blocks, one per block and
+//oldest first, which eth_feeHistory reports for every percentile.
+func (n *FakeNode) SetRewards(rewards []*big.Int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.rewards = append([]*big.Int(nil), rewards...)
+}
+
+//Sent returns the transactions broadcast to the node.
+func (n *FakeNode) Sent() []*SentTX {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]*SentTX(nil), n.sent...)
+}
+
+//Calls returns the JSON-RPC methods called so far.
+func (n *FakeNode) Calls() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.calls...)
+}
+
+func (n *FakeNode) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp := response{JSONRPC: "2.0", ID: req.ID}
+	n.mu.Lock()
+	n.calls = append(n.calls, req.Method)
+	resp.Result, err = n.handle(req.Method, req.Params)
+	n.mu.Unlock()
+	if err != nil {
+		resp.Result = nil
+		resp.Error = &rpcError{Code: -32000, Message: err.Error()}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (n *FakeNode) handle(method string, params []json.RawMessage) (interface{}, error) {
+	switch method {
+	case "eth_getBalance":
+		addr, err := stringParam(params, 0)
+		if err != nil {
+			return nil, err
+		}
+		balance := n.balances[strings.ToLower(addr)]
+		if balance == nil {
+			balance = new(big.Int)
+		}
+		return (*hexutil.Big)(balance), nil
+	case "eth_gasPrice":
+		return (*hexutil.Big)(n.gasPrice), nil
+	case "eth_maxPriorityFeePerGas":
+		if n.baseFee == nil {
+			return nil, fmt.Errorf("the method %s does not exist", method)
+		}
+		return (*hexutil.Big)(n.tip), nil
+	case "eth_feeHistory":
+		return n.feeHistory(params)
+	case "eth_estimateGas":
+		return hexutil.Uint64(n.gas), nil
+	case "eth_getTransactionCount":
+		addr, err := stringParam(params, 0)
+		if err != nil {
+			return nil, err
+		}
+		return hexutil.Uint64(n.nonces[strings.ToLower(addr)]), nil
+	case "eth_sendRawTransaction":
+		return n.sendRawTransaction(params)
+	case "eth_getTransactionReceipt":
+		return n.receipt(params)
+	default:
+		return nil, fmt.Errorf("the method %s does not exist", method)
+	}
+}
+
+func (n *FakeNode) sendRawTransaction(params []json.RawMessage) (interface{}, error) {
+	if len(params) < 1 {
+		return nil, fmt.Errorf("missing raw transaction")
+	}
+	var raw hexutil.Bytes
+	if err := json.Unmarshal(params[0], &raw); err != nil {
+		return nil, err
+	}
+	var tx *SentTX
+	var err error
+	if len(raw) > 0 && raw[0] == dynamicFeeTXType {
+		tx, err = n.decodeDynamicFeeTX(raw)
+	} else {
+		tx, err = n.decodeLegacyTX(raw)
+	}
+	if err != nil {
+		return nil, err
+	}
+	key := strings.ToLower(tx.From.Hex())
+	if tx.Nonce != n.nonces[key] {
+		return nil, fmt.Errorf("invalid nonce %d, expected %d", tx.Nonce, n.nonces[key])
+	}
+	n.nonces[key]++
+	n.sent = append(n.sent, tx)
+	return tx.Hash, nil
+}
+
+func (n *FakeNode) decodeLegacyTX(raw []byte) (*SentTX, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(raw, tx); err != nil {
+		return nil, err
+	}
+	from, err := types.Sender(types.NewEIP155Signer(n.chainID), tx)
+	if err != nil {
+		return nil, err
+	}
+	sent := &SentTX{}
+	sent.Hash = tx.Hash()
+	sent.From = from
+	sent.Nonce = tx.Nonce()
+	sent.To = tx.To()
+	sent.Value = tx.Value()
+	sent.Gas = tx.Gas().Uint64()
+	sent.Data = tx.Data()
+	sent.GasPrice = tx.GasPrice()
+	return sent, nil
+}
+
+const dynamicFeeTXType = 0x02
+
+//decodeDynamicFeeTX decodes and checks an EIP-1559 transaction,
+//0x02 || rlp([chainId, nonce, tip, feeCap, gas, to, value, data, accessList, v, r, s])
+func (n *FakeNode) decodeDynamicFeeTX(raw []byte) (*SentTX, error) {
+	if n.baseFee == nil {
+		return nil, fmt.Errorf("transaction type not supported")
+	}
+	var dec struct {
+		ChainID    *big.Int
+		Nonce      uint64
+		GasTipCap  *big.Int
+		GasFeeCap  *big.Int
+		Gas        uint64
+		To         []byte
+		Value      *big.Int
+		Data       []byte
+		AccessList []struct {
+			Address     common.Address
+			StorageKeys []common.Hash
+		}
+		V, R, S *big.Int
+	}
+	if err := rlp.DecodeBytes(raw[1:], &dec); err != nil {
+		return nil, err
+	}
+	if dec.ChainID.Cmp(n.chainID) != 0 {
+		return nil, fmt.Errorf("invalid chain id %v", dec.ChainID)
+	}
+	if dec.GasTipCap.Cmp(dec.GasFeeCap) > 0 {
+		return nil, fmt.Errorf("max priority fee per gas higher than max fee per gas")
+	}
+	if dec.GasFeeCap.Cmp(n.baseFee) < 0 {
+		return nil, fmt.Errorf("max fee per gas less than block base fee")
+	}
+	if dec.V.BitLen() > 1 || dec.R.BitLen() > 256 || dec.S.BitLen() > 256 {
+		return nil, fmt.Errorf("invalid signature")
+	}
+	payload, err := rlp.EncodeToBytes([]interface{}{dec.ChainID, dec.Nonce, dec.GasTipCap, dec.GasFeeCap, dec.Gas, dec.To, dec.Value, dec.Data, dec.AccessList})
+	if err != nil {
+		return nil, err
+	}
+	sigHash := crypto.Keccak256([]byte{dynamicFeeTXType}, payload)
+	sig := make([]byte, 65)
+	copy(sig[32-len(dec.R.Bytes()):32], dec.R.Bytes())
+	copy(sig[64-len(dec.S.Bytes()):64], dec.S.Bytes())
+	sig[64] = byte(dec.V.Uint64())
+	pub, err := crypto.Ecrecover(sigHash, sig)
+	if err != nil {
+		return nil, err
+	}
+	tx := &SentTX{}
+	tx.Type = dynamicFeeTXType
+	tx.Hash = common.BytesToHash(crypto.Keccak256(raw))
+	copy(tx.From[:], crypto.Keccak256(pub[1:])[12:])
+	tx.Nonce = dec.Nonce
+	if len(dec.To) > 0 {
+		to := common.BytesToAddress(dec.To)
+		tx.To = &to
+	}
+	tx.Value = dec.Value
+	tx.Gas = dec.Gas
+	tx.Data = dec.Data
+	tx.GasFeeCap = dec.GasFeeCap
+	tx.GasTipCap = dec.GasTipCap
+	return tx, nil
+}
+
+func (n *FakeNode) feeHistory(params []json.RawMessage) (interface{}, error) {
+	if n.baseFee == nil {
+		return nil, fmt.Errorf("the method eth_feeHistory does not exist")
+	}
+	if len(params) < 3 {
+		return nil, fmt.Errorf("missing params")
+	}
+	var blocks hexutil.Uint64
+	if err := json.Unmarshal(params[0], &blocks); err != nil {
+		return nil, err
+	}
+	var percentiles []float64
+	if err := json.Unmarshal(params[2], &percentiles); err != nil {
+		return nil, err
+	}
+	count := int(blocks)
+	if len(n.rewards) > 0 && count > len(n.rewards) {
+		count = len(n.rewards)
+	}
+	result := map[string]interface{}{}
+	result["oldestBlock"] = hexutil.Uint64(1)
+	baseFees := make([]*hexutil.Big, 0, count+1)
+	ratios := make([]float64, 0, count)
+	for i := 0; i <= count; i++ {
+		baseFees = append(baseFees, (*hexutil.Big)(n.baseFee))
+	}
+	for i := 0; i < count; i++ {
+		ratios = append(ratios, 0.5)
+	}
+	result["baseFeePerGas"] = baseFees
+	result["gasUsedRatio"] = ratios
+	if len(percentiles) > 0 {
+		rewards := make([][]*hexutil.Big, 0, count)
+		for i := 0; i < count; i++ {
+			reward := n.tip
+			if len(n.rewards) > 0 {
+				reward = n.rewards[len(n.rewards)-count+i]
+			}
+			blockRewards := make([]*hexutil.Big, 0, len(percentiles))
+			for range percentiles {
+				blockRewards = append(blockRewards, (*hexutil.Big)(reward))
+			}
+			rewards = append(rewards, blockRewards)
+		}
+		result["reward"] = rewards
+	}
+	return result, nil
+}
+
+func (n *FakeNode) receipt(params []json.RawMessage) (interface{}, error) {
+	hashStr, err := stringParam(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	hash := common.HexToHash(hashStr)
+	for i, tx := range n.sent {
+		if tx.Hash == hash {
+			return map[string]interface{}{
+				"transactionHash": hash,
+				"blockHash":       common.BigToHash(big.NewInt(int64(i + 1))),
+				"blockNumber":     (*hexutil.Big)(big.NewInt(int64(i + 1))),
+				"gasUsed":         hexutil.Uint64(tx.Gas),
+				"status":          hexutil.Uint64(1),
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+func stringParam(params []json.RawMessage, i int) (string, error) {
+	if len(params) <= i {
+		return "", fmt.Errorf("missing param %d", i)
+	}
+	var s string
+	err := json.Unmarshal(params[i], &s)
+	return s, err
+}
@@ -0,0 +1,154 @@
+package eth
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+)
+
+//DefaultFeeHistoryBlocks is how many recent blocks PercentileFee looks at.
+const DefaultFeeHistoryBlocks = 20
+
+//Fee is the gas pricing of a transaction. GasPrice is set for legacy
+//transactions, GasFeeCap (maxFeePerGas) and GasTipCap (maxPriorityFeePerGas)
+//for EIP-1559 dynamic fee transactions.
+type Fee struct {
+	GasPrice  *big.Int
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
+}
+
+//IsDynamic reports whether the fee is for an EIP-1559 transaction.
+func (f *Fee) IsDynamic() bool {
+	return f.GasPrice == nil
+}
+
+//FeeStrategy decides the fee of a transaction, a legacy gas price if dynamic
+//is false or the EIP-1559 fee caps otherwise.
+type FeeStrategy interface {
+	Fee(dynamic bool) (*Fee, error)
+}
+
+//FeeOracle is the node side of the fee strategies, Client implements it.
+type FeeOracle interface {
+	SuggestGasPrice() (*big.Int, error)
+	MaxPriorityFeePerGas() (*big.Int, error)
+	FeeHistory(blocks uint64, newest string, percentiles []float64) (*FeeHistory, error)
+}
+
+//FixedFee uses configured prices. GasPrice is the legacy price and the fee
+//cap of dynamic transactions unless GasFeeCap is set.
+type FixedFee struct {
+	GasPrice  *big.Int
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
+}
+
+//Fee returns the fixed prices.
+func (f *FixedFee) Fee(dynamic bool) (*Fee, error) {
+	if !dynamic {
+		if f.GasPrice == nil || f.GasPrice.Sign() <= 0 {
+			return nil, errors.New("fixed gas price not set")
+		}
+		return &Fee{GasPrice: new(big.Int).Set(f.GasPrice)}, nil
+	}
+	feeCap := f.GasFeeCap
+	if feeCap == nil {
+		feeCap = f.GasPrice
+	}
+	if feeCap == nil || feeCap.Sign() <= 0 || f.GasTipCap == nil || f.GasTipCap.Sign() < 0 {
+		return nil, errors.New("fixed fee caps not set")
+	}
+	return newDynamicFee(feeCap, f.GasTipCap)
+}
+
+//NodeSuggestedFee asks the node, eth_gasPrice for legacy transactions and
+//eth_maxPriorityFeePerGas on top of twice the next base fee for dynamic
+//ones, which stays valid through several full blocks.
+type NodeSuggestedFee struct {
+	Oracle FeeOracle
+}
+
+//Fee returns the prices suggested by the node.
+func (f *NodeSuggestedFee) Fee(dynamic bool) (*Fee, error) {
+	if !dynamic {
+		price, err := f.Oracle.SuggestGasPrice()
+		if err != nil {
+			return nil, err
+		}
+		return &Fee{GasPrice: price}, nil
+	}
+	tip, err := f.Oracle.MaxPriorityFeePerGas()
+	if err != nil {
+		return nil, err
+	}
+	history, err := f.Oracle.FeeHistory(1, "latest", nil)
+	if err != nil {
+		return nil, err
+	}
+	baseFee, err := history.NextBaseFee()
+	if err != nil {
+		return nil, err
+	}
+	return newDynamicFee(feeCapOf(baseFee, tip), tip)
+}
+
+//PercentileFee takes the Percentile of the priority fees paid in each of the
+//last Blocks blocks and uses the median of them as tip. Legacy transactions
+//pay the next base fee plus that tip, or the tip alone on chains without
+//EIP-1559 where the fees paid are whole gas prices.
+type PercentileFee struct {
+	Oracle     FeeOracle
+	Percentile float64
+	Blocks     uint64
+}
+
+//Fee returns prices derived from the fee history of recent blocks.
+func (f *PercentileFee) Fee(dynamic bool) (*Fee, error) {
+	if f.Percentile < 0 || f.Percentile > 100 {
+		return nil, errors.New("fee percentile must be in [0, 100]")
+	}
+	blocks := f.Blocks
+	if blocks == 0 {
+		blocks = DefaultFeeHistoryBlocks
+	}
+	history, err := f.Oracle.FeeHistory(blocks, "latest", []float64{f.Percentile})
+	if err != nil {
+		return nil, err
+	}
+	baseFee, err := history.NextBaseFee()
+	if err != nil {
+		if dynamic {
+			return nil, err
+		}
+		baseFee = new(big.Int)
+	}
+	tips := make([]*big.Int, 0, len(history.Reward))
+	for _, reward := range history.Reward {
+		if len(reward) > 0 && reward[0] != nil {
+			tips = append(tips, reward[0])
+		}
+	}
+	if len(tips) == 0 {
+		return nil, errors.New("no fee history rewards")
+	}
+	sort.Slice(tips, func(i, j int) bool { return tips[i].Cmp(tips[j]) < 0 })
+	tip := new(big.Int).Set(tips[len(tips)/2])
+	if !dynamic {
+		return &Fee{GasPrice: new(big.Int).Add(baseFee, tip)}, nil
+	}
+	return newDynamicFee(feeCapOf(baseFee, tip), tip)
+}
+
+//feeCapOf returns 2 * baseFee + tip
+func feeCapOf(baseFee, tip *big.Int) *big.Int {
+	feeCap := new(big.Int).Lsh(baseFee, 1)
+	return feeCap.Add(feeCap, tip)
+}
+
+func newDynamicFee(feeCap, tip *big.Int) (*Fee, error) {
+	if tip.Cmp(feeCap) > 0 {
+		return nil, errors.New("max priority fee exceeds max fee")
+	}
+	return &Fee{GasFeeCap: new(big.Int).Set(feeCap), GasTipCap: new(big.Int).Set(tip)}, nil
+}
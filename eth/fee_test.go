@@ -0,0 +1,91 @@
+package eth
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+//fakeFeeOracle answers with fixed prices, a nil baseFee is a chain without EIP-1559
+type fakeFeeOracle struct {
+	gasPrice *big.Int
+	tip      *big.Int
+	baseFee  *big.Int
+	rewards  []int64
+}
+
+func (o *fakeFeeOracle) SuggestGasPrice() (*big.Int, error) {
+	return o.gasPrice, nil
+}
+
+func (o *fakeFeeOracle) MaxPriorityFeePerGas() (*big.Int, error) {
+	if o.baseFee == nil {
+		return nil, errors.New("method not found")
+	}
+	return o.tip, nil
+}
+
+func (o *fakeFeeOracle) FeeHistory(blocks uint64, newest string, percentiles []float64) (*FeeHistory, error) {
+	h := &FeeHistory{BaseFee: []*big.Int{o.baseFee}}
+	for _, reward := range o.rewards {
+		h.Reward = append(h.Reward, []*big.Int{big.NewInt(reward)})
+	}
+	return h, nil
+}
+
+func TestFeeStrategies(t *testing.T) {
+	oracle := &fakeFeeOracle{gasPrice: big.NewInt(30), tip: big.NewInt(2), baseFee: big.NewInt(10), rewards: []int64{4, 1, 9, 3, 7}}
+	cases := []struct {
+		name     string
+		strategy FeeStrategy
+		dynamic  bool
+		expected Fee
+	}{
+		{"fixed legacy", &FixedFee{GasPrice: big.NewInt(50), GasTipCap: big.NewInt(5)}, false, Fee{GasPrice: big.NewInt(50)}},
+		{"fixed dynamic", &FixedFee{GasPrice: big.NewInt(50), GasTipCap: big.NewInt(5)}, true, Fee{GasFeeCap: big.NewInt(50), GasTipCap: big.NewInt(5)}},
+		{"fixed fee cap", &FixedFee{GasPrice: big.NewInt(50), GasFeeCap: big.NewInt(40), GasTipCap: big.NewInt(5)}, true, Fee{GasFeeCap: big.NewInt(40), GasTipCap: big.NewInt(5)}},
+		{"node legacy", &NodeSuggestedFee{Oracle: oracle}, false, Fee{GasPrice: big.NewInt(30)}},
+		{"node dynamic", &NodeSuggestedFee{Oracle: oracle}, true, Fee{GasFeeCap: big.NewInt(22), GasTipCap: big.NewInt(2)}},
+		{"percentile legacy", &PercentileFee{Oracle: oracle, Percentile: 60}, false, Fee{GasPrice: big.NewInt(14)}},
+		{"percentile dynamic", &PercentileFee{Oracle: oracle, Percentile: 60}, true, Fee{GasFeeCap: big.NewInt(24), GasTipCap: big.NewInt(4)}},
+		{"percentile legacy without base fee", &PercentileFee{Oracle: &fakeFeeOracle{rewards: []int64{40, 10, 30}}, Percentile: 50}, false, Fee{GasPrice: big.NewInt(30)}},
+	}
+	for _, c := range cases {
+		fee, err := c.strategy.Fee(c.dynamic)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if fee.IsDynamic() != c.dynamic {
+			t.Errorf("%s: dynamic not matched", c.name)
+		}
+		if !equalBig(fee.GasPrice, c.expected.GasPrice) || !equalBig(fee.GasFeeCap, c.expected.GasFeeCap) || !equalBig(fee.GasTipCap, c.expected.GasTipCap) {
+			t.Errorf("%s: fee not matched: %+v", c.name, fee)
+		}
+	}
+
+	errCases := []struct {
+		name     string
+		strategy FeeStrategy
+		dynamic  bool
+	}{
+		{"tip above cap", &FixedFee{GasPrice: big.NewInt(5), GasTipCap: big.NewInt(6)}, true},
+		{"no fixed price", &FixedFee{}, false},
+		{"bad percentile", &PercentileFee{Oracle: oracle, Percentile: 101}, true},
+		{"no base fee", &NodeSuggestedFee{Oracle: &fakeFeeOracle{gasPrice: big.NewInt(30)}}, true},
+		{"percentile without base fee", &PercentileFee{Oracle: &fakeFeeOracle{rewards: []int64{40, 10, 30}}, Percentile: 50}, true},
+		{"no rewards", &PercentileFee{Oracle: &fakeFeeOracle{baseFee: big.NewInt(10)}, Percentile: 50}, true},
+	}
+	for _, c := range errCases {
+		if _, err := c.strategy.Fee(c.dynamic); err == nil {
+			t.Errorf("%s: expected error", c.name)
+		}
+	}
+}
+
+func equalBig(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
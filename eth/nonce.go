@@ -0,0 +1,96 @@
+package eth
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+//DefaultNonceReservationTTL is how long a reserved nonce is held before it is
+//considered abandoned.
+const DefaultNonceReservationTTL = 10 * time.Minute
+
+//NonceProvider hands out the nonces of transactions from an account.
+type NonceProvider interface {
+	//ReserveNonce reserves the next free nonce of addr.
+	ReserveNonce(addr string) (uint64, error)
+	//ReleaseNonce gives back a reserved nonce whose transaction will never be sent.
+	ReleaseNonce(addr string, nonce uint64)
+}
+
+//NonceCounter reports the next nonce of an account as seen by a node,
+//Client implements it.
+type NonceCounter interface {
+	PendingNonceAt(addr string) (uint64, error)
+}
+
+//ReservingNonceProvider starts from the pending nonce reported by the node
+//and keeps a local table of nonces handed out but not yet seen by the node,
+//so concurrent callers for the same account get sequential nonces.
+//Reservations the node has caught up with are dropped, abandoned ones
+//expire after ttl and released ones are handed out again.
+type ReservingNonceProvider struct {
+	mu       sync.Mutex
+	counter  NonceCounter
+	ttl      time.Duration
+	reserved map[string]map[uint64]time.Time
+	now      func() time.Time
+}
+
+//NewReservingNonceProvider creates a provider on top of counter, ttl <= 0
+//uses DefaultNonceReservationTTL.
+func NewReservingNonceProvider(counter NonceCounter, ttl time.Duration) *ReservingNonceProvider {
+	if ttl <= 0 {
+		ttl = DefaultNonceReservationTTL
+	}
+	p := &ReservingNonceProvider{}
+	p.counter = counter
+	p.ttl = ttl
+	p.reserved = make(map[string]map[uint64]time.Time)
+	p.now = time.Now
+	return p
+}
+
+//ReserveNonce reserves the lowest nonce of addr that is neither known to the
+//node nor reserved.
+func (p *ReservingNonceProvider) ReserveNonce(addr string) (uint64, error) {
+	addr = strings.ToLower(addr)
+	pending, err := p.counter.PendingNonceAt(addr)
+	if err != nil {
+		return 0, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	reserved := p.reserved[addr]
+	if reserved == nil {
+		reserved = make(map[uint64]time.Time)
+		p.reserved[addr] = reserved
+	}
+	for nonce, expire := range reserved {
+		if nonce < pending || now.After(expire) {
+			delete(reserved, nonce)
+		}
+	}
+	nonce := pending
+	for {
+		if _, ok := reserved[nonce]; !ok {
+			break
+		}
+		nonce++
+	}
+	reserved[nonce] = now.Add(p.ttl)
+	return nonce, nil
+}
+
+//ReleaseNonce drops the reservation of nonce.
+func (p *ReservingNonceProvider) ReleaseNonce(addr string, nonce uint64) {
+	addr = strings.ToLower(addr)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	reserved := p.reserved[addr]
+	delete(reserved, nonce)
+	if len(reserved) == 0 {
+		delete(p.reserved, addr)
+	}
+}
@@ -0,0 +1,92 @@
+package eth
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeNonceCounter struct {
+	mu      sync.Mutex
+	pending uint64
+}
+
+func (f *fakeNonceCounter) PendingNonceAt(addr string) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pending, nil
+}
+
+func (f *fakeNonceCounter) set(pending uint64) {
+	f.mu.Lock()
+	f.pending = pending
+	f.mu.Unlock()
+}
+
+func TestReserveNonce(t *testing.T) {
+	counter := &fakeNonceCounter{pending: 5}
+	p := NewReservingNonceProvider(counter, time.Minute)
+	now := time.Now()
+	p.now = func() time.Time { return now }
+	addr := "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+	for _, expected := range []uint64{5, 6, 7} {
+		if nonce, _ := p.ReserveNonce(addr); nonce != expected {
+			t.Errorf("expected nonce %d, got %d", expected, nonce)
+		}
+	}
+	//addresses are case insensitive
+	if nonce, _ := p.ReserveNonce("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"); nonce != 8 {
+		t.Errorf("expected nonce 8, got %d", nonce)
+	}
+	//other accounts have their own nonces
+	if nonce, _ := p.ReserveNonce("0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"); nonce != 5 {
+		t.Errorf("expected nonce 5 for other account, got %d", nonce)
+	}
+
+	//a released nonce is handed out again
+	p.ReleaseNonce(addr, 6)
+	if nonce, _ := p.ReserveNonce(addr); nonce != 6 {
+		t.Errorf("expected released nonce 6, got %d", nonce)
+	}
+
+	//the node saw 5 and 6, reservations below pending are dropped
+	counter.set(7)
+	if nonce, _ := p.ReserveNonce(addr); nonce != 9 {
+		t.Errorf("expected nonce 9, got %d", nonce)
+	}
+
+	//abandoned reservations expire
+	now = now.Add(2 * time.Minute)
+	if nonce, _ := p.ReserveNonce(addr); nonce != 7 {
+		t.Errorf("expected expired nonce 7, got %d", nonce)
+	}
+}
+
+func TestReserveNonceConcurrent(t *testing.T) {
+	p := NewReservingNonceProvider(&fakeNonceCounter{pending: 100}, 0)
+	addr := "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[uint64]bool)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nonce, err := p.ReserveNonce(addr)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			seen[nonce] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	for nonce := uint64(100); nonce < 150; nonce++ {
+		if !seen[nonce] {
+			t.Errorf("nonce %d not handed out", nonce)
+		}
+	}
+}
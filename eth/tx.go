@@ -0,0 +1,41 @@
+package eth
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+//UnsignedTX is the transaction handed to the offline signer together with
+//the hash it has to sign. TX is a legacy *types.Transaction signed over its
+//EIP-155 hash when Type is 0, or a *DynamicFeeTX when Type is 2. Token is set
+//when the tx calls transfer of an ERC-20 contract.
+type UnsignedTX struct {
+	Type    hexutil.Uint64 `json:"type"`
+	TX      interface{}    `json:"tx"`
+	ChainID *hexutil.Big   `json:"chainId"`
+	SigHash common.Hash    `json:"sigHash"`
+	Token   *TokenTransfer `json:"token,omitempty"`
+}
+
+//NewUnsignedTX computes the EIP-155 signing hash of tx for chainID
+func NewUnsignedTX(tx *types.Transaction, chainID *big.Int) *UnsignedTX {
+	signer := types.NewEIP155Signer(chainID)
+	unsigned := &UnsignedTX{}
+	unsigned.TX = tx
+	unsigned.ChainID = (*hexutil.Big)(new(big.Int).Set(chainID))
+	unsigned.SigHash = signer.Hash(tx)
+	return unsigned
+}
+
+//NewUnsignedDynamicFeeTX wraps an EIP-1559 transaction and its signing hash
+func NewUnsignedDynamicFeeTX(tx *DynamicFeeTX) *UnsignedTX {
+	unsigned := &UnsignedTX{}
+	unsigned.Type = DynamicFeeTXType
+	unsigned.TX = tx
+	unsigned.ChainID = (*hexutil.Big)(new(big.Int).Set(tx.ChainID))
+	unsigned.SigHash = tx.SigHash()
+	return unsigned
+}
@@ -0,0 +1,143 @@
+package eth
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestUnsignedTXSigHash(t *testing.T) {
+	key, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0x3535353535353535353535353535353535353535")
+	tx := types.NewTransaction(9, to, big.NewInt(1e18), big.NewInt(DefaultGasLimit), big.NewInt(20e9), nil)
+	unsigned := NewUnsignedTX(tx, big.NewInt(3))
+
+	//a signature over SigHash makes a valid transaction for chain 3 only
+	sig, err := crypto.Sign(unsigned.SigHash[:], key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := types.NewEIP155Signer(big.NewInt(3))
+	signed, err := tx.WithSignature(signer, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from, err := types.Sender(signer, signed)
+	if err != nil {
+		t.Fatalf("recover sender error: %v", err)
+	}
+	if from != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Errorf("sender not matched: %s", from.Hex())
+	}
+	if signed.ChainId().Int64() != 3 {
+		t.Errorf("chain id not matched: %v", signed.ChainId())
+	}
+	if _, err := types.Sender(types.NewEIP155Signer(big.NewInt(1)), signed); err == nil {
+		t.Errorf("signature should not be valid on another chain")
+	}
+
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		ChainID string `json:"chainId"`
+		SigHash string `json:"sigHash"`
+		TX      struct {
+			Nonce string `json:"nonce"`
+			To    string `json:"to"`
+		} `json:"tx"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ChainID != "0x3" || decoded.SigHash != unsigned.SigHash.Hex() || decoded.TX.Nonce != "0x9" {
+		t.Errorf("unexpected unsigned tx json: %s", data)
+	}
+}
+
+func TestDynamicFeeTX(t *testing.T) {
+	key, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0x3535353535353535353535353535353535353535")
+	fee := &Fee{GasFeeCap: big.NewInt(30e9), GasTipCap: big.NewInt(2e9)}
+	tx := NewDynamicFeeTX(big.NewInt(5), 9, &to, big.NewInt(1e18), DefaultGasLimit, fee, nil)
+	unsigned := NewUnsignedDynamicFeeTX(tx)
+	if unsigned.SigHash != tx.SigHash() {
+		t.Errorf("sig hash not matched")
+	}
+	if _, err := tx.MarshalBinary(); err == nil {
+		t.Errorf("unsigned tx should not encode")
+	}
+
+	sig, err := crypto.Sign(unsigned.SigHash[:], key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := tx.WithSignature(sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from, err := signed.Sender()
+	if err != nil {
+		t.Fatalf("recover sender error: %v", err)
+	}
+	if from != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Errorf("sender not matched: %s", from.Hex())
+	}
+
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw[0] != DynamicFeeTXType {
+		t.Errorf("raw tx not typed: %x", raw[:1])
+	}
+	decoded, err := DecodeDynamicFeeTX(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.SigHash() != tx.SigHash() || *decoded.To != to || decoded.GasFeeCap.Cmp(fee.GasFeeCap) != 0 {
+		t.Errorf("decoded tx not matched: %+v", decoded)
+	}
+	hash, _ := signed.Hash()
+	if decodedHash, _ := decoded.Hash(); decodedHash != hash {
+		t.Errorf("tx hash not matched")
+	}
+	//a different chain gives a different signing hash
+	other := NewDynamicFeeTX(big.NewInt(1), 9, &to, big.NewInt(1e18), DefaultGasLimit, fee, nil)
+	if other.SigHash() == tx.SigHash() {
+		t.Errorf("sig hash should commit to the chain id")
+	}
+
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var enc struct {
+		Type string `json:"type"`
+		TX   struct {
+			Type                 string        `json:"type"`
+			MaxFeePerGas         string        `json:"maxFeePerGas"`
+			MaxPriorityFeePerGas string        `json:"maxPriorityFeePerGas"`
+			AccessList           []AccessTuple `json:"accessList"`
+			V                    *string       `json:"v"`
+		} `json:"tx"`
+	}
+	if err := json.Unmarshal(data, &enc); err != nil {
+		t.Fatal(err)
+	}
+	if enc.Type != "0x2" || enc.TX.Type != "0x2" || enc.TX.MaxFeePerGas != "0x6fc23ac00" || enc.TX.MaxPriorityFeePerGas != "0x77359400" ||
+		enc.TX.AccessList == nil || enc.TX.V != nil {
+		t.Errorf("unexpected unsigned tx json: %s", data)
+	}
+}
@@ -1,17 +1,22 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"math/big"
 	"os"
 	"os/signal"
 	"strconv"
 	"sync"
 	"syscall"
+	"time"
 
+	"github.com/GameLeLe/trade-addr-tx-service/btc"
+	"github.com/GameLeLe/trade-addr-tx-service/eth"
 	"github.com/GameLeLe/trade-addr-tx-service/hdwallet"
 )
 
@@ -63,8 +68,116 @@ func main() {
 
 	port := daConfig.RPCConfig.Port
 	daRPCServer = newRPCServer(port, &wg)
-	ethPubKey, _ := hdwallet.ReadWalletFromFile(daConfig.ETHMasterPubKeyFile)
-	btcPubKey, _ := hdwallet.ReadWalletFromFile(daConfig.BTCMasterPubKeyFile)
+	daRPCServer.handler.btcAccountPath = daConfig.BTCAccountPath
+	daRPCServer.handler.ethAccountPath = daConfig.ETHAccountPath
+	daRPCServer.handler.btcP2WPKHAccountPath = daConfig.BTCP2WPKHAccountPath
+	daRPCServer.handler.btcP2SHP2WPKHAccountPath = daConfig.BTCP2SHP2WPKHAccountPath
+	daRPCServer.handler.btcP2TRAccountPath = daConfig.BTCP2TRAccountPath
+	daRPCServer.handler.uidScanLimit = daConfig.UIDScanLimit
+	daRPCServer.handler.ethChainID = big.NewInt(daConfig.ETHConfig.ChainID)
+	daRPCServer.handler.ethFeeConfig = daConfig.ETHConfig
+	daRPCServer.handler.ethTokens, err = daConfig.ETHConfig.tokens()
+	if err != nil {
+		log.Fatalln("load eth tokens:", err)
+		return
+	}
+	daRPCServer.handler.btcFeeConfig = daConfig.BTCConfig
+	daRPCServer.handler.btcCoinSelection, err = daConfig.BTCConfig.coinSelection()
+	if err != nil {
+		log.Fatalln("load btc coin selection:", err)
+		return
+	}
+	daRPCServer.handler.btcAddrType, err = daConfig.BTCConfig.addrType()
+	if err != nil {
+		log.Fatalln("load btc address type:", err)
+		return
+	}
+	daRPCServer.handler.btcMasterFingerprint, err = daConfig.BTCConfig.masterFingerprint()
+	if err != nil {
+		log.Fatalln("load btc master fingerprint:", err)
+		return
+	}
+	daRPCServer.handler.btcPSBTVersion = daConfig.BTCConfig.PSBTVersion
+	if daConfig.ETHConfig.RPCURL != "" {
+		ttl := time.Duration(daConfig.ETHConfig.NonceReservationTTL) * time.Second
+		client := eth.NewClient(daConfig.ETHConfig.RPCURL)
+		daRPCServer.handler.ethNonces = eth.NewReservingNonceProvider(client, ttl)
+		daRPCServer.handler.ethFees = client
+		daRPCServer.handler.ethGas = client
+	} else {
+		log.Println("eth rpc url not configured, eth transactions can not be built")
+	}
+	if daConfig.BTCConfig.Backend != "" {
+		service, err := daConfig.BTCConfig.service()
+		if err != nil {
+			log.Fatalln("create btc service:", err)
+			return
+		}
+		ttl := time.Duration(daConfig.BTCConfig.UTXOCacheTTL) * time.Second
+		reservationTTL := time.Duration(daConfig.BTCConfig.UTXOReservationTTL) * time.Second
+		daRPCServer.handler.btcUTXOs = btc.NewUTXOCache(service, ttl, reservationTTL)
+		if fees, ok := service.(btc.FeeEstimator); ok {
+			daRPCServer.handler.btcFees = fees
+		}
+		if txs, ok := service.(btc.RawTXGetter); ok {
+			daRPCServer.handler.btcTXs = txs
+		}
+	} else {
+		log.Println("btc backend not configured, btc transactions can not be built")
+	}
+	if daConfig.DBConfig.Host != "" {
+		store, err := newMysqlAddrStore(daConfig.DBConfig)
+		if err != nil {
+			log.Fatalln("open mysql address store:", err)
+			return
+		}
+		defer store.Close()
+		daRPCServer.handler.store = store
+	} else {
+		log.Println("mysql host not configured, issued addresses are not recorded")
+	}
+	if daConfig.RedisConfig.Host != "" {
+		remote, err := newRedisCache(daConfig.RedisConfig)
+		if err != nil {
+			log.Fatalln("connect redis:", err)
+			return
+		}
+		defer remote.Close()
+		daRPCServer.handler.cache = newAddrCache(daConfig.AddrCacheSize, remote)
+	} else {
+		daRPCServer.handler.cache = newAddrCache(daConfig.AddrCacheSize, nil)
+	}
+	ethPubKey, err := loadMasterPubKey(daConfig.ETHMasterPubKeyFile)
+	if err != nil {
+		log.Fatalln("load eth master public key:", err)
+		return
+	}
+	btcPubKey, err := loadMasterPubKey(daConfig.BTCMasterPubKeyFile)
+	if err != nil {
+		log.Fatalln("load btc master public key:", err)
+		return
+	}
+	if daConfig.BTCP2WPKHMasterPubKeyFile != "" {
+		daRPCServer.handler.btcP2WPKHPubKey, err = loadMasterPubKey(daConfig.BTCP2WPKHMasterPubKeyFile)
+		if err != nil {
+			log.Fatalln("load btc p2wpkh master public key:", err)
+			return
+		}
+	}
+	if daConfig.BTCP2SHP2WPKHMasterPubKeyFile != "" {
+		daRPCServer.handler.btcP2SHP2WPKHPubKey, err = loadMasterPubKey(daConfig.BTCP2SHP2WPKHMasterPubKeyFile)
+		if err != nil {
+			log.Fatalln("load btc p2sh-p2wpkh master public key:", err)
+			return
+		}
+	}
+	if daConfig.BTCP2TRMasterPubKeyFile != "" {
+		daRPCServer.handler.btcP2TRPubKey, err = loadMasterPubKey(daConfig.BTCP2TRMasterPubKeyFile)
+		if err != nil {
+			log.Fatalln("load btc p2tr master public key:", err)
+			return
+		}
+	}
 	go daRPCServer.start(ethPubKey, btcPubKey)
 
 	cc = make(chan struct{})
@@ -81,6 +194,19 @@ func main() {
 	wg.Wait()
 }
 
+//loadMasterPubKey reads an extended key from filename and refuses anything
+//other than a public key, the service never holds secret material
+func loadMasterPubKey(filename string) (*hdwallet.HDWallet, error) {
+	w, err := hdwallet.ReadWalletFromFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(w.Vbytes, hdwallet.Public) && !bytes.Equal(w.Vbytes, hdwallet.TestPublic) {
+		return nil, fmt.Errorf("%s does not hold an extended public key", filename)
+	}
+	return w, nil
+}
+
 func getPID() (int, error) {
 	fileName := ".pid"
 	_, err := os.Stat(fileName)
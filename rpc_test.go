@@ -1,6 +1,11 @@
 package main
 
 import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
 	"strconv"
 	"strings"
 	"sync"
@@ -8,8 +13,18 @@ import (
 	"time"
 
 	"git.apache.org/thrift.git/lib/go/thrift"
+	bip39 "github.com/GameLeLe/trade-addr-tx-service/bip39"
+	"github.com/GameLeLe/trade-addr-tx-service/btc"
+	eth "github.com/GameLeLe/trade-addr-tx-service/eth"
+	"github.com/GameLeLe/trade-addr-tx-service/eth/ethtest"
 	hdwallet "github.com/GameLeLe/trade-addr-tx-service/hdwallet"
 	addrtx "github.com/GameLeLe/trade-addr-tx-service/thrift/addrtx"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -89,12 +104,903 @@ func TestGetAddr(t *testing.T) {
 		if err != nil {
 			t.Errorf("get message from server error return: %v", err)
 		}
-		expected := strings.ToLower(c.expected)
-		ret = strings.ToLower("0x" + ret)
-		assert.Equal(t, expected, ret, "eth address not matched: %s|%s", expected, ret)
+		assert.Equal(t, c.expected, ret, "eth address not matched: %s|%s", c.expected, ret)
+	}
+
+	errCases := []struct {
+		coinType string
+		uid      int64
+		code     addrtx.ErrorCode
+	}{
+		{"DOGE", 1, addrtx.ErrorCode_UNSUPPORTED_COIN},
+		{"BTC", -1, addrtx.ErrorCode_INVALID_UID},
+	}
+	for _, c := range errCases {
+		msg := &addrtx.GetAddrMsg{}
+		msg.UID = c.uid
+		msg.CoinType = c.coinType
+		_, err := client0.GetAddr(msg)
+		e, ok := err.(*addrtx.AddrTXException)
+		if !ok {
+			t.Errorf("expected AddrTXException, got %v", err)
+			continue
+		}
+		assert.Equal(t, c.code, e.Code, "error code not matched: %s", e.Message)
 	}
 
 	transport.Close()
 	server.stop()
 	wg.Wait()
 }
+
+func TestGetTXDerivation(t *testing.T) {
+	daConfig, err := ParseConfig("config.toml")
+	if err != nil {
+		t.Fatalf("parse config file error: %v", err)
+	}
+	ethPubKey, err := loadMasterPubKey(daConfig.ETHMasterPubKeyFile)
+	if err != nil {
+		t.Fatalf("load eth master public key error: %v", err)
+	}
+	handler := &rpcThrift{ethPubKey: ethPubKey}
+	handler.ethChainID = big.NewInt(daConfig.ETHConfig.ChainID)
+	handler.ethNonces = eth.NewReservingNonceProvider(fakeNonceCounter(7), 0)
+	handler.ethFeeConfig = daConfig.ETHConfig
+	handler.ethFeeConfig.FeeStrategy = "fixed"
+
+	for i, toUID := range []int64{0, 2, 41} {
+		addr, err := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "ETH", UID: toUID})
+		if err != nil {
+			t.Fatalf("get addr error: %v", err)
+		}
+		msg := &addrtx.GetTXMsg{CoinType: "ETH", FromUID: 1, FromAmount: 1000, ToUID: toUID, ToAmount: 1000}
+		ret, err := handler.GetTX(msg)
+		if err != nil {
+			t.Fatalf("get tx error: %v", err)
+		}
+		txJSON, err := hex.DecodeString(ret)
+		if err != nil {
+			t.Fatalf("decode tx error: %v", err)
+		}
+		var unsigned struct {
+			Type string `json:"type"`
+			TX   struct {
+				To                   string `json:"to"`
+				Nonce                string `json:"nonce"`
+				MaxFeePerGas         string `json:"maxFeePerGas"`
+				MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas"`
+			} `json:"tx"`
+			ChainID string `json:"chainId"`
+			SigHash string `json:"sigHash"`
+		}
+		if err := json.Unmarshal(txJSON, &unsigned); err != nil {
+			t.Fatalf("unmarshal tx error: %v", err)
+		}
+		assert.Equal(t, strings.ToLower(addr), strings.ToLower(unsigned.TX.To), "tx recipient not derived from master public key")
+		assert.Equal(t, "0x1", unsigned.ChainID)
+		//dynamic fee tx at the configured fixed prices
+		assert.Equal(t, "0x2", unsigned.Type)
+		assert.Equal(t, hexutil.EncodeBig(big.NewInt(daConfig.ETHConfig.MaxFeePerGas)), unsigned.TX.MaxFeePerGas)
+		assert.Equal(t, hexutil.EncodeBig(big.NewInt(daConfig.ETHConfig.MaxPriorityFeePerGas)), unsigned.TX.MaxPriorityFeePerGas)
+		assert.Equal(t, 66, len(unsigned.SigHash), "tx should carry the signing hash")
+		//concurrent txs of an account get sequential nonces
+		assert.Equal(t, "0x"+strconv.Itoa(7+i), unsigned.TX.Nonce)
+	}
+
+	//the nonce of a failed tx is released
+	_, err = handler.GetTX(&addrtx.GetTXMsg{CoinType: "ETH", FromUID: 1, FromAmount: -1, ToUID: 2, ToAmount: -1})
+	assert.NotNil(t, err)
+	fromAddr, _ := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "ETH", UID: 1})
+	nonce, _ := handler.ethNonces.ReserveNonce(fromAddr)
+	assert.Equal(t, uint64(10), nonce)
+
+	//bad fees are rejected before a nonce is reserved
+	strategy := addrtx.FeeStrategy_FIXED
+	maxFee, tip := int64(1e9), int64(2e9)
+	_, err = handler.GetTX(&addrtx.GetTXMsg{CoinType: "ETH", FromUID: 1, FromAmount: 1000, ToUID: 2, ToAmount: 1000,
+		FeeStrategy: &strategy, MaxFeePerGas: &maxFee, MaxPriorityFeePerGas: &tip})
+	if e, ok := err.(*addrtx.AddrTXException); !ok || e.Code != addrtx.ErrorCode_INVALID_ARGUMENT {
+		t.Errorf("expected INVALID_ARGUMENT, got %v", err)
+	}
+	strategy = addrtx.FeeStrategy_NODE_SUGGESTED
+	_, err = handler.GetTX(&addrtx.GetTXMsg{CoinType: "ETH", FromUID: 1, FromAmount: 1000, ToUID: 2, ToAmount: 1000, FeeStrategy: &strategy})
+	if e, ok := err.(*addrtx.AddrTXException); !ok || e.Code != addrtx.ErrorCode_INTERNAL_ERROR {
+		t.Errorf("expected INTERNAL_ERROR without a node, got %v", err)
+	}
+	nonce, _ = handler.ethNonces.ReserveNonce(fromAddr)
+	assert.Equal(t, uint64(11), nonce)
+
+	//no master key configured for the coin
+	_, err = handler.GetTX(&addrtx.GetTXMsg{CoinType: "BTC", FromUID: 1, FromAmount: 1000, ToUID: 2, ToAmount: 1000})
+	assert.NotNil(t, err)
+}
+
+func TestGetTXETHFakeNode(t *testing.T) {
+	daConfig, err := ParseConfig("config.toml")
+	if err != nil {
+		t.Fatalf("parse config file error: %v", err)
+	}
+	ethPubKey, _ := hdwallet.ReadWalletFromFile(daConfig.ETHMasterPubKeyFile)
+	node := ethtest.NewFakeNode(1)
+	defer node.Close()
+	node.SetBaseFee(big.NewInt(10e9))
+	node.SetPriorityFee(big.NewInt(1e9))
+	node.SetGasPrice(big.NewInt(12e9))
+	node.SetRewards([]*big.Int{big.NewInt(1e9), big.NewInt(5e9), big.NewInt(3e9)})
+	client := eth.NewClient(node.URL())
+	handler := &rpcThrift{ethPubKey: ethPubKey}
+	handler.ethChainID = big.NewInt(1)
+	handler.ethNonces = eth.NewReservingNonceProvider(client, 0)
+	handler.ethFees = client
+	handler.ethFeeConfig = daConfig.ETHConfig
+
+	fromAddr, _ := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "ETH", UID: 1})
+	toAddr, _ := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "ETH", UID: 2})
+	node.SetNonce(fromAddr, 4)
+
+	//private key of uid 1, m/44'/60'/0'/1 of the seed the pub key file was made from
+	prv := hdwallet.MasterKey(getSeed())
+	for _, i := range []uint32{0x8000002c, 0x8000003c, 0x80000000, 1} {
+		prv, _ = prv.Child(i)
+	}
+	key, err := crypto.ToECDSA(prv.Key[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodeSuggested := addrtx.FeeStrategy_NODE_SUGGESTED
+	percentile := addrtx.FeeStrategy_PERCENTILE
+	legacy := true
+	var p90 int32 = 90
+	cases := []struct {
+		msg       *addrtx.GetTXMsg
+		txType    byte
+		gasPrice  int64
+		gasFeeCap int64
+		gasTipCap int64
+	}{
+		//configured default, node suggested tip on twice the base fee
+		{&addrtx.GetTXMsg{}, 2, 0, 21e9, 1e9},
+		{&addrtx.GetTXMsg{FeeStrategy: &nodeSuggested, LegacyTX: &legacy}, 0, 12e9, 0, 0},
+		//median of the per block rewards
+		{&addrtx.GetTXMsg{FeeStrategy: &percentile, FeePercentile: &p90}, 2, 0, 23e9, 3e9},
+		{&addrtx.GetTXMsg{FeeStrategy: &percentile, LegacyTX: &legacy}, 0, 13e9, 0, 0},
+	}
+	for i, c := range cases {
+		msg := c.msg
+		msg.CoinType, msg.FromUID, msg.FromAmount, msg.ToUID, msg.ToAmount = "ETH", 1, 1000, 2, 1000
+		ret, err := handler.GetTX(msg)
+		if err != nil {
+			t.Fatalf("get tx error: %v", err)
+		}
+		raw := signETHTX(t, ret, key)
+		hash, err := client.SendRawTransaction(raw)
+		if err != nil {
+			t.Fatalf("broadcast tx error: %v", err)
+		}
+		if _, err := client.TransactionReceipt(hash); err != nil {
+			t.Errorf("tx not mined: %v", err)
+		}
+		sent := node.Sent()
+		tx := sent[len(sent)-1]
+		assert.Equal(t, c.txType, tx.Type)
+		assert.Equal(t, uint64(4+i), tx.Nonce)
+		assert.Equal(t, toAddr, eth.ChecksumAddress(tx.To[:]))
+		assert.Equal(t, int64(1000), tx.Value.Int64())
+		if c.txType == eth.DynamicFeeTXType {
+			assert.Equal(t, c.gasFeeCap, tx.GasFeeCap.Int64())
+			assert.Equal(t, c.gasTipCap, tx.GasTipCap.Int64())
+		} else {
+			assert.Equal(t, c.gasPrice, tx.GasPrice.Int64())
+		}
+	}
+
+	//a chain without EIP-1559 can not price dynamic fee txs
+	node.SetBaseFee(nil)
+	_, err = handler.GetTX(&addrtx.GetTXMsg{CoinType: "ETH", FromUID: 1, FromAmount: 1000, ToUID: 2, ToAmount: 1000})
+	if e, ok := err.(*addrtx.AddrTXException); !ok || e.Code != addrtx.ErrorCode_UPSTREAM_UNAVAILABLE {
+		t.Errorf("expected UPSTREAM_UNAVAILABLE, got %v", err)
+	}
+}
+
+func TestGetTXERC20(t *testing.T) {
+	daConfig, err := ParseConfig("config.toml")
+	if err != nil {
+		t.Fatalf("parse config file error: %v", err)
+	}
+	ethPubKey, _ := hdwallet.ReadWalletFromFile(daConfig.ETHMasterPubKeyFile)
+	node := ethtest.NewFakeNode(1)
+	defer node.Close()
+	node.SetGasEstimate(50000)
+	client := eth.NewClient(node.URL())
+	handler := &rpcThrift{ethPubKey: ethPubKey}
+	handler.ethChainID = big.NewInt(1)
+	handler.ethNonces = eth.NewReservingNonceProvider(client, 0)
+	handler.ethFees = client
+	handler.ethGas = client
+	handler.ethFeeConfig = daConfig.ETHConfig
+	handler.ethTokens, err = daConfig.ETHConfig.tokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	usdt := handler.ethTokens["USDT"]
+	toAddr, _ := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "ETH", UID: 2})
+
+	prv := hdwallet.MasterKey(getSeed())
+	for _, i := range []uint32{0x8000002c, 0x8000003c, 0x80000000, 1} {
+		prv, _ = prv.Child(i)
+	}
+	key, err := crypto.ToECDSA(prv.Key[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := "usdt"
+	msg := &addrtx.GetTXMsg{CoinType: "ETH", FromUID: 1, FromAmount: 1500000, ToUID: 2, ToAmount: 1500000, Token: &token}
+	ret, err := handler.GetTX(msg)
+	if err != nil {
+		t.Fatalf("get tx error: %v", err)
+	}
+	payload, _ := hex.DecodeString(ret)
+	var unsigned struct {
+		Token struct {
+			Symbol   string `json:"symbol"`
+			Decimals int    `json:"decimals"`
+			To       string `json:"to"`
+			Amount   string `json:"amount"`
+		} `json:"token"`
+	}
+	if err := json.Unmarshal(payload, &unsigned); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "USDT", unsigned.Token.Symbol)
+	assert.Equal(t, 6, unsigned.Token.Decimals)
+	assert.Equal(t, strings.ToLower(toAddr), strings.ToLower(unsigned.Token.To))
+	assert.Equal(t, "0x16e360", unsigned.Token.Amount)
+
+	if _, err := client.SendRawTransaction(signETHTX(t, ret, key)); err != nil {
+		t.Fatalf("broadcast tx error: %v", err)
+	}
+	sent := node.Sent()
+	tx := sent[len(sent)-1]
+	//the tx calls the contract with no ether and an estimated gas limit
+	assert.Equal(t, usdt.Contract, *tx.To)
+	assert.Equal(t, int64(0), tx.Value.Int64())
+	assert.Equal(t, uint64(60000), tx.Gas)
+	expectedData, _ := usdt.TransferData(common.HexToAddress(toAddr), big.NewInt(1500000))
+	assert.Equal(t, expectedData, tx.Data)
+
+	//unknown tokens
+	token = "DOGE"
+	_, err = handler.GetTX(msg)
+	if e, ok := err.(*addrtx.AddrTXException); !ok || e.Code != addrtx.ErrorCode_UNSUPPORTED_COIN {
+		t.Errorf("expected UNSUPPORTED_COIN, got %v", err)
+	}
+	//token transfers need a node to estimate gas
+	token = "USDT"
+	handler.ethGas = nil
+	_, err = handler.GetTX(msg)
+	if e, ok := err.(*addrtx.AddrTXException); !ok || e.Code != addrtx.ErrorCode_INTERNAL_ERROR {
+		t.Errorf("expected INTERNAL_ERROR, got %v", err)
+	}
+}
+
+type fakeFeeEstimator struct {
+	rate uint64
+	err  error
+}
+
+func (f *fakeFeeEstimator) EstimateFeeRate(blocks int) (uint64, error) {
+	return f.rate * uint64(blocks), f.err
+}
+
+func TestBTCFeeRate(t *testing.T) {
+	handler := newRPCServer(0, &sync.WaitGroup{}).handler
+	msg := &addrtx.GetTXMsg{CoinType: "BTC", FromUID: 1, FromAmount: 1000, ToUID: 2, ToAmount: 1000}
+	//without an estimator the default rate is used
+	rate, err := handler.btcFeeRate(msg)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(btc.DefaultFeeRate), rate)
+
+	handler.btcFees = &fakeFeeEstimator{rate: 2}
+	rate, err = handler.btcFeeRate(msg)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(12), rate, "estimate should be for the default target of 6 blocks")
+	handler.btcFeeConfig.FeeRate = 5
+	rate, _ = handler.btcFeeRate(msg)
+	assert.Equal(t, uint64(5), rate, "configured rate should be preferred over estimates")
+	requested := int64(30)
+	msg.FeeRate = &requested
+	rate, _ = handler.btcFeeRate(msg)
+	assert.Equal(t, uint64(30), rate, "requested rate should be preferred")
+
+	for _, invalid := range []int64{0, btc.MaxFeeRate + 1, 100000} {
+		requested = invalid
+		_, err = handler.btcFeeRate(msg)
+		if e, ok := err.(*addrtx.AddrTXException); !ok || e.Code != addrtx.ErrorCode_INVALID_ARGUMENT {
+			t.Errorf("fee rate %d: expected INVALID_ARGUMENT, got %v", invalid, err)
+		}
+	}
+	requested = btc.MaxFeeRate
+	rate, err = handler.btcFeeRate(msg)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(btc.MaxFeeRate), rate, "the maximum rate should be accepted")
+	msg.FeeRate = nil
+	handler.btcFeeConfig.FeeRate = 100000
+	_, err = handler.btcFeeRate(msg)
+	if e, ok := err.(*addrtx.AddrTXException); !ok || e.Code != addrtx.ErrorCode_INVALID_ARGUMENT {
+		t.Errorf("configured fee rate: expected INVALID_ARGUMENT, got %v", err)
+	}
+	handler.btcFeeConfig.FeeRate = 0
+	handler.btcFees = &fakeFeeEstimator{err: errors.New("no estimate")}
+	_, err = handler.btcFeeRate(msg)
+	if e, ok := err.(*addrtx.AddrTXException); !ok || e.Code != addrtx.ErrorCode_UPSTREAM_UNAVAILABLE {
+		t.Errorf("expected UPSTREAM_UNAVAILABLE, got %v", err)
+	}
+}
+
+//signETHTX signs the unsigned tx payload of GetTX the way an offline signer
+//does and returns the raw tx
+func signETHTX(t *testing.T, payload string, key *ecdsa.PrivateKey) []byte {
+	data, err := hex.DecodeString(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var unsigned struct {
+		Type hexutil.Uint64 `json:"type"`
+		TX   struct {
+			Nonce                hexutil.Uint64 `json:"nonce"`
+			GasPrice             *hexutil.Big   `json:"gasPrice"`
+			MaxFeePerGas         *hexutil.Big   `json:"maxFeePerGas"`
+			MaxPriorityFeePerGas *hexutil.Big   `json:"maxPriorityFeePerGas"`
+			Gas                  *hexutil.Big   `json:"gas"`
+			To                   common.Address `json:"to"`
+			Value                *hexutil.Big   `json:"value"`
+			Input                hexutil.Bytes  `json:"input"`
+		} `json:"tx"`
+		ChainID *hexutil.Big `json:"chainId"`
+		SigHash common.Hash  `json:"sigHash"`
+	}
+	if err := json.Unmarshal(data, &unsigned); err != nil {
+		t.Fatal(err)
+	}
+	d := unsigned.TX
+	sig, err := crypto.Sign(unsigned.SigHash[:], key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unsigned.Type == eth.DynamicFeeTXType {
+		fee := &eth.Fee{GasFeeCap: d.MaxFeePerGas.ToInt(), GasTipCap: d.MaxPriorityFeePerGas.ToInt()}
+		tx := eth.NewDynamicFeeTX(unsigned.ChainID.ToInt(), uint64(d.Nonce), &d.To, d.Value.ToInt(), d.Gas.ToInt().Uint64(), fee, d.Input)
+		if tx.SigHash() != unsigned.SigHash {
+			t.Fatalf("sig hash does not match the tx")
+		}
+		signed, err := tx.WithSignature(sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := signed.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+	tx := types.NewTransaction(uint64(d.Nonce), d.To, d.Value.ToInt(), d.Gas.ToInt(), d.GasPrice.ToInt(), d.Input)
+	signer := types.NewEIP155Signer(unsigned.ChainID.ToInt())
+	if signer.Hash(tx) != unsigned.SigHash {
+		t.Fatalf("sig hash does not match the tx")
+	}
+	signed, err := tx.WithSignature(signer, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+//fakeNonceCounter is a node reporting a fixed pending nonce
+type fakeNonceCounter uint64
+
+func (f fakeNonceCounter) PendingNonceAt(addr string) (uint64, error) {
+	return uint64(f), nil
+}
+
+func TestGetAddrBatch(t *testing.T) {
+	daConfig, err := ParseConfig("config.toml")
+	if err != nil {
+		t.Fatalf("parse config file error: %v", err)
+	}
+	ethPubKey, _ := hdwallet.ReadWalletFromFile(daConfig.ETHMasterPubKeyFile)
+	btcPubKey, _ := hdwallet.ReadWalletFromFile(daConfig.BTCMasterPubKeyFile)
+	handler := &rpcThrift{ethPubKey: ethPubKey, btcPubKey: btcPubKey}
+
+	for _, coinType := range []string{"BTC", "ETH"} {
+		msg := &addrtx.GetAddrBatchMsg{CoinType: coinType, UIDList: []int64{0, 6, 58, 6}}
+		addrs, err := handler.GetAddrBatch(msg)
+		if err != nil {
+			t.Fatalf("get addr batch error: %v", err)
+		}
+		assert.Equal(t, 3, len(addrs))
+		for uid, addr := range addrs {
+			expected, _ := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: coinType, UID: uid})
+			assert.Equal(t, expected, addr, "%s address of uid %d not matched", coinType, uid)
+		}
+
+		startUID := int64(40)
+		count := int32(6)
+		msg = &addrtx.GetAddrBatchMsg{CoinType: coinType, StartUID: &startUID, Count: &count}
+		addrs, err = handler.GetAddrBatch(msg)
+		if err != nil {
+			t.Fatalf("get addr batch error: %v", err)
+		}
+		assert.Equal(t, 6, len(addrs))
+		for uid := startUID; uid < startUID+int64(count); uid++ {
+			expected, _ := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: coinType, UID: uid})
+			assert.Equal(t, expected, addrs[uid], "%s address of uid %d not matched", coinType, uid)
+		}
+	}
+
+	tooMany := int32(maxAddrBatchSize + 1)
+	startUID := int64(0)
+	errCases := []*addrtx.GetAddrBatchMsg{
+		{CoinType: "BTC"},
+		{CoinType: "BTC", StartUID: &startUID},
+		{CoinType: "BTC", StartUID: &startUID, Count: &tooMany},
+		{CoinType: "BTC", UIDList: []int64{1}, StartUID: &startUID},
+	}
+	for _, msg := range errCases {
+		_, err := handler.GetAddrBatch(msg)
+		e, ok := err.(*addrtx.AddrTXException)
+		if !ok || e.Code != addrtx.ErrorCode_INVALID_ARGUMENT {
+			t.Errorf("expected INVALID_ARGUMENT for %v, got %v", msg, err)
+		}
+	}
+	_, err = handler.GetAddrBatch(&addrtx.GetAddrBatchMsg{CoinType: "DOGE", UIDList: []int64{1}})
+	if e, ok := err.(*addrtx.AddrTXException); !ok || e.Code != addrtx.ErrorCode_UNSUPPORTED_COIN {
+		t.Errorf("expected UNSUPPORTED_COIN, got %v", err)
+	}
+}
+
+func TestGetAddrRecorded(t *testing.T) {
+	daConfig, err := ParseConfig("config.toml")
+	if err != nil {
+		t.Fatalf("parse config file error: %v", err)
+	}
+	ethPubKey, _ := hdwallet.ReadWalletFromFile(daConfig.ETHMasterPubKeyFile)
+	btcPubKey, _ := hdwallet.ReadWalletFromFile(daConfig.BTCMasterPubKeyFile)
+	store := newMemAddrStore()
+	handler := &rpcThrift{ethPubKey: ethPubKey, btcPubKey: btcPubKey, store: store}
+	handler.btcAccountPath = daConfig.BTCAccountPath
+	handler.ethAccountPath = daConfig.ETHAccountPath
+
+	addr, err := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: 9})
+	if err != nil {
+		t.Fatalf("get addr error: %v", err)
+	}
+	rec, err := store.GetAddrRecord("BTC", addr)
+	if err != nil {
+		t.Fatalf("btc address not recorded: %v", err)
+	}
+	assert.Equal(t, int64(9), rec.UID)
+	assert.Equal(t, "m/44'/0'/0'/0/9", rec.Path)
+
+	addrs, err := handler.GetAddrBatch(&addrtx.GetAddrBatchMsg{CoinType: "ETH", UIDList: []int64{12, 33}})
+	if err != nil {
+		t.Fatalf("get addr batch error: %v", err)
+	}
+	for uid, addr := range addrs {
+		rec, err := store.GetAddrRecord("ETH", strings.ToUpper(addr))
+		if err != nil {
+			t.Fatalf("eth address not recorded: %v", err)
+		}
+		assert.Equal(t, uid, rec.UID)
+		assert.Equal(t, "m/44'/60'/0'/"+strconv.FormatInt(uid, 10), rec.Path)
+	}
+}
+
+func TestGetAddrRecordedOnce(t *testing.T) {
+	daConfig, err := ParseConfig("config.toml")
+	if err != nil {
+		t.Fatalf("parse config file error: %v", err)
+	}
+	btcPubKey, _ := hdwallet.ReadWalletFromFile(daConfig.BTCMasterPubKeyFile)
+	store := newMemAddrStore()
+	handler := &rpcThrift{btcPubKey: btcPubKey, store: store, cache: newAddrCache(100, nil)}
+
+	//addresses failing to record are not cached
+	store.err = errors.New("mysql down")
+	_, err = handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: 3})
+	e, ok := err.(*addrtx.AddrTXException)
+	if !ok || e.Code != addrtx.ErrorCode_UPSTREAM_UNAVAILABLE {
+		t.Errorf("expected UPSTREAM_UNAVAILABLE, got %v", err)
+	}
+	store.err = nil
+	addr, err := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: 3})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, store.saved)
+	//cache hits are recorded already
+	again, err := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: 3})
+	assert.Nil(t, err)
+	assert.Equal(t, addr, again)
+	assert.Equal(t, 1, store.saved)
+
+	addrs, err := handler.GetAddrBatch(&addrtx.GetAddrBatchMsg{CoinType: "BTC", UIDList: []int64{3, 4, 5}})
+	assert.Nil(t, err)
+	assert.Equal(t, addr, addrs[3])
+	assert.Equal(t, 3, store.saved, "only the uncached addresses should be recorded")
+	_, err = handler.GetAddrBatch(&addrtx.GetAddrBatchMsg{CoinType: "BTC", UIDList: []int64{3, 4, 5}})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, store.saved)
+}
+
+func TestGetUIDByAddr(t *testing.T) {
+	daConfig, err := ParseConfig("config.toml")
+	if err != nil {
+		t.Fatalf("parse config file error: %v", err)
+	}
+	ethPubKey, _ := hdwallet.ReadWalletFromFile(daConfig.ETHMasterPubKeyFile)
+	btcPubKey, _ := hdwallet.ReadWalletFromFile(daConfig.BTCMasterPubKeyFile)
+	store := newMemAddrStore()
+	remote := newMemRemoteCache()
+	handler := &rpcThrift{ethPubKey: ethPubKey, btcPubKey: btcPubKey, store: store, cache: newAddrCache(100, remote)}
+	handler.uidScanLimit = 64
+
+	//recorded by GetAddr, beyond the scan limit
+	addr, err := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: 500})
+	if err != nil {
+		t.Fatalf("get addr error: %v", err)
+	}
+	uid, err := handler.GetUIDByAddr(&addrtx.GetUIDByAddrMsg{CoinType: "BTC", Addr: addr})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(500), uid)
+
+	//never recorded, found by the derivation scan and recorded afterwards
+	uid, err = handler.GetUIDByAddr(&addrtx.GetUIDByAddrMsg{CoinType: "ETH", Addr: "0xaEA3d615e5822F38931083AF70d5Cfb0A7eD0d73"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(9), uid)
+	rec, err := store.GetAddrRecord("ETH", "0xaea3d615e5822f38931083af70d5cfb0a7ed0d73")
+	if err != nil {
+		t.Fatalf("scanned address not recorded: %v", err)
+	}
+	assert.Equal(t, int64(9), rec.UID)
+	//of the scanned uids only the match is cached
+	assert.Equal(t, 2, len(remote.values))
+	assert.Equal(t, "0xaea3d615e5822f38931083af70d5cfb0a7ed0d73", strings.ToLower(remote.values[addrCacheKey("ETH", ethPubKey, 9)]))
+	assert.Equal(t, 2, handler.cache.ll.Len())
+
+	errCases := []struct {
+		coinType string
+		addr     string
+		code     addrtx.ErrorCode
+	}{
+		//neither recorded nor derived within the scan limit
+		{"BTC", "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA", addrtx.ErrorCode_ADDRESS_NOT_FOUND},
+		{"BTC", "", addrtx.ErrorCode_INVALID_ARGUMENT},
+		//checksum of 0xaEA3d615e5822F38931083AF70d5Cfb0A7eD0d73 broken
+		{"ETH", "0xAEA3d615e5822F38931083AF70d5Cfb0A7eD0d73", addrtx.ErrorCode_INVALID_ARGUMENT},
+		{"DOGE", "DH5yaieqoZN36fDVciNyRueRGvGLR3mr7L", addrtx.ErrorCode_UNSUPPORTED_COIN},
+	}
+	for _, c := range errCases {
+		_, err := handler.GetUIDByAddr(&addrtx.GetUIDByAddrMsg{CoinType: c.coinType, Addr: c.addr})
+		e, ok := err.(*addrtx.AddrTXException)
+		if !ok {
+			t.Errorf("expected AddrTXException, got %v", err)
+			continue
+		}
+		assert.Equal(t, c.code, e.Code, "error code not matched: %s", e.Message)
+	}
+}
+
+func TestUIDDerivation(t *testing.T) {
+	daConfig, err := ParseConfig("config.toml")
+	if err != nil {
+		t.Fatalf("parse config file error: %v", err)
+	}
+	btcPubKey, _ := hdwallet.ReadWalletFromFile(daConfig.BTCMasterPubKeyFile)
+	handler := &rpcThrift{btcPubKey: btcPubKey}
+	handler.btcAccountPath = daConfig.BTCAccountPath
+
+	pathCases := []struct {
+		uid  int64
+		path string
+	}{
+		{0, "m/44'/0'/0'/0/0"},
+		{1<<31 - 1, "m/44'/0'/0'/0/2147483647"},
+		{1 << 31, "m/44'/0'/0'/0/1/0"},
+		{1<<31 + 5, "m/44'/0'/0'/0/1/5"},
+		{maxUID, "m/44'/0'/0'/0/2147483647/2147483647"},
+	}
+	for _, c := range pathCases {
+		assert.Equal(t, c.path, handler.addrPath("BTC", addrtx.BTCAddrType_P2PKH, c.uid), "path of uid %d not matched", c.uid)
+	}
+
+	//uids from 2^31 derive two levels below the account key
+	child1, _ := btcPubKey.Child(1)
+	child1x5, _ := child1.Child(5)
+	addr, err := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: 1<<31 + 5})
+	assert.Nil(t, err)
+	assert.Equal(t, genBTCAddr(child1x5.Pub().Key, false), addr)
+	addr5, _ := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: 5})
+	assert.NotEqual(t, addr5, addr)
+	_, err = handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: maxUID})
+	assert.Nil(t, err)
+
+	for _, uid := range []int64{-1, -1 << 63, maxUID + 1, 1<<63 - 1} {
+		_, err := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: uid})
+		e, ok := err.(*addrtx.AddrTXException)
+		if !ok || e.Code != addrtx.ErrorCode_INVALID_UID {
+			t.Errorf("expected INVALID_UID for uid %d, got %v", uid, err)
+		}
+	}
+}
+
+//testBTCAccount returns a handler with the legacy account key of config.toml,
+//a memory store and the account m/purpose'/0'/0'/0 of the test mnemonic of
+//BIP-49, BIP-84 and BIP-86 as the key of addrType, with the private account
+func testBTCAccount(t *testing.T, purpose uint32, addrType addrtx.BTCAddrType) (*rpcThrift, *memAddrStore, *hdwallet.HDWallet) {
+	daConfig, err := ParseConfig("config.toml")
+	if err != nil {
+		t.Fatalf("parse config file error: %v", err)
+	}
+	btcPubKey, _ := hdwallet.ReadWalletFromFile(daConfig.BTCMasterPubKeyFile)
+	seed := bip39.NewSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	account := hdwallet.MasterKey(seed)
+	for _, i := range []uint32{purpose + 1<<31, 1 << 31, 1 << 31, 0} {
+		account, _ = account.Child(i)
+	}
+	store := newMemAddrStore()
+	handler := newRPCServer(0, &sync.WaitGroup{}).handler
+	handler.btcPubKey = btcPubKey
+	switch addrType {
+	case addrtx.BTCAddrType_P2WPKH:
+		handler.btcP2WPKHPubKey = account.Pub()
+	case addrtx.BTCAddrType_P2SH_P2WPKH:
+		handler.btcP2SHP2WPKHPubKey = account.Pub()
+	case addrtx.BTCAddrType_P2TR:
+		handler.btcP2TRPubKey = account.Pub()
+	default:
+		t.Fatalf("no account key of address type %v", addrType)
+	}
+	handler.store = store
+	handler.uidScanLimit = 64
+	return handler, store, account
+}
+
+//fundBTCAddr makes a fake backend holding utxos of amounts paying addr the
+//utxos and previous txs of handler
+func fundBTCAddr(handler *rpcThrift, addr string, amounts ...uint64) *fakeBTCService {
+	script, _ := btc.AddressScript(addr)
+	fake := newFakeBTCService(script, amounts...)
+	handler.btcUTXOs = btc.NewUTXOCache(fake, 0, 0)
+	handler.btcTXs = fake
+	return fake
+}
+
+func TestGetAddrP2WPKH(t *testing.T) {
+	//the BIP-84 test account m/84'/0'/0'/0
+	handler, store, _ := testBTCAccount(t, 84, addrtx.BTCAddrType_P2WPKH)
+	btcPubKey := handler.btcPubKey
+	handler.cache = newAddrCache(100, nil)
+
+	p2wpkh := addrtx.BTCAddrType_P2WPKH
+	addr, err := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: 1, AddrType: &p2wpkh})
+	assert.Nil(t, err)
+	assert.Equal(t, "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g", addr)
+	rec, err := store.GetAddrRecord("BTC", addr)
+	if err != nil {
+		t.Fatalf("p2wpkh address not recorded: %v", err)
+	}
+	assert.Equal(t, "m/84'/0'/0'/0/1", rec.Path)
+	//requests without a type get the configured one
+	legacy, err := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: 1})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(legacy, "1"), "legacy address expected: %s", legacy)
+	handler.btcAddrType = addrtx.BTCAddrType_P2WPKH
+	addr, _ = handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: 1})
+	assert.Equal(t, "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g", addr)
+
+	addrs, err := handler.GetAddrBatch(&addrtx.GetAddrBatchMsg{CoinType: "BTC", UIDList: []int64{0, 1}, AddrType: &p2wpkh})
+	assert.Nil(t, err)
+	assert.Equal(t, "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu", addrs[0])
+
+	//the account is picked from the address
+	uid, err := handler.GetUIDByAddr(&addrtx.GetUIDByAddrMsg{CoinType: "BTC", Addr: "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), uid)
+	uid, err = handler.GetUIDByAddr(&addrtx.GetUIDByAddrMsg{CoinType: "BTC", Addr: legacy})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), uid)
+	handler.store = nil
+	uid, err = handler.GetUIDByAddr(&addrtx.GetUIDByAddrMsg{CoinType: "BTC", Addr: "BC1QCR8TE4KR609GCAWUTMRZA0J4XV80JY8Z306FYU"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), uid)
+
+	//a shared account key still caches the types apart
+	handler.btcP2WPKHPubKey = btcPubKey
+	addr, _ = handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: 1})
+	assert.NotEqual(t, legacy, addr)
+	child1, _ := btcPubKey.Child(1)
+	assert.Equal(t, genP2WPKHAddr(child1.Key, false), addr)
+
+	//change and inputs stay on the sender's type
+	fake := fundBTCAddr(handler, addr, 500000)
+	fromScript, _ := btc.AddressScript(addr)
+	p2pkh := addrtx.BTCAddrType_P2PKH
+	psbt, err := handler.GetTX(&addrtx.GetTXMsg{CoinType: "BTC", FromUID: 1, FromAmount: 400000, ToUID: 2, ToAmount: 400000, ToAddrType: &p2pkh})
+	assert.Nil(t, err)
+	p, txHex := parseBTCPSBT(t, psbt)
+	toAddr, _ := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: 2, AddrType: &p2pkh})
+	toScript, _ := btc.AddressScript(toAddr)
+	assert.Contains(t, txHex, "801a060000000000"+"19"+hex.EncodeToString(toScript))
+	assert.Contains(t, txHex, "16"+hex.EncodeToString(fromScript))
+	//without a master fingerprint the account key is the origin of the keys
+	assert.Equal(t, "p2wpkh", p.Inputs[0].ScriptType)
+	assert.NotNil(t, p.Inputs[0].NonWitnessUTXO)
+	assert.Equal(t, []*btc.KeyDerivation{{PubKey: child1.Key, Fingerprint: btc.Hash160(btcPubKey.Key)[:4], Path: []uint32{1}}}, p.Inputs[0].Derivations)
+	handler.btcMasterFingerprint = []byte{0x73, 0xc5, 0xda, 0x0a}
+	handler.btcUTXOs = btc.NewUTXOCache(fake, 0, 0)
+	psbt, err = handler.GetTX(&addrtx.GetTXMsg{CoinType: "BTC", FromUID: 1, FromAmount: 1000, ToUID: 2, ToAmount: 1000, ToAddrType: &p2pkh})
+	assert.Nil(t, err)
+	p, _ = parseBTCPSBT(t, psbt)
+	assert.Equal(t, []*btc.KeyDerivation{{PubKey: child1.Key, Fingerprint: handler.btcMasterFingerprint, Path: []uint32{84 + 1<<31, 1 << 31, 1 << 31, 0, 1}}}, p.Inputs[0].Derivations)
+	assert.Equal(t, p.Inputs[0].Derivations, p.Outputs[1].Derivations, "change should carry the sender's origin")
+	handler.btcMasterFingerprint = nil
+
+	errCases := []struct {
+		handler  *rpcThrift
+		addrType addrtx.BTCAddrType
+		code     addrtx.ErrorCode
+	}{
+		{handler, addrtx.BTCAddrType(9), addrtx.ErrorCode_INVALID_ARGUMENT},
+		//no account key for the type
+		{&rpcThrift{btcPubKey: btcPubKey}, addrtx.BTCAddrType_P2WPKH, addrtx.ErrorCode_UNSUPPORTED_COIN},
+	}
+	for _, c := range errCases {
+		addrType := c.addrType
+		_, err := c.handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: 1, AddrType: &addrType})
+		if e, ok := err.(*addrtx.AddrTXException); !ok || e.Code != c.code {
+			t.Errorf("address type %v: expected %v, got %v", c.addrType, c.code, err)
+		}
+	}
+	//p2wsh addresses are never issued
+	_, err = handler.GetUIDByAddr(&addrtx.GetUIDByAddrMsg{CoinType: "BTC", Addr: "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7"})
+	if e, ok := err.(*addrtx.AddrTXException); !ok || e.Code != addrtx.ErrorCode_INVALID_ARGUMENT {
+		t.Errorf("expected INVALID_ARGUMENT, got %v", err)
+	}
+}
+
+func TestGetAddrP2SHP2WPKH(t *testing.T) {
+	//the BIP-49 account m/49'/0'/0'/0 of the BIP-49 test mnemonic
+	handler, store, account := testBTCAccount(t, 49, addrtx.BTCAddrType_P2SH_P2WPKH)
+	handler.btcAddrType = addrtx.BTCAddrType_P2SH_P2WPKH
+
+	addr, err := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: 0})
+	assert.Nil(t, err)
+	assert.Equal(t, "37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf", addr)
+	rec, err := store.GetAddrRecord("BTC", addr)
+	if err != nil {
+		t.Fatalf("p2sh-p2wpkh address not recorded: %v", err)
+	}
+	assert.Equal(t, "m/49'/0'/0'/0/0", rec.Path)
+
+	//script hash addresses are looked up under the BIP-49 account
+	child7, _ := account.Pub().Child(7)
+	uid, err := handler.GetUIDByAddr(&addrtx.GetUIDByAddrMsg{CoinType: "BTC", Addr: genP2SHP2WPKHAddr(child7.Key, false)})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), uid)
+	_, err = handler.GetUIDByAddr(&addrtx.GetUIDByAddrMsg{CoinType: "BTC", Addr: "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy"})
+	if e, ok := err.(*addrtx.AddrTXException); !ok || e.Code != addrtx.ErrorCode_ADDRESS_NOT_FOUND {
+		t.Errorf("expected ADDRESS_NOT_FOUND, got %v", err)
+	}
+
+	//GetTX pays to nested segwit addresses
+	fundBTCAddr(handler, addr, 500000)
+	psbt, err := handler.GetTX(&addrtx.GetTXMsg{CoinType: "BTC", FromUID: 0, FromAmount: 400000, ToUID: 7, ToAmount: 400000})
+	assert.Nil(t, err)
+	p, txHex := parseBTCPSBT(t, psbt)
+	assert.Equal(t, "p2sh-p2wpkh", p.Inputs[0].ScriptType)
+	child0, _ := account.Pub().Child(0)
+	assert.Equal(t, btc.P2WPKHRedeemScript(btc.Hash160(child0.Key)), p.Inputs[0].RedeemScript)
+	toScript, _ := btc.CreateP2SHScriptPubkey(genP2SHP2WPKHAddr(child7.Key, false))
+	assert.Contains(t, txHex, "801a060000000000"+"17"+hex.EncodeToString(toScript))
+}
+
+func TestGetAddrP2TR(t *testing.T) {
+	//the BIP-86 account m/86'/0'/0'/0 of the BIP-86 test mnemonic
+	handler, store, _ := testBTCAccount(t, 86, addrtx.BTCAddrType_P2TR)
+
+	p2tr := addrtx.BTCAddrType_P2TR
+	addr, err := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: 1, AddrType: &p2tr})
+	assert.Nil(t, err)
+	assert.Equal(t, "bc1p4qhjn9zdvkux4e44uhx8tc55attvtyu358kutcqkudyccelu0was9fqzwh", addr)
+	rec, err := store.GetAddrRecord("BTC", addr)
+	if err != nil {
+		t.Fatalf("p2tr address not recorded: %v", err)
+	}
+	assert.Equal(t, "m/86'/0'/0'/0/1", rec.Path)
+	handler.store = nil
+	uid, err := handler.GetUIDByAddr(&addrtx.GetUIDByAddrMsg{CoinType: "BTC", Addr: "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), uid)
+
+	//withdrawals from a legacy address to a taproot one
+	p2pkh := addrtx.BTCAddrType_P2PKH
+	fromAddr, _ := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: 5, AddrType: &p2pkh})
+	fundBTCAddr(handler, fromAddr, 500000)
+	fromScript, _ := btc.AddressScript(fromAddr)
+	psbt, err := handler.GetTX(&addrtx.GetTXMsg{CoinType: "BTC", FromUID: 5, FromAmount: 400000, ToUID: 1, ToAmount: 400000, ToAddrType: &p2tr})
+	assert.Nil(t, err)
+	p, txHex := parseBTCPSBT(t, psbt)
+	assert.Equal(t, 0, len(p.Outputs[0].TapDerivations), "the taproot receiver is not change")
+	assert.Contains(t, txHex, "801a060000000000"+"22"+"5120a82f29944d65b86ae6b5e5cc75e294ead6c59391a1edc5e016e3498c67fc7bbb")
+	assert.Contains(t, txHex, "19"+hex.EncodeToString(fromScript), "change should return to the legacy sender")
+
+	//no taproot account configured
+	handler.btcP2TRPubKey = nil
+	_, err = handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: 1, AddrType: &p2tr})
+	if e, ok := err.(*addrtx.AddrTXException); !ok || e.Code != addrtx.ErrorCode_UNSUPPORTED_COIN {
+		t.Errorf("expected UNSUPPORTED_COIN, got %v", err)
+	}
+}
+
+func TestFinalizeTX(t *testing.T) {
+	//the BIP-84 account m/84'/0'/0'/0 of the BIP-84 test mnemonic
+	handler, _, account := testBTCAccount(t, 84, addrtx.BTCAddrType_P2WPKH)
+	handler.btcAddrType = addrtx.BTCAddrType_P2WPKH
+	fromAddr, _ := handler.GetAddr(&addrtx.GetAddrMsg{CoinType: "BTC", UID: 1})
+	fake := fundBTCAddr(handler, fromAddr, 500000)
+	unsigned, err := handler.GetTX(&addrtx.GetTXMsg{CoinType: "BTC", FromUID: 1, FromAmount: 400000, ToUID: 2, ToAmount: 400000})
+	if err != nil {
+		t.Fatalf("get btc tx: %v", err)
+	}
+
+	//the signer of uid 1 signs the PSBT
+	p, _ := parseBTCPSBT(t, unsigned)
+	child, _ := account.Child(1)
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), child.Key[1:])
+	//the signer need not compute the hash to sign
+	sigHash, err := p.TX.WitnessV0SigHash(nil, 0, btc.SigHashAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, sigHash, p.Inputs[0].SigHash)
+	assert.Equal(t, btc.SigHashAll, *p.Inputs[0].SigHashType)
+	sig, _ := priv.Sign(p.Inputs[0].SigHash)
+	p.Inputs[0].PartialSigs[string(priv.PubKey().SerializeCompressed())] = append(sig.Serialize(), byte(btc.SigHashAll))
+	signed, _ := p.Base64()
+
+	txid, err := handler.FinalizeTX(&addrtx.FinalizeTXMsg{CoinType: "BTC", Psbts: []string{unsigned, signed}})
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(fake.sent)) {
+		tx, err := btc.ParseTX(fake.sent[0])
+		assert.Nil(t, err)
+		assert.Equal(t, hex.EncodeToString(tx.TXID()), txid)
+		assert.Equal(t, 2, len(tx.Txin[0].Witness), "p2wpkh input should be spent by signature and key")
+	}
+
+	errCases := []struct {
+		coinType string
+		psbts    []string
+		code     addrtx.ErrorCode
+	}{
+		{"ETH", []string{signed}, addrtx.ErrorCode_UNSUPPORTED_COIN},
+		{"BTC", nil, addrtx.ErrorCode_INVALID_ARGUMENT},
+		{"BTC", []string{"cHNidP8="}, addrtx.ErrorCode_INVALID_ARGUMENT},
+		//no signature of the input
+		{"BTC", []string{unsigned}, addrtx.ErrorCode_INVALID_ARGUMENT},
+	}
+	for _, c := range errCases {
+		_, err := handler.FinalizeTX(&addrtx.FinalizeTXMsg{CoinType: c.coinType, Psbts: c.psbts})
+		if e, ok := err.(*addrtx.AddrTXException); !ok || e.Code != c.code {
+			t.Errorf("finalize %s %v: expected %v, got %v", c.coinType, c.psbts, c.code, err)
+		}
+	}
+	fake.sendErr = errors.New("tx rejected")
+	_, err = handler.FinalizeTX(&addrtx.FinalizeTXMsg{CoinType: "BTC", Psbts: []string{signed}})
+	if e, ok := err.(*addrtx.AddrTXException); !ok || e.Code != addrtx.ErrorCode_UPSTREAM_UNAVAILABLE {
+		t.Errorf("expected UPSTREAM_UNAVAILABLE, got %v", err)
+	}
+}
@@ -1,34 +1,106 @@
 package main
 
 import (
-	"errors"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"log"
+	"math/big"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"git.apache.org/thrift.git/lib/go/thrift"
-	bip39 "github.com/GameLeLe/trade-addr-tx-service/bip39"
+	btc "github.com/GameLeLe/trade-addr-tx-service/btc"
+	eth "github.com/GameLeLe/trade-addr-tx-service/eth"
 	hdwallet "github.com/GameLeLe/trade-addr-tx-service/hdwallet"
 	addrtx "github.com/GameLeLe/trade-addr-tx-service/thrift/addrtx"
+	"github.com/ethereum/go-ethereum/common"
 )
 
+//maxAddrBatchSize bounds the number of addresses one GetAddrBatch call generates
+const maxAddrBatchSize = 10000
+
 type rpcServer struct {
 	started      bool
 	wg           *sync.WaitGroup
 	addr         string
 	thriftServer *thrift.TSimpleServer
+	handler      *rpcThrift
 }
 
 func newRPCServer(port int, wg *sync.WaitGroup) *rpcServer {
 	server := &rpcServer{}
 	server.addr = "0.0.0.0:" + strconv.Itoa(port)
 	server.wg = wg
+	server.handler = &rpcThrift{}
+	server.handler.btcAccountPath = defaultBTCAccountPath
+	server.handler.btcP2WPKHAccountPath = defaultBTCP2WPKHAccountPath
+	server.handler.btcP2SHP2WPKHAccountPath = defaultBTCP2SHP2WPKHAccountPath
+	server.handler.btcP2TRAccountPath = defaultBTCP2TRAccountPath
+	server.handler.btcAddrType = addrtx.BTCAddrType_P2PKH
+	server.handler.ethAccountPath = defaultETHAccountPath
+	server.handler.uidScanLimit = defaultUIDScanLimit
+	server.handler.ethChainID = big.NewInt(defaultETHChainID)
+	server.handler.btcCoinSelection = addrtx.CoinSelection_BRANCH_AND_BOUND
+	server.handler.btcFeeConfig.FeeTargetBlocks = defaultBTCFeeTargetBlocks
+	server.handler.ethFeeConfig.FeeStrategy = defaultETHFeeStrategy
+	server.handler.ethFeeConfig.FeePercentile = defaultETHFeePercentile
 	return server
 }
 
 type rpcThrift struct {
-	ethPubKey *hdwallet.HDWallet
-	btcPubKey *hdwallet.HDWallet
+	ethPubKey      *hdwallet.HDWallet
+	btcPubKey      *hdwallet.HDWallet
+	btcAccountPath string
+	ethAccountPath string
+	ethChainID     *big.Int
+	ethNonces      eth.NonceProvider
+	//btcUTXOs provides and reserves utxos of BTC addresses, nil disables BTC txs
+	btcUTXOs *btc.UTXOCache
+	//btcCoinSelection is used by requests that leave it unset
+	btcCoinSelection addrtx.CoinSelection
+	//btcFees estimates fee rates, nil uses btc.DefaultFeeRate
+	btcFees btc.FeeEstimator
+	//btcFeeConfig holds the fee settings of requests that leave them unset
+	btcFeeConfig btcConfig
+	//btcTXs returns the previous txs put in PSBTs, nil leaves them out of
+	//segwit inputs and fails legacy ones
+	btcTXs btc.RawTXGetter
+	//btcMasterFingerprint is of the master key the account keys derive from,
+	//nil makes each account key the master of the PSBT key origins
+	btcMasterFingerprint []byte
+	//btcPSBTVersion is the version of the PSBTs GetTX returns, 0 or 2
+	btcPSBTVersion uint32
+	//btcP2WPKHPubKey is the BIP-84 account key of P2WPKH addresses, nil
+	//disables them
+	btcP2WPKHPubKey      *hdwallet.HDWallet
+	btcP2WPKHAccountPath string
+	//btcP2SHP2WPKHPubKey is the BIP-49 account key of P2SH-P2WPKH addresses,
+	//nil disables them
+	btcP2SHP2WPKHPubKey      *hdwallet.HDWallet
+	btcP2SHP2WPKHAccountPath string
+	//btcP2TRPubKey is the BIP-86 account key of P2TR addresses, nil disables
+	//them
+	btcP2TRPubKey      *hdwallet.HDWallet
+	btcP2TRAccountPath string
+	//btcAddrType is used by requests that leave it unset, the zero value is P2PKH
+	btcAddrType addrtx.BTCAddrType
+	//ethFees prices gas from the node, nil leaves only the fixed strategy
+	ethFees eth.FeeOracle
+	//ethFeeConfig holds the fee settings of requests that leave them unset
+	ethFeeConfig ethConfig
+	//ethTokens are the ERC-20 tokens by symbol
+	ethTokens map[string]*eth.Token
+	//ethGas estimates token transfers, nil disables them
+	ethGas eth.GasEstimator
+	//store records issued addresses, nil disables persistence
+	store addrStore
+	//uidScanLimit bounds the derivation scan of GetUIDByAddr, negative disables it
+	uidScanLimit int64
+	//cache holds derived addresses, nil disables caching
+	cache *addrCache
 }
 
 func (rpcT *rpcThrift) GetTX(msg *addrtx.GetTXMsg) (string, error) {
@@ -36,46 +108,619 @@ func (rpcT *rpcThrift) GetTX(msg *addrtx.GetTXMsg) (string, error) {
 	fromUID := msg.FromUID
 	totalAmount := msg.FromAmount
 	toUID := msg.ToUID
-	mnemonic := "duty capital transfer goose segment trap good kite ramp before amused fiber alter awful into chair smile erupt burger scare culture quote visit dragon"
-	password := "222222"
-	// Generate a Bip32 HD wallet for the mnemonic and a user supplied password
-	seed := bip39.NewSeed(mnemonic, password)
-	// Create a master private key
-	masterprv := hdwallet.MasterKey(seed)
-	// Convert a private key to public key
-	masterpub := masterprv.Pub()
-	// Generate new child key based on private or public key
-	//childprv, err := masterprv.Child(0)
-	childpub0, _ := masterpub.Child(0)
-	childpubFrom, _ := childpub0.Child(uint32(fromUID))
-	childpubTO, _ := childpub0.Child(uint32(toUID))
+	fromAddrType := rpcT.addrType(msg.FromAddrType)
+	toAddrType := rpcT.addrType(msg.ToAddrType)
+	//derive from the same account public key GetAddr uses for the coin
+	childpubFrom, err := rpcT.deriveChild(coinType, fromAddrType, fromUID)
+	if err != nil {
+		return "", err
+	}
+	childpubTO, err := rpcT.deriveChild(coinType, toAddrType, toUID)
+	if err != nil {
+		return "", err
+	}
 	switch coinType {
 	case "BTC":
-		return "", nil
+		fromAddr, _, err := rpcT.genAddr(coinType, fromAddrType, fromUID)
+		if err != nil {
+			return "", err
+		}
+		toAddr, _, err := rpcT.genAddr(coinType, toAddrType, toUID)
+		if err != nil {
+			return "", err
+		}
+		if rpcT.btcUTXOs == nil {
+			return "", newAddrTXError(addrtx.ErrorCode_INTERNAL_ERROR, "btc backend not configured")
+		}
+		selector, err := rpcT.btcCoinSelector(msg)
+		if err != nil {
+			return "", err
+		}
+		feeRate, err := rpcT.btcFeeRate(msg)
+		if err != nil {
+			return "", err
+		}
+		fromKey, err := rpcT.btcKeyOrigin(fromAddrType, fromUID, childpubFrom.Pub().Key)
+		if err != nil {
+			return "", err
+		}
+		return getBTCTX(rpcT.btcUTXOs, rpcT.btcTXs, selector, feeRate, fromKey, fromAddr, toAddr, totalAmount, rpcT.btcPSBTVersion)
 	case "ETH":
-		txJSONStr := getETHTX(childpubFrom.Pub().Key, childpubTO.Pub().Key, totalAmount)
-		return txJSONStr, nil
+		if rpcT.ethNonces == nil {
+			return "", newAddrTXError(addrtx.ErrorCode_INTERNAL_ERROR, "eth nonce provider not configured")
+		}
+		token, err := rpcT.ethToken(msg)
+		if err != nil {
+			return "", err
+		}
+		fee, err := rpcT.ethFee(msg)
+		if err != nil {
+			return "", err
+		}
+		fromAddr := genETHAddr(childpubFrom.Pub().Key)
+		gas := uint64(eth.DefaultGasLimit)
+		if token != nil {
+			gas, err = rpcT.ethTokenGas(fromAddr, childpubTO.Pub().Key, token, totalAmount)
+			if err != nil {
+				return "", err
+			}
+		}
+		nonce, err := rpcT.ethNonces.ReserveNonce(fromAddr)
+		if err != nil {
+			return "", newAddrTXError(addrtx.ErrorCode_UPSTREAM_UNAVAILABLE, "get nonce of %s: %v", fromAddr, err)
+		}
+		txStr, err := getETHTX(childpubFrom.Pub().Key, childpubTO.Pub().Key, totalAmount, nonce, rpcT.ethChainID, fee, token, gas)
+		if err != nil {
+			rpcT.ethNonces.ReleaseNonce(fromAddr, nonce)
+			return "", err
+		}
+		return txStr, nil
 	default:
-		return "", nil
+		return "", newAddrTXError(addrtx.ErrorCode_UNSUPPORTED_COIN, "coin type %s not supported", coinType)
 	}
 }
 
+//ethToken returns the ERC-20 token msg transfers, nil for ether
+func (rpcT *rpcThrift) ethToken(msg *addrtx.GetTXMsg) (*eth.Token, error) {
+	if !msg.IsSetToken() {
+		return nil, nil
+	}
+	token, ok := rpcT.ethTokens[strings.ToUpper(msg.GetToken())]
+	if !ok {
+		return nil, newAddrTXError(addrtx.ErrorCode_UNSUPPORTED_COIN, "token %s not configured", msg.GetToken())
+	}
+	return token, nil
+}
+
+//ethTokenGas estimates the gas of transferring amount of token from fromAddr
+//to the address of toPubKey, with a margin for state changes until it is mined
+func (rpcT *rpcThrift) ethTokenGas(fromAddr string, toPubKey []byte, token *eth.Token, amount int64) (uint64, error) {
+	if rpcT.ethGas == nil {
+		return 0, newAddrTXError(addrtx.ErrorCode_INTERNAL_ERROR, "eth node not configured for token transfers")
+	}
+	if amount < 0 {
+		return 0, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "amount must not be negative")
+	}
+	toAddr := common.HexToAddress(genETHAddr(toPubKey))
+	data, err := token.TransferData(toAddr, big.NewInt(amount))
+	if err != nil {
+		return 0, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "%v", err)
+	}
+	gas, err := rpcT.ethGas.EstimateGas(eth.CallMsg{From: fromAddr, To: token.Contract.Hex(), Data: data})
+	if _, ok := err.(*eth.RPCError); ok {
+		//the node ran the call and it reverts, e.g. the token balance is too low
+		return 0, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "%s transfer from %s fails: %v", token.Symbol, fromAddr, err)
+	}
+	if err != nil {
+		return 0, newAddrTXError(addrtx.ErrorCode_UPSTREAM_UNAVAILABLE, "estimate gas of %s transfer: %v", token.Symbol, err)
+	}
+	return eth.GasWithMargin(gas), nil
+}
+
+//btcCoinSelector returns the coin selector of msg, or the configured one
+func (rpcT *rpcThrift) btcCoinSelector(msg *addrtx.GetTXMsg) (btc.CoinSelector, error) {
+	selection := rpcT.btcCoinSelection
+	if msg.IsSetCoinSelection() {
+		selection = msg.GetCoinSelection()
+	}
+	switch selection {
+	case addrtx.CoinSelection_BRANCH_AND_BOUND:
+		return btc.BranchAndBound{}, nil
+	case addrtx.CoinSelection_LARGEST_FIRST:
+		return btc.LargestFirst{}, nil
+	case addrtx.CoinSelection_OLDEST_FIRST:
+		return btc.OldestFirst{}, nil
+	case addrtx.CoinSelection_KNAPSACK:
+		return btc.Knapsack{}, nil
+	}
+	return nil, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "unknown coin selection %v", selection)
+}
+
+//btcFeeRate returns the fee rate of msg, the configured one or the estimate
+//of the backend, in that order
+func (rpcT *rpcThrift) btcFeeRate(msg *addrtx.GetTXMsg) (uint64, error) {
+	if msg.IsSetFeeRate() {
+		if msg.GetFeeRate() < btc.MinFeeRate || msg.GetFeeRate() > btc.MaxFeeRate {
+			return 0, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "fee rate %d not within %d to %d satoshi per vbyte", msg.GetFeeRate(), btc.MinFeeRate, btc.MaxFeeRate)
+		}
+		return uint64(msg.GetFeeRate()), nil
+	}
+	if rpcT.btcFeeConfig.FeeRate > btc.MaxFeeRate {
+		return 0, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "configured fee rate %d above %d satoshi per vbyte", rpcT.btcFeeConfig.FeeRate, btc.MaxFeeRate)
+	}
+	if rpcT.btcFeeConfig.FeeRate > 0 {
+		return uint64(rpcT.btcFeeConfig.FeeRate), nil
+	}
+	if rpcT.btcFees == nil {
+		return btc.DefaultFeeRate, nil
+	}
+	rate, err := rpcT.btcFees.EstimateFeeRate(rpcT.btcFeeConfig.FeeTargetBlocks)
+	if err != nil {
+		return 0, newAddrTXError(addrtx.ErrorCode_UPSTREAM_UNAVAILABLE, "estimate btc fee rate: %v", err)
+	}
+	return rate, nil
+}
+
+//ethFee prices the gas of an ETH tx with the fee strategy, prices and tx type
+//of msg, falling back to the configured ones
+func (rpcT *rpcThrift) ethFee(msg *addrtx.GetTXMsg) (*eth.Fee, error) {
+	conf := rpcT.ethFeeConfig
+	strategy, err := conf.feeStrategy()
+	if err != nil {
+		return nil, newAddrTXError(addrtx.ErrorCode_INTERNAL_ERROR, "%v", err)
+	}
+	if msg.IsSetFeeStrategy() {
+		strategy = msg.GetFeeStrategy()
+	}
+	dynamic := !conf.LegacyTX
+	if msg.IsSetLegacyTX() {
+		dynamic = !msg.GetLegacyTX()
+	}
+	var fees eth.FeeStrategy
+	switch strategy {
+	case addrtx.FeeStrategy_FIXED:
+		maxFee, tip := conf.MaxFeePerGas, conf.MaxPriorityFeePerGas
+		if msg.IsSetMaxFeePerGas() {
+			maxFee = msg.GetMaxFeePerGas()
+		}
+		if msg.IsSetMaxPriorityFeePerGas() {
+			tip = msg.GetMaxPriorityFeePerGas()
+		}
+		fixed := &eth.FixedFee{GasPrice: big.NewInt(maxFee), GasTipCap: big.NewInt(tip)}
+		fee, err := fixed.Fee(dynamic)
+		if err != nil {
+			return nil, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "fixed fee of max %d tip %d: %v", maxFee, tip, err)
+		}
+		return fee, nil
+	case addrtx.FeeStrategy_NODE_SUGGESTED:
+		fees = &eth.NodeSuggestedFee{Oracle: rpcT.ethFees}
+	case addrtx.FeeStrategy_PERCENTILE:
+		percentile := conf.FeePercentile
+		if msg.IsSetFeePercentile() {
+			percentile = int(msg.GetFeePercentile())
+		}
+		if percentile < 0 || percentile > 100 {
+			return nil, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "fee percentile %d not in [0, 100]", percentile)
+		}
+		fees = &eth.PercentileFee{Oracle: rpcT.ethFees, Percentile: float64(percentile), Blocks: conf.FeeHistoryBlocks}
+	default:
+		return nil, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "unknown fee strategy %v", strategy)
+	}
+	if rpcT.ethFees == nil {
+		return nil, newAddrTXError(addrtx.ErrorCode_INTERNAL_ERROR, "eth node not configured for fee strategy %v", strategy)
+	}
+	fee, err := fees.Fee(dynamic)
+	if err != nil {
+		return nil, newAddrTXError(addrtx.ErrorCode_UPSTREAM_UNAVAILABLE, "price eth gas by %v: %v", strategy, err)
+	}
+	return fee, nil
+}
+
 func (rpcT *rpcThrift) GetAddr(msg *addrtx.GetAddrMsg) (string, error) {
+	addrType := rpcT.addrType(msg.AddrType)
+	addr, cached, err := rpcT.genAddr(msg.CoinType, addrType, msg.UID)
+	if err != nil {
+		return "", err
+	}
+	//cached addresses are recorded already
+	if cached {
+		return addr, nil
+	}
+	if err := rpcT.recordAddrs(msg.CoinType, addrType, map[int64]string{msg.UID: addr}); err != nil {
+		return "", err
+	}
+	return addr, nil
+}
+
+func (rpcT *rpcThrift) GetAddrBatch(msg *addrtx.GetAddrBatchMsg) (map[int64]string, error) {
+	uids, err := batchUIDs(msg)
+	if err != nil {
+		return nil, err
+	}
+	addrType := rpcT.addrType(msg.AddrType)
+	addrs := make(map[int64]string, len(uids))
+	//only the addresses not cached need recording
+	derived := make(map[int64]string)
+	for _, uid := range uids {
+		if _, ok := addrs[uid]; ok {
+			continue
+		}
+		addr, cached, err := rpcT.genAddr(msg.CoinType, addrType, uid)
+		if err != nil {
+			return nil, err
+		}
+		addrs[uid] = addr
+		if !cached {
+			derived[uid] = addr
+		}
+	}
+	if err := rpcT.recordAddrs(msg.CoinType, addrType, derived); err != nil {
+		return nil, err
+	}
+	return addrs, nil
+}
+
+func (rpcT *rpcThrift) GetUIDByAddr(msg *addrtx.GetUIDByAddrMsg) (int64, error) {
 	coinType := msg.CoinType
-	uid := msg.UID
+	if msg.Addr == "" {
+		return 0, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "address is empty")
+	}
+	//btc addresses are looked up under the account of their type
+	var addrType addrtx.BTCAddrType
+	if coinType == "BTC" {
+		var err error
+		addrType, err = btcAddrTypeOf(msg.Addr)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if _, err := rpcT.masterPubKey(coinType, addrType); err != nil {
+		return 0, err
+	}
+	if coinType == "ETH" {
+		if err := eth.ValidateAddress(msg.Addr); err != nil {
+			return 0, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "%s: %v", msg.Addr, err)
+		}
+	}
+	if rpcT.store != nil {
+		rec, err := rpcT.store.GetAddrRecord(coinType, msg.Addr)
+		if err == nil {
+			return rec.UID, nil
+		}
+		if err != errAddrNotFound {
+			return 0, newAddrTXError(addrtx.ErrorCode_UPSTREAM_UNAVAILABLE, "look up %s address %s: %v", coinType, msg.Addr, err)
+		}
+	}
+	//not issued through GetAddr, derive the first uids and compare, past the
+	//cache so the scan does not fill it with addresses nobody asked for
+	addr := normalizeAddr(coinType, msg.Addr)
+	for uid := int64(0); uid < rpcT.uidScanLimit; uid++ {
+		uidAddr, err := rpcT.deriveAddr(coinType, addrType, uid)
+		if err != nil {
+			return 0, err
+		}
+		if normalizeAddr(coinType, uidAddr) == addr {
+			if err := rpcT.recordAddrs(coinType, addrType, map[int64]string{uid: uidAddr}); err != nil {
+				return 0, err
+			}
+			return uid, nil
+		}
+	}
+	return 0, newAddrTXError(addrtx.ErrorCode_ADDRESS_NOT_FOUND, "%s address %s not found", coinType, msg.Addr)
+}
+
+//FinalizeTX combines the signed PSBTs of msg, broadcasts the finalized tx and
+//returns its txid
+func (rpcT *rpcThrift) FinalizeTX(msg *addrtx.FinalizeTXMsg) (string, error) {
+	if msg.CoinType != "BTC" {
+		return "", newAddrTXError(addrtx.ErrorCode_UNSUPPORTED_COIN, "coin type %s has no psbts", msg.CoinType)
+	}
+	if rpcT.btcUTXOs == nil {
+		return "", newAddrTXError(addrtx.ErrorCode_INTERNAL_ERROR, "btc backend not configured")
+	}
+	rawtx, err := finalizeBTCTX(msg.Psbts)
+	if err != nil {
+		return "", err
+	}
+	txid, err := rpcT.btcUTXOs.SendTX(rawtx)
+	if err != nil {
+		return "", newAddrTXError(addrtx.ErrorCode_UPSTREAM_UNAVAILABLE, "send btc tx through %s: %v", rpcT.btcUTXOs.GetServiceName(), err)
+	}
+	return hex.EncodeToString(txid), nil
+}
 
+//recordAddrs persists the addresses of coinType and addrType issued to uids,
+//an address is only handed out once it is recorded. Recorded addresses are
+//then cached, so the cache only holds addresses the store has.
+func (rpcT *rpcThrift) recordAddrs(coinType string, addrType addrtx.BTCAddrType, addrs map[int64]string) error {
+	if len(addrs) == 0 {
+		return nil
+	}
+	if err := rpcT.saveAddrs(coinType, addrType, addrs); err != nil {
+		return err
+	}
+	for uid, addr := range addrs {
+		cacheKey, err := rpcT.cacheKey(coinType, addrType, uid)
+		if err != nil {
+			return err
+		}
+		rpcT.cache.Set(cacheKey, addr)
+	}
+	return nil
+}
+
+//saveAddrs writes the records of addrs to the store, if there is one
+func (rpcT *rpcThrift) saveAddrs(coinType string, addrType addrtx.BTCAddrType, addrs map[int64]string) error {
+	if rpcT.store == nil {
+		return nil
+	}
+	now := time.Now().UTC()
+	recs := make([]*addrRecord, 0, len(addrs))
+	for uid, addr := range addrs {
+		rec := &addrRecord{}
+		rec.Coin = coinType
+		rec.UID = uid
+		rec.Path = rpcT.addrPath(coinType, addrType, uid)
+		rec.Addr = addr
+		rec.CreatedAt = now
+		recs = append(recs, rec)
+	}
+	if err := rpcT.store.SaveAddrs(recs); err != nil {
+		return newAddrTXError(addrtx.ErrorCode_UPSTREAM_UNAVAILABLE, "record %s addresses: %v", coinType, err)
+	}
+	return nil
+}
+
+//addrPath returns the full derivation path of the address of uid
+func (rpcT *rpcThrift) addrPath(coinType string, addrType addrtx.BTCAddrType, uid int64) string {
+	path := rpcT.accountPath(coinType, addrType)
+	for _, i := range uidPath(uid) {
+		path += "/" + strconv.FormatUint(uint64(i), 10)
+	}
+	return path
+}
+
+//genAddr returns the address of uid for coinType, of addrType for BTC, and
+//whether it was served from the cache. Derived addresses are cached by
+//recordAddrs once recorded, not here.
+func (rpcT *rpcThrift) genAddr(coinType string, addrType addrtx.BTCAddrType, uid int64) (string, bool, error) {
+	cacheKey, err := rpcT.cacheKey(coinType, addrType, uid)
+	if err != nil {
+		return "", false, err
+	}
+	if err := validateUID(uid); err != nil {
+		return "", false, err
+	}
+	if addr, ok := rpcT.cache.Get(cacheKey); ok {
+		return addr, true, nil
+	}
+	addr, err := rpcT.deriveAddr(coinType, addrType, uid)
+	if err != nil {
+		return "", false, err
+	}
+	return addr, false, nil
+}
+
+//deriveAddr derives the address of uid for coinType, of addrType for BTC,
+//from the account key without the cache
+func (rpcT *rpcThrift) deriveAddr(coinType string, addrType addrtx.BTCAddrType, uid int64) (string, error) {
+	childpubUID, err := rpcT.deriveChild(coinType, addrType, uid)
+	if err != nil {
+		return "", err
+	}
 	switch coinType {
 	case "BTC":
-		childpubUID, _ := rpcT.btcPubKey.Child(uint32(uid))
-		addr := genBTCAddr(childpubUID.Pub().Key, false)
-		return addr, nil
+		return genBTCTypedAddr(childpubUID.Pub().Key, addrType)
+	case "ETH":
+		return genETHAddr(childpubUID.Pub().Key), nil
+	}
+	return "", newAddrTXError(addrtx.ErrorCode_UNSUPPORTED_COIN, "coin type %s not supported", coinType)
+}
+
+//cacheKey returns the cache key of the address of uid for coinType, of
+//addrType for BTC
+func (rpcT *rpcThrift) cacheKey(coinType string, addrType addrtx.BTCAddrType, uid int64) (string, error) {
+	masterPubKey, err := rpcT.masterPubKey(coinType, addrType)
+	if err != nil {
+		return "", err
+	}
+	//legacy addresses keep the cache keys they had before address types
+	cacheCoin := coinType
+	if coinType == "BTC" && addrType != addrtx.BTCAddrType_P2PKH {
+		cacheCoin += "-" + addrType.String()
+	}
+	return addrCacheKey(cacheCoin, masterPubKey, uid), nil
+}
+
+//addrCacheKey keys the address of uid by coin and account key, so a changed
+//master public key file never serves stale addresses
+func addrCacheKey(coinType string, masterPubKey *hdwallet.HDWallet, uid int64) string {
+	keyHash := sha256.Sum256(masterPubKey.Key)
+	return "addr:" + coinType + ":" + hex.EncodeToString(keyHash[:4]) + ":" + strconv.FormatInt(uid, 10)
+}
+
+//batchUIDs expands msg into the uids to generate, either the uid list or the
+//range [startUID, startUID+count)
+func batchUIDs(msg *addrtx.GetAddrBatchMsg) ([]int64, error) {
+	switch {
+	case msg.IsSetUIDList() && (msg.IsSetStartUID() || msg.IsSetCount()):
+		return nil, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "uidList can not be used with startUID and count")
+	case msg.IsSetUIDList():
+		if len(msg.UIDList) > maxAddrBatchSize {
+			return nil, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "batch of %d uids exceeds limit %d", len(msg.UIDList), maxAddrBatchSize)
+		}
+		return msg.UIDList, nil
+	case msg.IsSetStartUID() && msg.IsSetCount():
+		count := msg.GetCount()
+		if count <= 0 || count > maxAddrBatchSize {
+			return nil, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "count %d must be in [1, %d]", count, maxAddrBatchSize)
+		}
+		startUID := msg.GetStartUID()
+		uids := make([]int64, count)
+		for i := range uids {
+			uids[i] = startUID + int64(i)
+		}
+		return uids, nil
+	default:
+		return nil, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "either uidList or startUID and count must be set")
+	}
+}
+
+//addrType returns the requested BTC address type, or the configured one when
+//the request leaves it unset
+func (rpcT *rpcThrift) addrType(requested *addrtx.BTCAddrType) addrtx.BTCAddrType {
+	if requested != nil {
+		return *requested
+	}
+	if rpcT.btcAddrType == 0 {
+		return addrtx.BTCAddrType_P2PKH
+	}
+	return rpcT.btcAddrType
+}
+
+//btcAddrTypeOf returns the type of the mainnet or testnet address addr
+func btcAddrTypeOf(addr string) (addrtx.BTCAddrType, error) {
+	script, err := btc.AddressScript(addr)
+	if err != nil {
+		return 0, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "%s: %v", addr, err)
+	}
+	switch btc.GetScriptType(script) {
+	case btc.ScriptP2PKH:
+		return addrtx.BTCAddrType_P2PKH, nil
+	case btc.ScriptP2WPKH:
+		return addrtx.BTCAddrType_P2WPKH, nil
+	case btc.ScriptP2SH:
+		//the only script hash addresses the service issues
+		return addrtx.BTCAddrType_P2SH_P2WPKH, nil
+	case btc.ScriptP2TR:
+		return addrtx.BTCAddrType_P2TR, nil
+	default:
+		return 0, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "%s is not an address type issued by the service", addr)
+	}
+}
+
+//masterPubKey returns the account public key configured for coinType, for BTC
+//the one of addrType
+func (rpcT *rpcThrift) masterPubKey(coinType string, addrType addrtx.BTCAddrType) (*hdwallet.HDWallet, error) {
+	var pubKey *hdwallet.HDWallet
+	account := coinType
+	switch coinType {
+	case "BTC":
+		switch addrType {
+		case addrtx.BTCAddrType_P2PKH:
+			pubKey = rpcT.btcPubKey
+		case addrtx.BTCAddrType_P2WPKH:
+			pubKey = rpcT.btcP2WPKHPubKey
+			account += " " + addrType.String()
+		case addrtx.BTCAddrType_P2SH_P2WPKH:
+			pubKey = rpcT.btcP2SHP2WPKHPubKey
+			account += " " + addrType.String()
+		case addrtx.BTCAddrType_P2TR:
+			pubKey = rpcT.btcP2TRPubKey
+			account += " " + addrType.String()
+		default:
+			return nil, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "btc address type %v not supported", addrType)
+		}
 	case "ETH":
-		childpubUID, _ := rpcT.ethPubKey.Child(uint32(uid))
-		addr := genETHAddr(childpubUID.Pub().Key)
-		return "0x" + addr, nil
+		pubKey = rpcT.ethPubKey
 	default:
-		return "", errors.New("coin type not supported")
+		return nil, newAddrTXError(addrtx.ErrorCode_UNSUPPORTED_COIN, "coin type %s not supported", coinType)
+	}
+	if pubKey == nil {
+		return nil, newAddrTXError(addrtx.ErrorCode_UNSUPPORTED_COIN, "%s master public key not loaded", account)
+	}
+	return pubKey, nil
+}
+
+//accountPath returns the derivation path of the account key of masterPubKey
+func (rpcT *rpcThrift) accountPath(coinType string, addrType addrtx.BTCAddrType) string {
+	switch {
+	case coinType == "ETH":
+		return rpcT.ethAccountPath
+	case addrType == addrtx.BTCAddrType_P2WPKH:
+		return rpcT.btcP2WPKHAccountPath
+	case addrType == addrtx.BTCAddrType_P2SH_P2WPKH:
+		return rpcT.btcP2SHP2WPKHAccountPath
+	case addrType == addrtx.BTCAddrType_P2TR:
+		return rpcT.btcP2TRAccountPath
+	default:
+		return rpcT.btcAccountPath
+	}
+}
+
+//btcKeyOrigin returns the BIP-32 origin of pubKey, the key of uid under the
+//account of addrType: the account path and uid path under the configured
+//master fingerprint, or the uid path under the account key without one
+func (rpcT *rpcThrift) btcKeyOrigin(addrType addrtx.BTCAddrType, uid int64, pubKey []byte) (*btc.KeyDerivation, error) {
+	key := &btc.KeyDerivation{PubKey: pubKey}
+	if rpcT.btcMasterFingerprint == nil {
+		account, err := rpcT.masterPubKey("BTC", addrType)
+		if err != nil {
+			return nil, err
+		}
+		key.Fingerprint = btc.Hash160(account.Key)[:4]
+		key.Path = uidPath(uid)
+		return key, nil
+	}
+	path, err := parseBIP32Path(rpcT.accountPath("BTC", addrType))
+	if err != nil {
+		return nil, newAddrTXError(addrtx.ErrorCode_INTERNAL_ERROR, "btc %v account: %v", addrType, err)
+	}
+	key.Fingerprint = rpcT.btcMasterFingerprint
+	key.Path = append(path, uidPath(uid)...)
+	return key, nil
+}
+
+//deriveChild derives the public key of uid under the account key of coinType
+//and addrType
+func (rpcT *rpcThrift) deriveChild(coinType string, addrType addrtx.BTCAddrType, uid int64) (*hdwallet.HDWallet, error) {
+	masterPubKey, err := rpcT.masterPubKey(coinType, addrType)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateUID(uid); err != nil {
+		return nil, err
+	}
+	child := masterPubKey
+	for _, i := range uidPath(uid) {
+		child, err = child.Child(i)
+		if err != nil {
+			return nil, newAddrTXError(addrtx.ErrorCode_DERIVATION_FAILED, "derive %s key of uid %d: %v", coinType, uid, err)
+		}
 	}
+	return child, nil
+}
+
+//maxUID bounds the uids, both levels of uidPath must stay below the hardened
+//index 2^31 since only non-hardened children derive from a public key
+const maxUID = 1<<62 - 1
+
+//validateUID checks uid is in [0, maxUID]
+func validateUID(uid int64) error {
+	if uid < 0 || uid > maxUID {
+		return newAddrTXError(addrtx.ErrorCode_INVALID_UID, "uid %d out of range [0, %d]", uid, int64(maxUID))
+	}
+	return nil
+}
+
+//uidPath returns the child indexes of uid under the account key.
+//A uid below 2^31 is the single index account/uid, which keeps the addresses
+//issued before larger uids were supported. A larger uid is split into two
+//levels account/uid_hi/uid_lo with uid_hi = uid >> 31 (never 0 here) and
+//uid_lo = uid & (2^31 - 1), so the two forms never share a path.
+func uidPath(uid int64) []uint32 {
+	if uid < 1<<31 {
+		return []uint32{uint32(uid)}
+	}
+	return []uint32{uint32(uid >> 31), uint32(uid & (1<<31 - 1))}
+}
+
+//newAddrTXError builds the typed exception handed back to thrift callers
+func newAddrTXError(code addrtx.ErrorCode, format string, a ...interface{}) *addrtx.AddrTXException {
+	e := addrtx.NewAddrTXException()
+	e.Code = code
+	e.Message = fmt.Sprintf(format, a...)
+	return e
 }
 
 func (server *rpcServer) start(ethPubKey, btcPubKey *hdwallet.HDWallet) {
@@ -88,7 +733,7 @@ func (server *rpcServer) start(ethPubKey, btcPubKey *hdwallet.HDWallet) {
 		log.Fatal(err)
 	}
 
-	handler := &rpcThrift{}
+	handler := server.handler
 	handler.ethPubKey = ethPubKey
 	handler.btcPubKey = btcPubKey
 	processor := addrtx.NewAddrTXServiceProcessor(handler)
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+//errAddrNotFound is returned when an address was never issued by the service
+var errAddrNotFound = errors.New("address not found")
+
+//addrRecord is an address issued to a uid
+type addrRecord struct {
+	Coin      string
+	UID       int64
+	Path      string
+	Addr      string
+	CreatedAt time.Time
+}
+
+//addrStore records issued addresses and maps them back to the owning uid
+type addrStore interface {
+	SaveAddrs(recs []*addrRecord) error
+	GetAddrRecord(coin, addr string) (*addrRecord, error)
+	Close() error
+}
+
+//normalizeAddr returns the form addresses of coin are stored and looked up in,
+//eth and bech32 btc addresses are case insensitive so they are lower cased
+func normalizeAddr(coin, addr string) string {
+	switch {
+	case coin == "ETH":
+		return strings.ToLower(addr)
+	case coin == "BTC" && len(addr) > 3 && (strings.EqualFold(addr[:3], "bc1") || strings.EqualFold(addr[:3], "tb1")):
+		return strings.ToLower(addr)
+	}
+	return addr
+}
+
+const createAddrTableSQL = `CREATE TABLE IF NOT EXISTS issued_addr (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	coin VARCHAR(16) NOT NULL,
+	uid BIGINT NOT NULL,
+	path VARCHAR(128) NOT NULL,
+	addr VARCHAR(128) NOT NULL,
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (id),
+	UNIQUE KEY uk_coin_addr (coin, addr),
+	KEY idx_coin_uid (coin, uid)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8`
+
+//saveAddrBatchSize bounds the rows of one insert statement
+const saveAddrBatchSize = 500
+
+type mysqlAddrStore struct {
+	db *sql.DB
+}
+
+//newMysqlAddrStore connects to the configured database and creates the
+//issued_addr table if it does not exist
+func newMysqlAddrStore(config mysqlConfig) (*mysqlAddrStore, error) {
+	dsn := mysql.NewConfig()
+	dsn.Net = "tcp"
+	dsn.Addr = config.Host + ":" + strconv.Itoa(config.Port)
+	dsn.DBName = config.DBName
+	dsn.User = config.User
+	dsn.Passwd = config.Pwd
+	dsn.ParseTime = true
+	db, err := sql.Open("mysql", dsn.FormatDSN())
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxIdleConns(config.MaxIdleConn)
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, err = db.Exec(createAddrTableSQL); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &mysqlAddrStore{db: db}, nil
+}
+
+//SaveAddrs inserts recs, addresses already recorded keep their first record
+func (s *mysqlAddrStore) SaveAddrs(recs []*addrRecord) error {
+	if len(recs) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	for start := 0; start < len(recs); start += saveAddrBatchSize {
+		end := start + saveAddrBatchSize
+		if end > len(recs) {
+			end = len(recs)
+		}
+		query := "INSERT IGNORE INTO issued_addr (coin, uid, path, addr, created_at) VALUES " +
+			strings.TrimSuffix(strings.Repeat("(?, ?, ?, ?, ?), ", end-start), ", ")
+		args := make([]interface{}, 0, 5*(end-start))
+		for _, rec := range recs[start:end] {
+			args = append(args, rec.Coin, rec.UID, rec.Path, normalizeAddr(rec.Coin, rec.Addr), rec.CreatedAt.UTC())
+		}
+		if _, err = tx.Exec(query, args...); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+//GetAddrRecord looks up the record of addr, errAddrNotFound if it was never issued
+func (s *mysqlAddrStore) GetAddrRecord(coin, addr string) (*addrRecord, error) {
+	rec := &addrRecord{}
+	row := s.db.QueryRow("SELECT coin, uid, path, addr, created_at FROM issued_addr WHERE coin = ? AND addr = ?",
+		coin, normalizeAddr(coin, addr))
+	err := row.Scan(&rec.Coin, &rec.UID, &rec.Path, &rec.Addr, &rec.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errAddrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+//Close closes the database connections
+func (s *mysqlAddrStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//memAddrStore keeps records in memory for tests
+type memAddrStore struct {
+	mu   sync.Mutex
+	recs map[string]*addrRecord
+	//saved counts the records SaveAddrs was given, err fails it
+	saved int
+	err   error
+}
+
+func newMemAddrStore() *memAddrStore {
+	return &memAddrStore{recs: make(map[string]*addrRecord)}
+}
+
+func (s *memAddrStore) SaveAddrs(recs []*addrRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.saved += len(recs)
+	for _, rec := range recs {
+		key := rec.Coin + ":" + normalizeAddr(rec.Coin, rec.Addr)
+		if _, ok := s.recs[key]; !ok {
+			s.recs[key] = rec
+		}
+	}
+	return nil
+}
+
+func (s *memAddrStore) GetAddrRecord(coin, addr string) (*addrRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.recs[coin+":"+normalizeAddr(coin, addr)]
+	if !ok {
+		return nil, errAddrNotFound
+	}
+	return rec, nil
+}
+
+func (s *memAddrStore) Close() error {
+	return nil
+}
+
+func TestMysqlAddrStore(t *testing.T) {
+	daConfig, err := ParseConfig("config.toml")
+	if err != nil {
+		t.Fatalf("parse config file error: %v", err)
+	}
+	store, err := newMysqlAddrStore(daConfig.DBConfig)
+	if err != nil {
+		t.Skipf("mysql not available: %v", err)
+	}
+	defer store.Close()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	recs := []*addrRecord{
+		{Coin: "BTC", UID: 1, Path: "m/44'/0'/0'/0/1", Addr: "1Test" + suffix, CreatedAt: time.Now()},
+		{Coin: "ETH", UID: 2, Path: "m/44'/60'/0'/2", Addr: "0xAbC" + suffix, CreatedAt: time.Now()},
+	}
+	if err := store.SaveAddrs(recs); err != nil {
+		t.Fatalf("save addrs error: %v", err)
+	}
+	//saving again keeps the first record
+	if err := store.SaveAddrs(recs[:1]); err != nil {
+		t.Fatalf("save addrs again error: %v", err)
+	}
+
+	rec, err := store.GetAddrRecord("BTC", "1Test"+suffix)
+	if err != nil {
+		t.Fatalf("get addr record error: %v", err)
+	}
+	assert.Equal(t, int64(1), rec.UID)
+	assert.Equal(t, "m/44'/0'/0'/0/1", rec.Path)
+	rec, err = store.GetAddrRecord("ETH", "0xabc"+suffix)
+	if err != nil {
+		t.Fatalf("get addr record error: %v", err)
+	}
+	assert.Equal(t, int64(2), rec.UID)
+
+	_, err = store.GetAddrRecord("BTC", "1Missing"+suffix)
+	assert.Equal(t, errAddrNotFound, err)
+}
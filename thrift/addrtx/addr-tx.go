@@ -5,6 +5,8 @@ package addrtx
 
 import (
 	"bytes"
+	"database/sql/driver"
+	"errors"
 	"reflect"
 	"fmt"
 	"git.apache.org/thrift.git/lib/go/thrift"
@@ -16,12 +18,263 @@ var _ = fmt.Printf
 var _ = reflect.DeepEqual
 var _ = bytes.Equal
 
+type ErrorCode int64
+const (
+  ErrorCode_UNSUPPORTED_COIN ErrorCode = 1
+  ErrorCode_INVALID_UID ErrorCode = 2
+  ErrorCode_INVALID_ARGUMENT ErrorCode = 3
+  ErrorCode_DERIVATION_FAILED ErrorCode = 4
+  ErrorCode_UPSTREAM_UNAVAILABLE ErrorCode = 5
+  ErrorCode_INSUFFICIENT_FUNDS ErrorCode = 6
+  ErrorCode_INTERNAL_ERROR ErrorCode = 7
+  ErrorCode_ADDRESS_NOT_FOUND ErrorCode = 8
+)
+
+func (p ErrorCode) String() string {
+  switch p {
+  case ErrorCode_UNSUPPORTED_COIN: return "UNSUPPORTED_COIN"
+  case ErrorCode_INVALID_UID: return "INVALID_UID"
+  case ErrorCode_INVALID_ARGUMENT: return "INVALID_ARGUMENT"
+  case ErrorCode_DERIVATION_FAILED: return "DERIVATION_FAILED"
+  case ErrorCode_UPSTREAM_UNAVAILABLE: return "UPSTREAM_UNAVAILABLE"
+  case ErrorCode_INSUFFICIENT_FUNDS: return "INSUFFICIENT_FUNDS"
+  case ErrorCode_INTERNAL_ERROR: return "INTERNAL_ERROR"
+  case ErrorCode_ADDRESS_NOT_FOUND: return "ADDRESS_NOT_FOUND"
+  }
+  return "<UNSET>"
+}
+
+func ErrorCodeFromString(s string) (ErrorCode, error) {
+  switch s {
+  case "UNSUPPORTED_COIN": return ErrorCode_UNSUPPORTED_COIN, nil 
+  case "INVALID_UID": return ErrorCode_INVALID_UID, nil 
+  case "INVALID_ARGUMENT": return ErrorCode_INVALID_ARGUMENT, nil 
+  case "DERIVATION_FAILED": return ErrorCode_DERIVATION_FAILED, nil 
+  case "UPSTREAM_UNAVAILABLE": return ErrorCode_UPSTREAM_UNAVAILABLE, nil 
+  case "INSUFFICIENT_FUNDS": return ErrorCode_INSUFFICIENT_FUNDS, nil 
+  case "INTERNAL_ERROR": return ErrorCode_INTERNAL_ERROR, nil 
+  case "ADDRESS_NOT_FOUND": return ErrorCode_ADDRESS_NOT_FOUND, nil 
+  }
+  return ErrorCode(0), fmt.Errorf("not a valid ErrorCode string")
+}
+
+
+func ErrorCodePtr(v ErrorCode) *ErrorCode { return &v }
+
+func (p ErrorCode) MarshalText() ([]byte, error) {
+return []byte(p.String()), nil
+}
+
+func (p *ErrorCode) UnmarshalText(text []byte) error {
+q, err := ErrorCodeFromString(string(text))
+if (err != nil) {
+return err
+}
+*p = q
+return nil
+}
+
+func (p *ErrorCode) Scan(value interface{}) error {
+v, ok := value.(int64)
+if !ok {
+return errors.New("Scan value is not int64")
+}
+*p = ErrorCode(v)
+return nil
+}
+
+func (p * ErrorCode) Value() (driver.Value, error) {
+  if p == nil {
+    return nil, nil
+  }
+return int64(*p), nil
+}
+
+type FeeStrategy int64
+const (
+  FeeStrategy_NODE_SUGGESTED FeeStrategy = 1
+  FeeStrategy_FIXED FeeStrategy = 2
+  FeeStrategy_PERCENTILE FeeStrategy = 3
+)
+
+func (p FeeStrategy) String() string {
+  switch p {
+  case FeeStrategy_NODE_SUGGESTED: return "NODE_SUGGESTED"
+  case FeeStrategy_FIXED: return "FIXED"
+  case FeeStrategy_PERCENTILE: return "PERCENTILE"
+  }
+  return "<UNSET>"
+}
+
+func FeeStrategyFromString(s string) (FeeStrategy, error) {
+  switch s {
+  case "NODE_SUGGESTED": return FeeStrategy_NODE_SUGGESTED, nil 
+  case "FIXED": return FeeStrategy_FIXED, nil 
+  case "PERCENTILE": return FeeStrategy_PERCENTILE, nil 
+  }
+  return FeeStrategy(0), fmt.Errorf("not a valid FeeStrategy string")
+}
+
+
+func FeeStrategyPtr(v FeeStrategy) *FeeStrategy { return &v }
+
+func (p FeeStrategy) MarshalText() ([]byte, error) {
+return []byte(p.String()), nil
+}
+
+func (p *FeeStrategy) UnmarshalText(text []byte) error {
+q, err := FeeStrategyFromString(string(text))
+if (err != nil) {
+return err
+}
+*p = q
+return nil
+}
+
+func (p *FeeStrategy) Scan(value interface{}) error {
+v, ok := value.(int64)
+if !ok {
+return errors.New("Scan value is not int64")
+}
+*p = FeeStrategy(v)
+return nil
+}
+
+func (p * FeeStrategy) Value() (driver.Value, error) {
+  if p == nil {
+    return nil, nil
+  }
+return int64(*p), nil
+}
+
+type CoinSelection int64
+const (
+  CoinSelection_BRANCH_AND_BOUND CoinSelection = 1
+  CoinSelection_LARGEST_FIRST CoinSelection = 2
+  CoinSelection_OLDEST_FIRST CoinSelection = 3
+  CoinSelection_KNAPSACK CoinSelection = 4
+)
+
+func (p CoinSelection) String() string {
+  switch p {
+  case CoinSelection_BRANCH_AND_BOUND: return "BRANCH_AND_BOUND"
+  case CoinSelection_LARGEST_FIRST: return "LARGEST_FIRST"
+  case CoinSelection_OLDEST_FIRST: return "OLDEST_FIRST"
+  case CoinSelection_KNAPSACK: return "KNAPSACK"
+  }
+  return "<UNSET>"
+}
+
+func CoinSelectionFromString(s string) (CoinSelection, error) {
+  switch s {
+  case "BRANCH_AND_BOUND": return CoinSelection_BRANCH_AND_BOUND, nil 
+  case "LARGEST_FIRST": return CoinSelection_LARGEST_FIRST, nil 
+  case "OLDEST_FIRST": return CoinSelection_OLDEST_FIRST, nil 
+  case "KNAPSACK": return CoinSelection_KNAPSACK, nil 
+  }
+  return CoinSelection(0), fmt.Errorf("not a valid CoinSelection string")
+}
+
+
+func CoinSelectionPtr(v CoinSelection) *CoinSelection { return &v }
+
+func (p CoinSelection) MarshalText() ([]byte, error) {
+return []byte(p.String()), nil
+}
+
+func (p *CoinSelection) UnmarshalText(text []byte) error {
+q, err := CoinSelectionFromString(string(text))
+if (err != nil) {
+return err
+}
+*p = q
+return nil
+}
+
+func (p *CoinSelection) Scan(value interface{}) error {
+v, ok := value.(int64)
+if !ok {
+return errors.New("Scan value is not int64")
+}
+*p = CoinSelection(v)
+return nil
+}
+
+func (p * CoinSelection) Value() (driver.Value, error) {
+  if p == nil {
+    return nil, nil
+  }
+return int64(*p), nil
+}
+
+type BTCAddrType int64
+const (
+  BTCAddrType_P2PKH BTCAddrType = 1
+  BTCAddrType_P2WPKH BTCAddrType = 2
+  BTCAddrType_P2SH_P2WPKH BTCAddrType = 3
+  BTCAddrType_P2TR BTCAddrType = 4
+)
+
+func (p BTCAddrType) String() string {
+  switch p {
+  case BTCAddrType_P2PKH: return "P2PKH"
+  case BTCAddrType_P2WPKH: return "P2WPKH"
+  case BTCAddrType_P2SH_P2WPKH: return "P2SH_P2WPKH"
+  case BTCAddrType_P2TR: return "P2TR"
+  }
+  return "<UNSET>"
+}
+
+func BTCAddrTypeFromString(s string) (BTCAddrType, error) {
+  switch s {
+  case "P2PKH": return BTCAddrType_P2PKH, nil 
+  case "P2WPKH": return BTCAddrType_P2WPKH, nil 
+  case "P2SH_P2WPKH": return BTCAddrType_P2SH_P2WPKH, nil 
+  case "P2TR": return BTCAddrType_P2TR, nil 
+  }
+  return BTCAddrType(0), fmt.Errorf("not a valid BTCAddrType string")
+}
+
+
+func BTCAddrTypePtr(v BTCAddrType) *BTCAddrType { return &v }
+
+func (p BTCAddrType) MarshalText() ([]byte, error) {
+return []byte(p.String()), nil
+}
+
+func (p *BTCAddrType) UnmarshalText(text []byte) error {
+q, err := BTCAddrTypeFromString(string(text))
+if (err != nil) {
+return err
+}
+*p = q
+return nil
+}
+
+func (p *BTCAddrType) Scan(value interface{}) error {
+v, ok := value.(int64)
+if !ok {
+return errors.New("Scan value is not int64")
+}
+*p = BTCAddrType(v)
+return nil
+}
+
+func (p * BTCAddrType) Value() (driver.Value, error) {
+  if p == nil {
+    return nil, nil
+  }
+return int64(*p), nil
+}
+
 // Attributes:
 //  - CoinType
 //  - UID
+//  - AddrType
 type GetAddrMsg struct {
   CoinType string `thrift:"coinType,1,required" db:"coinType" json:"coinType"`
   UID int64 `thrift:"uid,2,required" db:"uid" json:"uid"`
+  AddrType *BTCAddrType `thrift:"addrType,3" db:"addrType" json:"addrType,omitempty"`
 }
 
 func NewGetAddrMsg() *GetAddrMsg {
@@ -36,6 +289,17 @@ func (p *GetAddrMsg) GetCoinType() string {
 func (p *GetAddrMsg) GetUID() int64 {
   return p.UID
 }
+var GetAddrMsg_AddrType_DEFAULT BTCAddrType
+func (p *GetAddrMsg) GetAddrType() BTCAddrType {
+  if !p.IsSetAddrType() {
+    return GetAddrMsg_AddrType_DEFAULT
+  }
+return *p.AddrType
+}
+func (p *GetAddrMsg) IsSetAddrType() bool {
+  return p.AddrType != nil
+}
+
 func (p *GetAddrMsg) Read(iprot thrift.TProtocol) error {
   if _, err := iprot.ReadStructBegin(); err != nil {
     return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
@@ -73,6 +337,16 @@ func (p *GetAddrMsg) Read(iprot thrift.TProtocol) error {
         }
       }
       issetUID = true
+    case 3:
+      if fieldTypeId == thrift.I32 {
+        if err := p.ReadField3(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
     default:
       if err := iprot.Skip(fieldTypeId); err != nil {
         return err
@@ -112,12 +386,23 @@ func (p *GetAddrMsg)  ReadField2(iprot thrift.TProtocol) error {
   return nil
 }
 
+func (p *GetAddrMsg)  ReadField3(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadI32(); err != nil {
+  return thrift.PrependError("error reading field 3: ", err)
+} else {
+  temp := BTCAddrType(v)
+  p.AddrType = &temp
+}
+  return nil
+}
+
 func (p *GetAddrMsg) Write(oprot thrift.TProtocol) error {
   if err := oprot.WriteStructBegin("GetAddrMsg"); err != nil {
     return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err) }
   if p != nil {
     if err := p.writeField1(oprot); err != nil { return err }
     if err := p.writeField2(oprot); err != nil { return err }
+    if err := p.writeField3(oprot); err != nil { return err }
   }
   if err := oprot.WriteFieldStop(); err != nil {
     return thrift.PrependError("write field stop error: ", err) }
@@ -146,6 +431,18 @@ func (p *GetAddrMsg) writeField2(oprot thrift.TProtocol) (err error) {
   return err
 }
 
+func (p *GetAddrMsg) writeField3(oprot thrift.TProtocol) (err error) {
+  if p.IsSetAddrType() {
+    if err := oprot.WriteFieldBegin("addrType", thrift.I32, 3); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:addrType: ", p), err) }
+    if err := oprot.WriteI32(int32(*p.AddrType)); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T.addrType (3) field write error: ", p), err) }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 3:addrType: ", p), err) }
+  }
+  return err
+}
+
 func (p *GetAddrMsg) String() string {
   if p == nil {
     return "<nil>"
@@ -159,12 +456,32 @@ func (p *GetAddrMsg) String() string {
 //  - FromAmount
 //  - ToUID
 //  - ToAmount
+//  - FeeStrategy
+//  - MaxFeePerGas
+//  - MaxPriorityFeePerGas
+//  - FeePercentile
+//  - LegacyTX
+//  - Token
+//  - CoinSelection
+//  - FeeRate
+//  - FromAddrType
+//  - ToAddrType
 type GetTXMsg struct {
   CoinType string `thrift:"coinType,1,required" db:"coinType" json:"coinType"`
   FromUID int64 `thrift:"fromUID,2,required" db:"fromUID" json:"fromUID"`
   FromAmount int64 `thrift:"fromAmount,3,required" db:"fromAmount" json:"fromAmount"`
   ToUID int64 `thrift:"toUID,4,required" db:"toUID" json:"toUID"`
   ToAmount int64 `thrift:"toAmount,5,required" db:"toAmount" json:"toAmount"`
+  FeeStrategy *FeeStrategy `thrift:"feeStrategy,6" db:"feeStrategy" json:"feeStrategy,omitempty"`
+  MaxFeePerGas *int64 `thrift:"maxFeePerGas,7" db:"maxFeePerGas" json:"maxFeePerGas,omitempty"`
+  MaxPriorityFeePerGas *int64 `thrift:"maxPriorityFeePerGas,8" db:"maxPriorityFeePerGas" json:"maxPriorityFeePerGas,omitempty"`
+  FeePercentile *int32 `thrift:"feePercentile,9" db:"feePercentile" json:"feePercentile,omitempty"`
+  LegacyTX *bool `thrift:"legacyTX,10" db:"legacyTX" json:"legacyTX,omitempty"`
+  Token *string `thrift:"token,11" db:"token" json:"token,omitempty"`
+  CoinSelection *CoinSelection `thrift:"coinSelection,12" db:"coinSelection" json:"coinSelection,omitempty"`
+  FeeRate *int64 `thrift:"feeRate,13" db:"feeRate" json:"feeRate,omitempty"`
+  FromAddrType *BTCAddrType `thrift:"fromAddrType,14" db:"fromAddrType" json:"fromAddrType,omitempty"`
+  ToAddrType *BTCAddrType `thrift:"toAddrType,15" db:"toAddrType" json:"toAddrType,omitempty"`
 }
 
 func NewGetTXMsg() *GetTXMsg {
@@ -191,6 +508,116 @@ func (p *GetTXMsg) GetToUID() int64 {
 func (p *GetTXMsg) GetToAmount() int64 {
   return p.ToAmount
 }
+var GetTXMsg_FeeStrategy_DEFAULT FeeStrategy
+func (p *GetTXMsg) GetFeeStrategy() FeeStrategy {
+  if !p.IsSetFeeStrategy() {
+    return GetTXMsg_FeeStrategy_DEFAULT
+  }
+return *p.FeeStrategy
+}
+var GetTXMsg_MaxFeePerGas_DEFAULT int64
+func (p *GetTXMsg) GetMaxFeePerGas() int64 {
+  if !p.IsSetMaxFeePerGas() {
+    return GetTXMsg_MaxFeePerGas_DEFAULT
+  }
+return *p.MaxFeePerGas
+}
+var GetTXMsg_MaxPriorityFeePerGas_DEFAULT int64
+func (p *GetTXMsg) GetMaxPriorityFeePerGas() int64 {
+  if !p.IsSetMaxPriorityFeePerGas() {
+    return GetTXMsg_MaxPriorityFeePerGas_DEFAULT
+  }
+return *p.MaxPriorityFeePerGas
+}
+var GetTXMsg_FeePercentile_DEFAULT int32
+func (p *GetTXMsg) GetFeePercentile() int32 {
+  if !p.IsSetFeePercentile() {
+    return GetTXMsg_FeePercentile_DEFAULT
+  }
+return *p.FeePercentile
+}
+var GetTXMsg_LegacyTX_DEFAULT bool
+func (p *GetTXMsg) GetLegacyTX() bool {
+  if !p.IsSetLegacyTX() {
+    return GetTXMsg_LegacyTX_DEFAULT
+  }
+return *p.LegacyTX
+}
+var GetTXMsg_Token_DEFAULT string
+func (p *GetTXMsg) GetToken() string {
+  if !p.IsSetToken() {
+    return GetTXMsg_Token_DEFAULT
+  }
+return *p.Token
+}
+var GetTXMsg_CoinSelection_DEFAULT CoinSelection
+func (p *GetTXMsg) GetCoinSelection() CoinSelection {
+  if !p.IsSetCoinSelection() {
+    return GetTXMsg_CoinSelection_DEFAULT
+  }
+return *p.CoinSelection
+}
+var GetTXMsg_FeeRate_DEFAULT int64
+func (p *GetTXMsg) GetFeeRate() int64 {
+  if !p.IsSetFeeRate() {
+    return GetTXMsg_FeeRate_DEFAULT
+  }
+return *p.FeeRate
+}
+var GetTXMsg_FromAddrType_DEFAULT BTCAddrType
+func (p *GetTXMsg) GetFromAddrType() BTCAddrType {
+  if !p.IsSetFromAddrType() {
+    return GetTXMsg_FromAddrType_DEFAULT
+  }
+return *p.FromAddrType
+}
+var GetTXMsg_ToAddrType_DEFAULT BTCAddrType
+func (p *GetTXMsg) GetToAddrType() BTCAddrType {
+  if !p.IsSetToAddrType() {
+    return GetTXMsg_ToAddrType_DEFAULT
+  }
+return *p.ToAddrType
+}
+func (p *GetTXMsg) IsSetFeeStrategy() bool {
+  return p.FeeStrategy != nil
+}
+
+func (p *GetTXMsg) IsSetMaxFeePerGas() bool {
+  return p.MaxFeePerGas != nil
+}
+
+func (p *GetTXMsg) IsSetMaxPriorityFeePerGas() bool {
+  return p.MaxPriorityFeePerGas != nil
+}
+
+func (p *GetTXMsg) IsSetFeePercentile() bool {
+  return p.FeePercentile != nil
+}
+
+func (p *GetTXMsg) IsSetLegacyTX() bool {
+  return p.LegacyTX != nil
+}
+
+func (p *GetTXMsg) IsSetToken() bool {
+  return p.Token != nil
+}
+
+func (p *GetTXMsg) IsSetCoinSelection() bool {
+  return p.CoinSelection != nil
+}
+
+func (p *GetTXMsg) IsSetFeeRate() bool {
+  return p.FeeRate != nil
+}
+
+func (p *GetTXMsg) IsSetFromAddrType() bool {
+  return p.FromAddrType != nil
+}
+
+func (p *GetTXMsg) IsSetToAddrType() bool {
+  return p.ToAddrType != nil
+}
+
 func (p *GetTXMsg) Read(iprot thrift.TProtocol) error {
   if _, err := iprot.ReadStructBegin(); err != nil {
     return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
@@ -264,6 +691,106 @@ func (p *GetTXMsg) Read(iprot thrift.TProtocol) error {
         }
       }
       issetToAmount = true
+    case 6:
+      if fieldTypeId == thrift.I32 {
+        if err := p.ReadField6(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    case 7:
+      if fieldTypeId == thrift.I64 {
+        if err := p.ReadField7(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    case 8:
+      if fieldTypeId == thrift.I64 {
+        if err := p.ReadField8(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    case 9:
+      if fieldTypeId == thrift.I32 {
+        if err := p.ReadField9(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    case 10:
+      if fieldTypeId == thrift.BOOL {
+        if err := p.ReadField10(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    case 11:
+      if fieldTypeId == thrift.STRING {
+        if err := p.ReadField11(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    case 12:
+      if fieldTypeId == thrift.I32 {
+        if err := p.ReadField12(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    case 13:
+      if fieldTypeId == thrift.I64 {
+        if err := p.ReadField13(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    case 14:
+      if fieldTypeId == thrift.I32 {
+        if err := p.ReadField14(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    case 15:
+      if fieldTypeId == thrift.I32 {
+        if err := p.ReadField15(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
     default:
       if err := iprot.Skip(fieldTypeId); err != nil {
         return err
@@ -339,34 +866,138 @@ func (p *GetTXMsg)  ReadField5(iprot thrift.TProtocol) error {
   return nil
 }
 
-func (p *GetTXMsg) Write(oprot thrift.TProtocol) error {
-  if err := oprot.WriteStructBegin("GetTXMsg"); err != nil {
-    return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err) }
-  if p != nil {
-    if err := p.writeField1(oprot); err != nil { return err }
-    if err := p.writeField2(oprot); err != nil { return err }
-    if err := p.writeField3(oprot); err != nil { return err }
-    if err := p.writeField4(oprot); err != nil { return err }
-    if err := p.writeField5(oprot); err != nil { return err }
-  }
-  if err := oprot.WriteFieldStop(); err != nil {
-    return thrift.PrependError("write field stop error: ", err) }
-  if err := oprot.WriteStructEnd(); err != nil {
-    return thrift.PrependError("write struct stop error: ", err) }
+func (p *GetTXMsg)  ReadField6(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadI32(); err != nil {
+  return thrift.PrependError("error reading field 6: ", err)
+} else {
+  temp := FeeStrategy(v)
+  p.FeeStrategy = &temp
+}
   return nil
 }
 
-func (p *GetTXMsg) writeField1(oprot thrift.TProtocol) (err error) {
-  if err := oprot.WriteFieldBegin("coinType", thrift.STRING, 1); err != nil {
-    return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:coinType: ", p), err) }
-  if err := oprot.WriteString(string(p.CoinType)); err != nil {
-  return thrift.PrependError(fmt.Sprintf("%T.coinType (1) field write error: ", p), err) }
-  if err := oprot.WriteFieldEnd(); err != nil {
-    return thrift.PrependError(fmt.Sprintf("%T write field end error 1:coinType: ", p), err) }
-  return err
+func (p *GetTXMsg)  ReadField7(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadI64(); err != nil {
+  return thrift.PrependError("error reading field 7: ", err)
+} else {
+  p.MaxFeePerGas = &v
+}
+  return nil
 }
 
-func (p *GetTXMsg) writeField2(oprot thrift.TProtocol) (err error) {
+func (p *GetTXMsg)  ReadField8(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadI64(); err != nil {
+  return thrift.PrependError("error reading field 8: ", err)
+} else {
+  p.MaxPriorityFeePerGas = &v
+}
+  return nil
+}
+
+func (p *GetTXMsg)  ReadField9(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadI32(); err != nil {
+  return thrift.PrependError("error reading field 9: ", err)
+} else {
+  p.FeePercentile = &v
+}
+  return nil
+}
+
+func (p *GetTXMsg)  ReadField10(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadBool(); err != nil {
+  return thrift.PrependError("error reading field 10: ", err)
+} else {
+  p.LegacyTX = &v
+}
+  return nil
+}
+
+func (p *GetTXMsg)  ReadField11(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadString(); err != nil {
+  return thrift.PrependError("error reading field 11: ", err)
+} else {
+  p.Token = &v
+}
+  return nil
+}
+
+func (p *GetTXMsg)  ReadField12(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadI32(); err != nil {
+  return thrift.PrependError("error reading field 12: ", err)
+} else {
+  temp := CoinSelection(v)
+  p.CoinSelection = &temp
+}
+  return nil
+}
+
+func (p *GetTXMsg)  ReadField13(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadI64(); err != nil {
+  return thrift.PrependError("error reading field 13: ", err)
+} else {
+  p.FeeRate = &v
+}
+  return nil
+}
+
+func (p *GetTXMsg)  ReadField14(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadI32(); err != nil {
+  return thrift.PrependError("error reading field 14: ", err)
+} else {
+  temp := BTCAddrType(v)
+  p.FromAddrType = &temp
+}
+  return nil
+}
+
+func (p *GetTXMsg)  ReadField15(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadI32(); err != nil {
+  return thrift.PrependError("error reading field 15: ", err)
+} else {
+  temp := BTCAddrType(v)
+  p.ToAddrType = &temp
+}
+  return nil
+}
+
+func (p *GetTXMsg) Write(oprot thrift.TProtocol) error {
+  if err := oprot.WriteStructBegin("GetTXMsg"); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err) }
+  if p != nil {
+    if err := p.writeField1(oprot); err != nil { return err }
+    if err := p.writeField2(oprot); err != nil { return err }
+    if err := p.writeField3(oprot); err != nil { return err }
+    if err := p.writeField4(oprot); err != nil { return err }
+    if err := p.writeField5(oprot); err != nil { return err }
+    if err := p.writeField6(oprot); err != nil { return err }
+    if err := p.writeField7(oprot); err != nil { return err }
+    if err := p.writeField8(oprot); err != nil { return err }
+    if err := p.writeField9(oprot); err != nil { return err }
+    if err := p.writeField10(oprot); err != nil { return err }
+    if err := p.writeField11(oprot); err != nil { return err }
+    if err := p.writeField12(oprot); err != nil { return err }
+    if err := p.writeField13(oprot); err != nil { return err }
+    if err := p.writeField14(oprot); err != nil { return err }
+    if err := p.writeField15(oprot); err != nil { return err }
+  }
+  if err := oprot.WriteFieldStop(); err != nil {
+    return thrift.PrependError("write field stop error: ", err) }
+  if err := oprot.WriteStructEnd(); err != nil {
+    return thrift.PrependError("write struct stop error: ", err) }
+  return nil
+}
+
+func (p *GetTXMsg) writeField1(oprot thrift.TProtocol) (err error) {
+  if err := oprot.WriteFieldBegin("coinType", thrift.STRING, 1); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:coinType: ", p), err) }
+  if err := oprot.WriteString(string(p.CoinType)); err != nil {
+  return thrift.PrependError(fmt.Sprintf("%T.coinType (1) field write error: ", p), err) }
+  if err := oprot.WriteFieldEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field end error 1:coinType: ", p), err) }
+  return err
+}
+
+func (p *GetTXMsg) writeField2(oprot thrift.TProtocol) (err error) {
   if err := oprot.WriteFieldBegin("fromUID", thrift.I64, 2); err != nil {
     return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:fromUID: ", p), err) }
   if err := oprot.WriteI64(int64(p.FromUID)); err != nil {
@@ -406,365 +1037,2393 @@ func (p *GetTXMsg) writeField5(oprot thrift.TProtocol) (err error) {
   return err
 }
 
-func (p *GetTXMsg) String() string {
-  if p == nil {
-    return "<nil>"
+func (p *GetTXMsg) writeField6(oprot thrift.TProtocol) (err error) {
+  if p.IsSetFeeStrategy() {
+    if err := oprot.WriteFieldBegin("feeStrategy", thrift.I32, 6); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:feeStrategy: ", p), err) }
+    if err := oprot.WriteI32(int32(*p.FeeStrategy)); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T.feeStrategy (6) field write error: ", p), err) }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 6:feeStrategy: ", p), err) }
   }
-  return fmt.Sprintf("GetTXMsg(%+v)", *p)
+  return err
 }
 
-type AddrTXService interface {
-  // Parameters:
-  //  - Msg
-  GetAddr(msg *GetAddrMsg) (r string, err error)
-  // Parameters:
-  //  - Msg
-  GetTX(msg *GetTXMsg) (r string, err error)
+func (p *GetTXMsg) writeField7(oprot thrift.TProtocol) (err error) {
+  if p.IsSetMaxFeePerGas() {
+    if err := oprot.WriteFieldBegin("maxFeePerGas", thrift.I64, 7); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 7:maxFeePerGas: ", p), err) }
+    if err := oprot.WriteI64(int64(*p.MaxFeePerGas)); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T.maxFeePerGas (7) field write error: ", p), err) }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 7:maxFeePerGas: ", p), err) }
+  }
+  return err
 }
 
-type AddrTXServiceClient struct {
-  Transport thrift.TTransport
-  ProtocolFactory thrift.TProtocolFactory
-  InputProtocol thrift.TProtocol
-  OutputProtocol thrift.TProtocol
-  SeqId int32
+func (p *GetTXMsg) writeField8(oprot thrift.TProtocol) (err error) {
+  if p.IsSetMaxPriorityFeePerGas() {
+    if err := oprot.WriteFieldBegin("maxPriorityFeePerGas", thrift.I64, 8); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 8:maxPriorityFeePerGas: ", p), err) }
+    if err := oprot.WriteI64(int64(*p.MaxPriorityFeePerGas)); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T.maxPriorityFeePerGas (8) field write error: ", p), err) }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 8:maxPriorityFeePerGas: ", p), err) }
+  }
+  return err
 }
 
-func NewAddrTXServiceClientFactory(t thrift.TTransport, f thrift.TProtocolFactory) *AddrTXServiceClient {
-  return &AddrTXServiceClient{Transport: t,
-    ProtocolFactory: f,
-    InputProtocol: f.GetProtocol(t),
-    OutputProtocol: f.GetProtocol(t),
-    SeqId: 0,
+func (p *GetTXMsg) writeField9(oprot thrift.TProtocol) (err error) {
+  if p.IsSetFeePercentile() {
+    if err := oprot.WriteFieldBegin("feePercentile", thrift.I32, 9); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 9:feePercentile: ", p), err) }
+    if err := oprot.WriteI32(int32(*p.FeePercentile)); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T.feePercentile (9) field write error: ", p), err) }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 9:feePercentile: ", p), err) }
   }
+  return err
 }
 
-func NewAddrTXServiceClientProtocol(t thrift.TTransport, iprot thrift.TProtocol, oprot thrift.TProtocol) *AddrTXServiceClient {
-  return &AddrTXServiceClient{Transport: t,
-    ProtocolFactory: nil,
-    InputProtocol: iprot,
-    OutputProtocol: oprot,
-    SeqId: 0,
+func (p *GetTXMsg) writeField10(oprot thrift.TProtocol) (err error) {
+  if p.IsSetLegacyTX() {
+    if err := oprot.WriteFieldBegin("legacyTX", thrift.BOOL, 10); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 10:legacyTX: ", p), err) }
+    if err := oprot.WriteBool(bool(*p.LegacyTX)); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T.legacyTX (10) field write error: ", p), err) }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 10:legacyTX: ", p), err) }
   }
+  return err
 }
 
-// Parameters:
-//  - Msg
-func (p *AddrTXServiceClient) GetAddr(msg *GetAddrMsg) (r string, err error) {
-  if err = p.sendGetAddr(msg); err != nil { return }
-  return p.recvGetAddr()
+func (p *GetTXMsg) writeField11(oprot thrift.TProtocol) (err error) {
+  if p.IsSetToken() {
+    if err := oprot.WriteFieldBegin("token", thrift.STRING, 11); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 11:token: ", p), err) }
+    if err := oprot.WriteString(string(*p.Token)); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T.token (11) field write error: ", p), err) }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 11:token: ", p), err) }
+  }
+  return err
 }
 
-func (p *AddrTXServiceClient) sendGetAddr(msg *GetAddrMsg)(err error) {
-  oprot := p.OutputProtocol
-  if oprot == nil {
-    oprot = p.ProtocolFactory.GetProtocol(p.Transport)
-    p.OutputProtocol = oprot
+func (p *GetTXMsg) writeField12(oprot thrift.TProtocol) (err error) {
+  if p.IsSetCoinSelection() {
+    if err := oprot.WriteFieldBegin("coinSelection", thrift.I32, 12); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 12:coinSelection: ", p), err) }
+    if err := oprot.WriteI32(int32(*p.CoinSelection)); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T.coinSelection (12) field write error: ", p), err) }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 12:coinSelection: ", p), err) }
   }
-  p.SeqId++
-  if err = oprot.WriteMessageBegin("GetAddr", thrift.CALL, p.SeqId); err != nil {
-      return
+  return err
+}
+
+func (p *GetTXMsg) writeField13(oprot thrift.TProtocol) (err error) {
+  if p.IsSetFeeRate() {
+    if err := oprot.WriteFieldBegin("feeRate", thrift.I64, 13); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 13:feeRate: ", p), err) }
+    if err := oprot.WriteI64(int64(*p.FeeRate)); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T.feeRate (13) field write error: ", p), err) }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 13:feeRate: ", p), err) }
   }
-  args := AddrTXServiceGetAddrArgs{
-  Msg : msg,
+  return err
+}
+
+func (p *GetTXMsg) writeField14(oprot thrift.TProtocol) (err error) {
+  if p.IsSetFromAddrType() {
+    if err := oprot.WriteFieldBegin("fromAddrType", thrift.I32, 14); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 14:fromAddrType: ", p), err) }
+    if err := oprot.WriteI32(int32(*p.FromAddrType)); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T.fromAddrType (14) field write error: ", p), err) }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 14:fromAddrType: ", p), err) }
   }
-  if err = args.Write(oprot); err != nil {
-      return
+  return err
+}
+
+func (p *GetTXMsg) writeField15(oprot thrift.TProtocol) (err error) {
+  if p.IsSetToAddrType() {
+    if err := oprot.WriteFieldBegin("toAddrType", thrift.I32, 15); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 15:toAddrType: ", p), err) }
+    if err := oprot.WriteI32(int32(*p.ToAddrType)); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T.toAddrType (15) field write error: ", p), err) }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 15:toAddrType: ", p), err) }
   }
-  if err = oprot.WriteMessageEnd(); err != nil {
-      return
+  return err
+}
+
+func (p *GetTXMsg) String() string {
+  if p == nil {
+    return "<nil>"
   }
-  return oprot.Flush()
+  return fmt.Sprintf("GetTXMsg(%+v)", *p)
 }
 
+// Attributes:
+//  - CoinType
+//  - UIDList
+//  - StartUID
+//  - Count
+//  - AddrType
+type GetAddrBatchMsg struct {
+  CoinType string `thrift:"coinType,1,required" db:"coinType" json:"coinType"`
+  UIDList []int64 `thrift:"uidList,2" db:"uidList" json:"uidList,omitempty"`
+  StartUID *int64 `thrift:"startUID,3" db:"startUID" json:"startUID,omitempty"`
+  Count *int32 `thrift:"count,4" db:"count" json:"count,omitempty"`
+  AddrType *BTCAddrType `thrift:"addrType,5" db:"addrType" json:"addrType,omitempty"`
+}
 
-func (p *AddrTXServiceClient) recvGetAddr() (value string, err error) {
-  iprot := p.InputProtocol
-  if iprot == nil {
-    iprot = p.ProtocolFactory.GetProtocol(p.Transport)
-    p.InputProtocol = iprot
-  }
-  method, mTypeId, seqId, err := iprot.ReadMessageBegin()
-  if err != nil {
-    return
-  }
-  if method != "GetAddr" {
-    err = thrift.NewTApplicationException(thrift.WRONG_METHOD_NAME, "GetAddr failed: wrong method name")
-    return
-  }
-  if p.SeqId != seqId {
-    err = thrift.NewTApplicationException(thrift.BAD_SEQUENCE_ID, "GetAddr failed: out of sequence response")
-    return
-  }
-  if mTypeId == thrift.EXCEPTION {
-    error0 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
-    var error1 error
-    error1, err = error0.Read(iprot)
-    if err != nil {
-      return
-    }
-    if err = iprot.ReadMessageEnd(); err != nil {
-      return
-    }
-    err = error1
-    return
-  }
-  if mTypeId != thrift.REPLY {
-    err = thrift.NewTApplicationException(thrift.INVALID_MESSAGE_TYPE_EXCEPTION, "GetAddr failed: invalid message type")
-    return
-  }
-  result := AddrTXServiceGetAddrResult{}
-  if err = result.Read(iprot); err != nil {
-    return
-  }
-  if err = iprot.ReadMessageEnd(); err != nil {
-    return
-  }
-  value = result.GetSuccess()
-  return
+func NewGetAddrBatchMsg() *GetAddrBatchMsg {
+  return &GetAddrBatchMsg{}
 }
 
-// Parameters:
-//  - Msg
-func (p *AddrTXServiceClient) GetTX(msg *GetTXMsg) (r string, err error) {
-  if err = p.sendGetTX(msg); err != nil { return }
-  return p.recvGetTX()
+
+func (p *GetAddrBatchMsg) GetCoinType() string {
+  return p.CoinType
 }
+var GetAddrBatchMsg_UIDList_DEFAULT []int64
 
-func (p *AddrTXServiceClient) sendGetTX(msg *GetTXMsg)(err error) {
-  oprot := p.OutputProtocol
-  if oprot == nil {
-    oprot = p.ProtocolFactory.GetProtocol(p.Transport)
-    p.OutputProtocol = oprot
-  }
-  p.SeqId++
-  if err = oprot.WriteMessageBegin("GetTX", thrift.CALL, p.SeqId); err != nil {
-      return
-  }
-  args := AddrTXServiceGetTXArgs{
-  Msg : msg,
+func (p *GetAddrBatchMsg) GetUIDList() []int64 {
+  return p.UIDList
+}
+var GetAddrBatchMsg_StartUID_DEFAULT int64
+func (p *GetAddrBatchMsg) GetStartUID() int64 {
+  if !p.IsSetStartUID() {
+    return GetAddrBatchMsg_StartUID_DEFAULT
   }
-  if err = args.Write(oprot); err != nil {
-      return
+return *p.StartUID
+}
+var GetAddrBatchMsg_Count_DEFAULT int32
+func (p *GetAddrBatchMsg) GetCount() int32 {
+  if !p.IsSetCount() {
+    return GetAddrBatchMsg_Count_DEFAULT
   }
-  if err = oprot.WriteMessageEnd(); err != nil {
-      return
+return *p.Count
+}
+var GetAddrBatchMsg_AddrType_DEFAULT BTCAddrType
+func (p *GetAddrBatchMsg) GetAddrType() BTCAddrType {
+  if !p.IsSetAddrType() {
+    return GetAddrBatchMsg_AddrType_DEFAULT
   }
-  return oprot.Flush()
+return *p.AddrType
+}
+func (p *GetAddrBatchMsg) IsSetUIDList() bool {
+  return p.UIDList != nil
 }
 
+func (p *GetAddrBatchMsg) IsSetStartUID() bool {
+  return p.StartUID != nil
+}
 
-func (p *AddrTXServiceClient) recvGetTX() (value string, err error) {
-  iprot := p.InputProtocol
-  if iprot == nil {
-    iprot = p.ProtocolFactory.GetProtocol(p.Transport)
-    p.InputProtocol = iprot
-  }
-  method, mTypeId, seqId, err := iprot.ReadMessageBegin()
-  if err != nil {
-    return
-  }
-  if method != "GetTX" {
-    err = thrift.NewTApplicationException(thrift.WRONG_METHOD_NAME, "GetTX failed: wrong method name")
-    return
-  }
-  if p.SeqId != seqId {
-    err = thrift.NewTApplicationException(thrift.BAD_SEQUENCE_ID, "GetTX failed: out of sequence response")
-    return
+func (p *GetAddrBatchMsg) IsSetCount() bool {
+  return p.Count != nil
+}
+
+func (p *GetAddrBatchMsg) IsSetAddrType() bool {
+  return p.AddrType != nil
+}
+
+func (p *GetAddrBatchMsg) Read(iprot thrift.TProtocol) error {
+  if _, err := iprot.ReadStructBegin(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
   }
-  if mTypeId == thrift.EXCEPTION {
-    error2 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
-    var error3 error
-    error3, err = error2.Read(iprot)
-    if err != nil {
+
+  var issetCoinType bool = false;
+
+  for {
+    _, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+    if err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+    }
+    if fieldTypeId == thrift.STOP { break; }
+    switch fieldId {
+    case 1:
+      if fieldTypeId == thrift.STRING {
+        if err := p.ReadField1(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+      issetCoinType = true
+    case 2:
+      if fieldTypeId == thrift.LIST {
+        if err := p.ReadField2(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    case 3:
+      if fieldTypeId == thrift.I64 {
+        if err := p.ReadField3(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    case 4:
+      if fieldTypeId == thrift.I32 {
+        if err := p.ReadField4(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    case 5:
+      if fieldTypeId == thrift.I32 {
+        if err := p.ReadField5(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    default:
+      if err := iprot.Skip(fieldTypeId); err != nil {
+        return err
+      }
+    }
+    if err := iprot.ReadFieldEnd(); err != nil {
+      return err
+    }
+  }
+  if err := iprot.ReadStructEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+  }
+  if !issetCoinType{
+    return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field CoinType is not set"));
+  }
+  return nil
+}
+
+func (p *GetAddrBatchMsg)  ReadField1(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadString(); err != nil {
+  return thrift.PrependError("error reading field 1: ", err)
+} else {
+  p.CoinType = v
+}
+  return nil
+}
+
+func (p *GetAddrBatchMsg)  ReadField2(iprot thrift.TProtocol) error {
+  _, size, err := iprot.ReadListBegin()
+  if err != nil {
+    return thrift.PrependError("error reading list begin: ", err)
+  }
+  tSlice := make([]int64, 0, size)
+  p.UIDList =  tSlice
+  for i := 0; i < size; i ++ {
+var _elem0 int64
+    if v, err := iprot.ReadI64(); err != nil {
+    return thrift.PrependError("error reading field 0: ", err)
+} else {
+    _elem0 = v
+}
+    p.UIDList = append(p.UIDList, _elem0)
+  }
+  if err := iprot.ReadListEnd(); err != nil {
+    return thrift.PrependError("error reading list end: ", err)
+  }
+  return nil
+}
+
+func (p *GetAddrBatchMsg)  ReadField3(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadI64(); err != nil {
+  return thrift.PrependError("error reading field 3: ", err)
+} else {
+  p.StartUID = &v
+}
+  return nil
+}
+
+func (p *GetAddrBatchMsg)  ReadField4(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadI32(); err != nil {
+  return thrift.PrependError("error reading field 4: ", err)
+} else {
+  p.Count = &v
+}
+  return nil
+}
+
+func (p *GetAddrBatchMsg)  ReadField5(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadI32(); err != nil {
+  return thrift.PrependError("error reading field 5: ", err)
+} else {
+  temp := BTCAddrType(v)
+  p.AddrType = &temp
+}
+  return nil
+}
+
+func (p *GetAddrBatchMsg) Write(oprot thrift.TProtocol) error {
+  if err := oprot.WriteStructBegin("GetAddrBatchMsg"); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err) }
+  if p != nil {
+    if err := p.writeField1(oprot); err != nil { return err }
+    if err := p.writeField2(oprot); err != nil { return err }
+    if err := p.writeField3(oprot); err != nil { return err }
+    if err := p.writeField4(oprot); err != nil { return err }
+    if err := p.writeField5(oprot); err != nil { return err }
+  }
+  if err := oprot.WriteFieldStop(); err != nil {
+    return thrift.PrependError("write field stop error: ", err) }
+  if err := oprot.WriteStructEnd(); err != nil {
+    return thrift.PrependError("write struct stop error: ", err) }
+  return nil
+}
+
+func (p *GetAddrBatchMsg) writeField1(oprot thrift.TProtocol) (err error) {
+  if err := oprot.WriteFieldBegin("coinType", thrift.STRING, 1); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:coinType: ", p), err) }
+  if err := oprot.WriteString(string(p.CoinType)); err != nil {
+  return thrift.PrependError(fmt.Sprintf("%T.coinType (1) field write error: ", p), err) }
+  if err := oprot.WriteFieldEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field end error 1:coinType: ", p), err) }
+  return err
+}
+
+func (p *GetAddrBatchMsg) writeField2(oprot thrift.TProtocol) (err error) {
+  if p.IsSetUIDList() {
+    if err := oprot.WriteFieldBegin("uidList", thrift.LIST, 2); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:uidList: ", p), err) }
+    if err := oprot.WriteListBegin(thrift.I64, len(p.UIDList)); err != nil {
+      return thrift.PrependError("error writing list begin: ", err)
+    }
+    for _, v := range p.UIDList {
+      if err := oprot.WriteI64(int64(v)); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err) }
+    }
+    if err := oprot.WriteListEnd(); err != nil {
+      return thrift.PrependError("error writing list end: ", err)
+    }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 2:uidList: ", p), err) }
+  }
+  return err
+}
+
+func (p *GetAddrBatchMsg) writeField3(oprot thrift.TProtocol) (err error) {
+  if p.IsSetStartUID() {
+    if err := oprot.WriteFieldBegin("startUID", thrift.I64, 3); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:startUID: ", p), err) }
+    if err := oprot.WriteI64(int64(*p.StartUID)); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T.startUID (3) field write error: ", p), err) }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 3:startUID: ", p), err) }
+  }
+  return err
+}
+
+func (p *GetAddrBatchMsg) writeField4(oprot thrift.TProtocol) (err error) {
+  if p.IsSetCount() {
+    if err := oprot.WriteFieldBegin("count", thrift.I32, 4); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:count: ", p), err) }
+    if err := oprot.WriteI32(int32(*p.Count)); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T.count (4) field write error: ", p), err) }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 4:count: ", p), err) }
+  }
+  return err
+}
+
+func (p *GetAddrBatchMsg) writeField5(oprot thrift.TProtocol) (err error) {
+  if p.IsSetAddrType() {
+    if err := oprot.WriteFieldBegin("addrType", thrift.I32, 5); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:addrType: ", p), err) }
+    if err := oprot.WriteI32(int32(*p.AddrType)); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T.addrType (5) field write error: ", p), err) }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 5:addrType: ", p), err) }
+  }
+  return err
+}
+
+func (p *GetAddrBatchMsg) String() string {
+  if p == nil {
+    return "<nil>"
+  }
+  return fmt.Sprintf("GetAddrBatchMsg(%+v)", *p)
+}
+
+// Attributes:
+//  - CoinType
+//  - Addr
+type GetUIDByAddrMsg struct {
+  CoinType string `thrift:"coinType,1,required" db:"coinType" json:"coinType"`
+  Addr string `thrift:"addr,2,required" db:"addr" json:"addr"`
+}
+
+func NewGetUIDByAddrMsg() *GetUIDByAddrMsg {
+  return &GetUIDByAddrMsg{}
+}
+
+
+func (p *GetUIDByAddrMsg) GetCoinType() string {
+  return p.CoinType
+}
+
+func (p *GetUIDByAddrMsg) GetAddr() string {
+  return p.Addr
+}
+func (p *GetUIDByAddrMsg) Read(iprot thrift.TProtocol) error {
+  if _, err := iprot.ReadStructBegin(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+  }
+
+  var issetCoinType bool = false;
+  var issetAddr bool = false;
+
+  for {
+    _, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+    if err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+    }
+    if fieldTypeId == thrift.STOP { break; }
+    switch fieldId {
+    case 1:
+      if fieldTypeId == thrift.STRING {
+        if err := p.ReadField1(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+      issetCoinType = true
+    case 2:
+      if fieldTypeId == thrift.STRING {
+        if err := p.ReadField2(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+      issetAddr = true
+    default:
+      if err := iprot.Skip(fieldTypeId); err != nil {
+        return err
+      }
+    }
+    if err := iprot.ReadFieldEnd(); err != nil {
+      return err
+    }
+  }
+  if err := iprot.ReadStructEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+  }
+  if !issetCoinType{
+    return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field CoinType is not set"));
+  }
+  if !issetAddr{
+    return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Addr is not set"));
+  }
+  return nil
+}
+
+func (p *GetUIDByAddrMsg)  ReadField1(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadString(); err != nil {
+  return thrift.PrependError("error reading field 1: ", err)
+} else {
+  p.CoinType = v
+}
+  return nil
+}
+
+func (p *GetUIDByAddrMsg)  ReadField2(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadString(); err != nil {
+  return thrift.PrependError("error reading field 2: ", err)
+} else {
+  p.Addr = v
+}
+  return nil
+}
+
+func (p *GetUIDByAddrMsg) Write(oprot thrift.TProtocol) error {
+  if err := oprot.WriteStructBegin("GetUIDByAddrMsg"); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err) }
+  if p != nil {
+    if err := p.writeField1(oprot); err != nil { return err }
+    if err := p.writeField2(oprot); err != nil { return err }
+  }
+  if err := oprot.WriteFieldStop(); err != nil {
+    return thrift.PrependError("write field stop error: ", err) }
+  if err := oprot.WriteStructEnd(); err != nil {
+    return thrift.PrependError("write struct stop error: ", err) }
+  return nil
+}
+
+func (p *GetUIDByAddrMsg) writeField1(oprot thrift.TProtocol) (err error) {
+  if err := oprot.WriteFieldBegin("coinType", thrift.STRING, 1); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:coinType: ", p), err) }
+  if err := oprot.WriteString(string(p.CoinType)); err != nil {
+  return thrift.PrependError(fmt.Sprintf("%T.coinType (1) field write error: ", p), err) }
+  if err := oprot.WriteFieldEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field end error 1:coinType: ", p), err) }
+  return err
+}
+
+func (p *GetUIDByAddrMsg) writeField2(oprot thrift.TProtocol) (err error) {
+  if err := oprot.WriteFieldBegin("addr", thrift.STRING, 2); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:addr: ", p), err) }
+  if err := oprot.WriteString(string(p.Addr)); err != nil {
+  return thrift.PrependError(fmt.Sprintf("%T.addr (2) field write error: ", p), err) }
+  if err := oprot.WriteFieldEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field end error 2:addr: ", p), err) }
+  return err
+}
+
+func (p *GetUIDByAddrMsg) String() string {
+  if p == nil {
+    return "<nil>"
+  }
+  return fmt.Sprintf("GetUIDByAddrMsg(%+v)", *p)
+}
+
+// Attributes:
+//  - CoinType
+//  - Psbts
+type FinalizeTXMsg struct {
+  CoinType string `thrift:"coinType,1,required" db:"coinType" json:"coinType"`
+  Psbts []string `thrift:"psbts,2,required" db:"psbts" json:"psbts"`
+}
+
+func NewFinalizeTXMsg() *FinalizeTXMsg {
+  return &FinalizeTXMsg{}
+}
+
+
+func (p *FinalizeTXMsg) GetCoinType() string {
+  return p.CoinType
+}
+
+func (p *FinalizeTXMsg) GetPsbts() []string {
+  return p.Psbts
+}
+func (p *FinalizeTXMsg) Read(iprot thrift.TProtocol) error {
+  if _, err := iprot.ReadStructBegin(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+  }
+
+  var issetCoinType bool = false;
+  var issetPsbts bool = false;
+
+  for {
+    _, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+    if err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+    }
+    if fieldTypeId == thrift.STOP { break; }
+    switch fieldId {
+    case 1:
+      if fieldTypeId == thrift.STRING {
+        if err := p.ReadField1(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+      issetCoinType = true
+    case 2:
+      if fieldTypeId == thrift.LIST {
+        if err := p.ReadField2(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+      issetPsbts = true
+    default:
+      if err := iprot.Skip(fieldTypeId); err != nil {
+        return err
+      }
+    }
+    if err := iprot.ReadFieldEnd(); err != nil {
+      return err
+    }
+  }
+  if err := iprot.ReadStructEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+  }
+  if !issetCoinType{
+    return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field CoinType is not set"));
+  }
+  if !issetPsbts{
+    return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Psbts is not set"));
+  }
+  return nil
+}
+
+func (p *FinalizeTXMsg)  ReadField1(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadString(); err != nil {
+  return thrift.PrependError("error reading field 1: ", err)
+} else {
+  p.CoinType = v
+}
+  return nil
+}
+
+func (p *FinalizeTXMsg)  ReadField2(iprot thrift.TProtocol) error {
+  _, size, err := iprot.ReadListBegin()
+  if err != nil {
+    return thrift.PrependError("error reading list begin: ", err)
+  }
+  tSlice := make([]string, 0, size)
+  p.Psbts =  tSlice
+  for i := 0; i < size; i ++ {
+var _elem1 string
+    if v, err := iprot.ReadString(); err != nil {
+    return thrift.PrependError("error reading field 0: ", err)
+} else {
+    _elem1 = v
+}
+    p.Psbts = append(p.Psbts, _elem1)
+  }
+  if err := iprot.ReadListEnd(); err != nil {
+    return thrift.PrependError("error reading list end: ", err)
+  }
+  return nil
+}
+
+func (p *FinalizeTXMsg) Write(oprot thrift.TProtocol) error {
+  if err := oprot.WriteStructBegin("FinalizeTXMsg"); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err) }
+  if p != nil {
+    if err := p.writeField1(oprot); err != nil { return err }
+    if err := p.writeField2(oprot); err != nil { return err }
+  }
+  if err := oprot.WriteFieldStop(); err != nil {
+    return thrift.PrependError("write field stop error: ", err) }
+  if err := oprot.WriteStructEnd(); err != nil {
+    return thrift.PrependError("write struct stop error: ", err) }
+  return nil
+}
+
+func (p *FinalizeTXMsg) writeField1(oprot thrift.TProtocol) (err error) {
+  if err := oprot.WriteFieldBegin("coinType", thrift.STRING, 1); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:coinType: ", p), err) }
+  if err := oprot.WriteString(string(p.CoinType)); err != nil {
+  return thrift.PrependError(fmt.Sprintf("%T.coinType (1) field write error: ", p), err) }
+  if err := oprot.WriteFieldEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field end error 1:coinType: ", p), err) }
+  return err
+}
+
+func (p *FinalizeTXMsg) writeField2(oprot thrift.TProtocol) (err error) {
+  if err := oprot.WriteFieldBegin("psbts", thrift.LIST, 2); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:psbts: ", p), err) }
+  if err := oprot.WriteListBegin(thrift.STRING, len(p.Psbts)); err != nil {
+    return thrift.PrependError("error writing list begin: ", err)
+  }
+  for _, v := range p.Psbts {
+    if err := oprot.WriteString(string(v)); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err) }
+  }
+  if err := oprot.WriteListEnd(); err != nil {
+    return thrift.PrependError("error writing list end: ", err)
+  }
+  if err := oprot.WriteFieldEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field end error 2:psbts: ", p), err) }
+  return err
+}
+
+func (p *FinalizeTXMsg) String() string {
+  if p == nil {
+    return "<nil>"
+  }
+  return fmt.Sprintf("FinalizeTXMsg(%+v)", *p)
+}
+
+// Attributes:
+//  - Code
+//  - Message
+type AddrTXException struct {
+  Code ErrorCode `thrift:"code,1" db:"code" json:"code"`
+  Message string `thrift:"message,2" db:"message" json:"message"`
+}
+
+func NewAddrTXException() *AddrTXException {
+  return &AddrTXException{}
+}
+
+
+func (p *AddrTXException) GetCode() ErrorCode {
+  return p.Code
+}
+
+func (p *AddrTXException) GetMessage() string {
+  return p.Message
+}
+func (p *AddrTXException) Read(iprot thrift.TProtocol) error {
+  if _, err := iprot.ReadStructBegin(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+  }
+
+
+  for {
+    _, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+    if err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+    }
+    if fieldTypeId == thrift.STOP { break; }
+    switch fieldId {
+    case 1:
+      if fieldTypeId == thrift.I32 {
+        if err := p.ReadField1(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    case 2:
+      if fieldTypeId == thrift.STRING {
+        if err := p.ReadField2(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    default:
+      if err := iprot.Skip(fieldTypeId); err != nil {
+        return err
+      }
+    }
+    if err := iprot.ReadFieldEnd(); err != nil {
+      return err
+    }
+  }
+  if err := iprot.ReadStructEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+  }
+  return nil
+}
+
+func (p *AddrTXException)  ReadField1(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadI32(); err != nil {
+  return thrift.PrependError("error reading field 1: ", err)
+} else {
+  temp := ErrorCode(v)
+  p.Code = temp
+}
+  return nil
+}
+
+func (p *AddrTXException)  ReadField2(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadString(); err != nil {
+  return thrift.PrependError("error reading field 2: ", err)
+} else {
+  p.Message = v
+}
+  return nil
+}
+
+func (p *AddrTXException) Write(oprot thrift.TProtocol) error {
+  if err := oprot.WriteStructBegin("AddrTXException"); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err) }
+  if p != nil {
+    if err := p.writeField1(oprot); err != nil { return err }
+    if err := p.writeField2(oprot); err != nil { return err }
+  }
+  if err := oprot.WriteFieldStop(); err != nil {
+    return thrift.PrependError("write field stop error: ", err) }
+  if err := oprot.WriteStructEnd(); err != nil {
+    return thrift.PrependError("write struct stop error: ", err) }
+  return nil
+}
+
+func (p *AddrTXException) writeField1(oprot thrift.TProtocol) (err error) {
+  if err := oprot.WriteFieldBegin("code", thrift.I32, 1); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:code: ", p), err) }
+  if err := oprot.WriteI32(int32(p.Code)); err != nil {
+  return thrift.PrependError(fmt.Sprintf("%T.code (1) field write error: ", p), err) }
+  if err := oprot.WriteFieldEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field end error 1:code: ", p), err) }
+  return err
+}
+
+func (p *AddrTXException) writeField2(oprot thrift.TProtocol) (err error) {
+  if err := oprot.WriteFieldBegin("message", thrift.STRING, 2); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:message: ", p), err) }
+  if err := oprot.WriteString(string(p.Message)); err != nil {
+  return thrift.PrependError(fmt.Sprintf("%T.message (2) field write error: ", p), err) }
+  if err := oprot.WriteFieldEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field end error 2:message: ", p), err) }
+  return err
+}
+
+func (p *AddrTXException) String() string {
+  if p == nil {
+    return "<nil>"
+  }
+  return fmt.Sprintf("AddrTXException(%+v)", *p)
+}
+
+func (p *AddrTXException) Error() string {
+  return p.String()
+}
+
+type AddrTXService interface {
+  // Parameters:
+  //  - Msg
+  GetAddr(msg *GetAddrMsg) (r string, err error)
+  // Parameters:
+  //  - Msg
+  GetTX(msg *GetTXMsg) (r string, err error)
+  // Parameters:
+  //  - Msg
+  GetAddrBatch(msg *GetAddrBatchMsg) (r map[int64]string, err error)
+  // Parameters:
+  //  - Msg
+  GetUIDByAddr(msg *GetUIDByAddrMsg) (r int64, err error)
+  // Parameters:
+  //  - Msg
+  FinalizeTX(msg *FinalizeTXMsg) (r string, err error)
+}
+
+type AddrTXServiceClient struct {
+  Transport thrift.TTransport
+  ProtocolFactory thrift.TProtocolFactory
+  InputProtocol thrift.TProtocol
+  OutputProtocol thrift.TProtocol
+  SeqId int32
+}
+
+func NewAddrTXServiceClientFactory(t thrift.TTransport, f thrift.TProtocolFactory) *AddrTXServiceClient {
+  return &AddrTXServiceClient{Transport: t,
+    ProtocolFactory: f,
+    InputProtocol: f.GetProtocol(t),
+    OutputProtocol: f.GetProtocol(t),
+    SeqId: 0,
+  }
+}
+
+func NewAddrTXServiceClientProtocol(t thrift.TTransport, iprot thrift.TProtocol, oprot thrift.TProtocol) *AddrTXServiceClient {
+  return &AddrTXServiceClient{Transport: t,
+    ProtocolFactory: nil,
+    InputProtocol: iprot,
+    OutputProtocol: oprot,
+    SeqId: 0,
+  }
+}
+
+// Parameters:
+//  - Msg
+func (p *AddrTXServiceClient) GetAddr(msg *GetAddrMsg) (r string, err error) {
+  if err = p.sendGetAddr(msg); err != nil { return }
+  return p.recvGetAddr()
+}
+
+func (p *AddrTXServiceClient) sendGetAddr(msg *GetAddrMsg)(err error) {
+  oprot := p.OutputProtocol
+  if oprot == nil {
+    oprot = p.ProtocolFactory.GetProtocol(p.Transport)
+    p.OutputProtocol = oprot
+  }
+  p.SeqId++
+  if err = oprot.WriteMessageBegin("GetAddr", thrift.CALL, p.SeqId); err != nil {
+      return
+  }
+  args := AddrTXServiceGetAddrArgs{
+  Msg : msg,
+  }
+  if err = args.Write(oprot); err != nil {
+      return
+  }
+  if err = oprot.WriteMessageEnd(); err != nil {
+      return
+  }
+  return oprot.Flush()
+}
+
+
+func (p *AddrTXServiceClient) recvGetAddr() (value string, err error) {
+  iprot := p.InputProtocol
+  if iprot == nil {
+    iprot = p.ProtocolFactory.GetProtocol(p.Transport)
+    p.InputProtocol = iprot
+  }
+  method, mTypeId, seqId, err := iprot.ReadMessageBegin()
+  if err != nil {
+    return
+  }
+  if method != "GetAddr" {
+    err = thrift.NewTApplicationException(thrift.WRONG_METHOD_NAME, "GetAddr failed: wrong method name")
+    return
+  }
+  if p.SeqId != seqId {
+    err = thrift.NewTApplicationException(thrift.BAD_SEQUENCE_ID, "GetAddr failed: out of sequence response")
+    return
+  }
+  if mTypeId == thrift.EXCEPTION {
+    error2 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
+    var error3 error
+    error3, err = error2.Read(iprot)
+    if err != nil {
+      return
+    }
+    if err = iprot.ReadMessageEnd(); err != nil {
+      return
+    }
+    err = error3
+    return
+  }
+  if mTypeId != thrift.REPLY {
+    err = thrift.NewTApplicationException(thrift.INVALID_MESSAGE_TYPE_EXCEPTION, "GetAddr failed: invalid message type")
+    return
+  }
+  result := AddrTXServiceGetAddrResult{}
+  if err = result.Read(iprot); err != nil {
+    return
+  }
+  if err = iprot.ReadMessageEnd(); err != nil {
+    return
+  }
+  if result.Err != nil {
+    err = result.Err
+    return 
+  }
+  value = result.GetSuccess()
+  return
+}
+
+// Parameters:
+//  - Msg
+func (p *AddrTXServiceClient) GetTX(msg *GetTXMsg) (r string, err error) {
+  if err = p.sendGetTX(msg); err != nil { return }
+  return p.recvGetTX()
+}
+
+func (p *AddrTXServiceClient) sendGetTX(msg *GetTXMsg)(err error) {
+  oprot := p.OutputProtocol
+  if oprot == nil {
+    oprot = p.ProtocolFactory.GetProtocol(p.Transport)
+    p.OutputProtocol = oprot
+  }
+  p.SeqId++
+  if err = oprot.WriteMessageBegin("GetTX", thrift.CALL, p.SeqId); err != nil {
+      return
+  }
+  args := AddrTXServiceGetTXArgs{
+  Msg : msg,
+  }
+  if err = args.Write(oprot); err != nil {
+      return
+  }
+  if err = oprot.WriteMessageEnd(); err != nil {
+      return
+  }
+  return oprot.Flush()
+}
+
+
+func (p *AddrTXServiceClient) recvGetTX() (value string, err error) {
+  iprot := p.InputProtocol
+  if iprot == nil {
+    iprot = p.ProtocolFactory.GetProtocol(p.Transport)
+    p.InputProtocol = iprot
+  }
+  method, mTypeId, seqId, err := iprot.ReadMessageBegin()
+  if err != nil {
+    return
+  }
+  if method != "GetTX" {
+    err = thrift.NewTApplicationException(thrift.WRONG_METHOD_NAME, "GetTX failed: wrong method name")
+    return
+  }
+  if p.SeqId != seqId {
+    err = thrift.NewTApplicationException(thrift.BAD_SEQUENCE_ID, "GetTX failed: out of sequence response")
+    return
+  }
+  if mTypeId == thrift.EXCEPTION {
+    error4 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
+    var error5 error
+    error5, err = error4.Read(iprot)
+    if err != nil {
+      return
+    }
+    if err = iprot.ReadMessageEnd(); err != nil {
+      return
+    }
+    err = error5
+    return
+  }
+  if mTypeId != thrift.REPLY {
+    err = thrift.NewTApplicationException(thrift.INVALID_MESSAGE_TYPE_EXCEPTION, "GetTX failed: invalid message type")
+    return
+  }
+  result := AddrTXServiceGetTXResult{}
+  if err = result.Read(iprot); err != nil {
+    return
+  }
+  if err = iprot.ReadMessageEnd(); err != nil {
+    return
+  }
+  if result.Err != nil {
+    err = result.Err
+    return 
+  }
+  value = result.GetSuccess()
+  return
+}
+
+// Parameters:
+//  - Msg
+func (p *AddrTXServiceClient) GetAddrBatch(msg *GetAddrBatchMsg) (r map[int64]string, err error) {
+  if err = p.sendGetAddrBatch(msg); err != nil { return }
+  return p.recvGetAddrBatch()
+}
+
+func (p *AddrTXServiceClient) sendGetAddrBatch(msg *GetAddrBatchMsg)(err error) {
+  oprot := p.OutputProtocol
+  if oprot == nil {
+    oprot = p.ProtocolFactory.GetProtocol(p.Transport)
+    p.OutputProtocol = oprot
+  }
+  p.SeqId++
+  if err = oprot.WriteMessageBegin("GetAddrBatch", thrift.CALL, p.SeqId); err != nil {
       return
+  }
+  args := AddrTXServiceGetAddrBatchArgs{
+  Msg : msg,
+  }
+  if err = args.Write(oprot); err != nil {
+      return
+  }
+  if err = oprot.WriteMessageEnd(); err != nil {
+      return
+  }
+  return oprot.Flush()
+}
+
+
+func (p *AddrTXServiceClient) recvGetAddrBatch() (value map[int64]string, err error) {
+  iprot := p.InputProtocol
+  if iprot == nil {
+    iprot = p.ProtocolFactory.GetProtocol(p.Transport)
+    p.InputProtocol = iprot
+  }
+  method, mTypeId, seqId, err := iprot.ReadMessageBegin()
+  if err != nil {
+    return
+  }
+  if method != "GetAddrBatch" {
+    err = thrift.NewTApplicationException(thrift.WRONG_METHOD_NAME, "GetAddrBatch failed: wrong method name")
+    return
+  }
+  if p.SeqId != seqId {
+    err = thrift.NewTApplicationException(thrift.BAD_SEQUENCE_ID, "GetAddrBatch failed: out of sequence response")
+    return
+  }
+  if mTypeId == thrift.EXCEPTION {
+    error6 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
+    var error7 error
+    error7, err = error6.Read(iprot)
+    if err != nil {
+      return
+    }
+    if err = iprot.ReadMessageEnd(); err != nil {
+      return
+    }
+    err = error7
+    return
+  }
+  if mTypeId != thrift.REPLY {
+    err = thrift.NewTApplicationException(thrift.INVALID_MESSAGE_TYPE_EXCEPTION, "GetAddrBatch failed: invalid message type")
+    return
+  }
+  result := AddrTXServiceGetAddrBatchResult{}
+  if err = result.Read(iprot); err != nil {
+    return
+  }
+  if err = iprot.ReadMessageEnd(); err != nil {
+    return
+  }
+  if result.Err != nil {
+    err = result.Err
+    return 
+  }
+  value = result.GetSuccess()
+  return
+}
+
+// Parameters:
+//  - Msg
+func (p *AddrTXServiceClient) GetUIDByAddr(msg *GetUIDByAddrMsg) (r int64, err error) {
+  if err = p.sendGetUIDByAddr(msg); err != nil { return }
+  return p.recvGetUIDByAddr()
+}
+
+func (p *AddrTXServiceClient) sendGetUIDByAddr(msg *GetUIDByAddrMsg)(err error) {
+  oprot := p.OutputProtocol
+  if oprot == nil {
+    oprot = p.ProtocolFactory.GetProtocol(p.Transport)
+    p.OutputProtocol = oprot
+  }
+  p.SeqId++
+  if err = oprot.WriteMessageBegin("GetUIDByAddr", thrift.CALL, p.SeqId); err != nil {
+      return
+  }
+  args := AddrTXServiceGetUIDByAddrArgs{
+  Msg : msg,
+  }
+  if err = args.Write(oprot); err != nil {
+      return
+  }
+  if err = oprot.WriteMessageEnd(); err != nil {
+      return
+  }
+  return oprot.Flush()
+}
+
+
+func (p *AddrTXServiceClient) recvGetUIDByAddr() (value int64, err error) {
+  iprot := p.InputProtocol
+  if iprot == nil {
+    iprot = p.ProtocolFactory.GetProtocol(p.Transport)
+    p.InputProtocol = iprot
+  }
+  method, mTypeId, seqId, err := iprot.ReadMessageBegin()
+  if err != nil {
+    return
+  }
+  if method != "GetUIDByAddr" {
+    err = thrift.NewTApplicationException(thrift.WRONG_METHOD_NAME, "GetUIDByAddr failed: wrong method name")
+    return
+  }
+  if p.SeqId != seqId {
+    err = thrift.NewTApplicationException(thrift.BAD_SEQUENCE_ID, "GetUIDByAddr failed: out of sequence response")
+    return
+  }
+  if mTypeId == thrift.EXCEPTION {
+    error8 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
+    var error9 error
+    error9, err = error8.Read(iprot)
+    if err != nil {
+      return
+    }
+    if err = iprot.ReadMessageEnd(); err != nil {
+      return
+    }
+    err = error9
+    return
+  }
+  if mTypeId != thrift.REPLY {
+    err = thrift.NewTApplicationException(thrift.INVALID_MESSAGE_TYPE_EXCEPTION, "GetUIDByAddr failed: invalid message type")
+    return
+  }
+  result := AddrTXServiceGetUIDByAddrResult{}
+  if err = result.Read(iprot); err != nil {
+    return
+  }
+  if err = iprot.ReadMessageEnd(); err != nil {
+    return
+  }
+  if result.Err != nil {
+    err = result.Err
+    return 
+  }
+  value = result.GetSuccess()
+  return
+}
+
+// Parameters:
+//  - Msg
+func (p *AddrTXServiceClient) FinalizeTX(msg *FinalizeTXMsg) (r string, err error) {
+  if err = p.sendFinalizeTX(msg); err != nil { return }
+  return p.recvFinalizeTX()
+}
+
+func (p *AddrTXServiceClient) sendFinalizeTX(msg *FinalizeTXMsg)(err error) {
+  oprot := p.OutputProtocol
+  if oprot == nil {
+    oprot = p.ProtocolFactory.GetProtocol(p.Transport)
+    p.OutputProtocol = oprot
+  }
+  p.SeqId++
+  if err = oprot.WriteMessageBegin("FinalizeTX", thrift.CALL, p.SeqId); err != nil {
+      return
+  }
+  args := AddrTXServiceFinalizeTXArgs{
+  Msg : msg,
+  }
+  if err = args.Write(oprot); err != nil {
+      return
+  }
+  if err = oprot.WriteMessageEnd(); err != nil {
+      return
+  }
+  return oprot.Flush()
+}
+
+
+func (p *AddrTXServiceClient) recvFinalizeTX() (value string, err error) {
+  iprot := p.InputProtocol
+  if iprot == nil {
+    iprot = p.ProtocolFactory.GetProtocol(p.Transport)
+    p.InputProtocol = iprot
+  }
+  method, mTypeId, seqId, err := iprot.ReadMessageBegin()
+  if err != nil {
+    return
+  }
+  if method != "FinalizeTX" {
+    err = thrift.NewTApplicationException(thrift.WRONG_METHOD_NAME, "FinalizeTX failed: wrong method name")
+    return
+  }
+  if p.SeqId != seqId {
+    err = thrift.NewTApplicationException(thrift.BAD_SEQUENCE_ID, "FinalizeTX failed: out of sequence response")
+    return
+  }
+  if mTypeId == thrift.EXCEPTION {
+    error10 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
+    var error11 error
+    error11, err = error10.Read(iprot)
+    if err != nil {
+      return
+    }
+    if err = iprot.ReadMessageEnd(); err != nil {
+      return
+    }
+    err = error11
+    return
+  }
+  if mTypeId != thrift.REPLY {
+    err = thrift.NewTApplicationException(thrift.INVALID_MESSAGE_TYPE_EXCEPTION, "FinalizeTX failed: invalid message type")
+    return
+  }
+  result := AddrTXServiceFinalizeTXResult{}
+  if err = result.Read(iprot); err != nil {
+    return
+  }
+  if err = iprot.ReadMessageEnd(); err != nil {
+    return
+  }
+  if result.Err != nil {
+    err = result.Err
+    return 
+  }
+  value = result.GetSuccess()
+  return
+}
+
+
+type AddrTXServiceProcessor struct {
+  processorMap map[string]thrift.TProcessorFunction
+  handler AddrTXService
+}
+
+func (p *AddrTXServiceProcessor) AddToProcessorMap(key string, processor thrift.TProcessorFunction) {
+  p.processorMap[key] = processor
+}
+
+func (p *AddrTXServiceProcessor) GetProcessorFunction(key string) (processor thrift.TProcessorFunction, ok bool) {
+  processor, ok = p.processorMap[key]
+  return processor, ok
+}
+
+func (p *AddrTXServiceProcessor) ProcessorMap() map[string]thrift.TProcessorFunction {
+  return p.processorMap
+}
+
+func NewAddrTXServiceProcessor(handler AddrTXService) *AddrTXServiceProcessor {
+
+  self12 := &AddrTXServiceProcessor{handler:handler, processorMap:make(map[string]thrift.TProcessorFunction)}
+  self12.processorMap["GetAddr"] = &addrTXServiceProcessorGetAddr{handler:handler}
+  self12.processorMap["GetTX"] = &addrTXServiceProcessorGetTX{handler:handler}
+  self12.processorMap["GetAddrBatch"] = &addrTXServiceProcessorGetAddrBatch{handler:handler}
+  self12.processorMap["GetUIDByAddr"] = &addrTXServiceProcessorGetUIDByAddr{handler:handler}
+  self12.processorMap["FinalizeTX"] = &addrTXServiceProcessorFinalizeTX{handler:handler}
+return self12
+}
+
+func (p *AddrTXServiceProcessor) Process(iprot, oprot thrift.TProtocol) (success bool, err thrift.TException) {
+  name, _, seqId, err := iprot.ReadMessageBegin()
+  if err != nil { return false, err }
+  if processor, ok := p.GetProcessorFunction(name); ok {
+    return processor.Process(seqId, iprot, oprot)
+  }
+  iprot.Skip(thrift.STRUCT)
+  iprot.ReadMessageEnd()
+  x13 := thrift.NewTApplicationException(thrift.UNKNOWN_METHOD, "Unknown function " + name)
+  oprot.WriteMessageBegin(name, thrift.EXCEPTION, seqId)
+  x13.Write(oprot)
+  oprot.WriteMessageEnd()
+  oprot.Flush()
+  return false, x13
+
+}
+
+type addrTXServiceProcessorGetAddr struct {
+  handler AddrTXService
+}
+
+func (p *addrTXServiceProcessorGetAddr) Process(seqId int32, iprot, oprot thrift.TProtocol) (success bool, err thrift.TException) {
+  args := AddrTXServiceGetAddrArgs{}
+  if err = args.Read(iprot); err != nil {
+    iprot.ReadMessageEnd()
+    x := thrift.NewTApplicationException(thrift.PROTOCOL_ERROR, err.Error())
+    oprot.WriteMessageBegin("GetAddr", thrift.EXCEPTION, seqId)
+    x.Write(oprot)
+    oprot.WriteMessageEnd()
+    oprot.Flush()
+    return false, err
+  }
+
+  iprot.ReadMessageEnd()
+  result := AddrTXServiceGetAddrResult{}
+var retval string
+  var err2 error
+  if retval, err2 = p.handler.GetAddr(args.Msg); err2 != nil {
+  switch v := err2.(type) {
+    case *AddrTXException:
+  result.Err = v
+    default:
+    x := thrift.NewTApplicationException(thrift.INTERNAL_ERROR, "Internal error processing GetAddr: " + err2.Error())
+    oprot.WriteMessageBegin("GetAddr", thrift.EXCEPTION, seqId)
+    x.Write(oprot)
+    oprot.WriteMessageEnd()
+    oprot.Flush()
+    return true, err2
+  }
+  } else {
+    result.Success = &retval
+}
+  if err2 = oprot.WriteMessageBegin("GetAddr", thrift.REPLY, seqId); err2 != nil {
+    err = err2
+  }
+  if err2 = result.Write(oprot); err == nil && err2 != nil {
+    err = err2
+  }
+  if err2 = oprot.WriteMessageEnd(); err == nil && err2 != nil {
+    err = err2
+  }
+  if err2 = oprot.Flush(); err == nil && err2 != nil {
+    err = err2
+  }
+  if err != nil {
+    return
+  }
+  return true, err
+}
+
+type addrTXServiceProcessorGetTX struct {
+  handler AddrTXService
+}
+
+func (p *addrTXServiceProcessorGetTX) Process(seqId int32, iprot, oprot thrift.TProtocol) (success bool, err thrift.TException) {
+  args := AddrTXServiceGetTXArgs{}
+  if err = args.Read(iprot); err != nil {
+    iprot.ReadMessageEnd()
+    x := thrift.NewTApplicationException(thrift.PROTOCOL_ERROR, err.Error())
+    oprot.WriteMessageBegin("GetTX", thrift.EXCEPTION, seqId)
+    x.Write(oprot)
+    oprot.WriteMessageEnd()
+    oprot.Flush()
+    return false, err
+  }
+
+  iprot.ReadMessageEnd()
+  result := AddrTXServiceGetTXResult{}
+var retval string
+  var err2 error
+  if retval, err2 = p.handler.GetTX(args.Msg); err2 != nil {
+  switch v := err2.(type) {
+    case *AddrTXException:
+  result.Err = v
+    default:
+    x := thrift.NewTApplicationException(thrift.INTERNAL_ERROR, "Internal error processing GetTX: " + err2.Error())
+    oprot.WriteMessageBegin("GetTX", thrift.EXCEPTION, seqId)
+    x.Write(oprot)
+    oprot.WriteMessageEnd()
+    oprot.Flush()
+    return true, err2
+  }
+  } else {
+    result.Success = &retval
+}
+  if err2 = oprot.WriteMessageBegin("GetTX", thrift.REPLY, seqId); err2 != nil {
+    err = err2
+  }
+  if err2 = result.Write(oprot); err == nil && err2 != nil {
+    err = err2
+  }
+  if err2 = oprot.WriteMessageEnd(); err == nil && err2 != nil {
+    err = err2
+  }
+  if err2 = oprot.Flush(); err == nil && err2 != nil {
+    err = err2
+  }
+  if err != nil {
+    return
+  }
+  return true, err
+}
+
+type addrTXServiceProcessorGetAddrBatch struct {
+  handler AddrTXService
+}
+
+func (p *addrTXServiceProcessorGetAddrBatch) Process(seqId int32, iprot, oprot thrift.TProtocol) (success bool, err thrift.TException) {
+  args := AddrTXServiceGetAddrBatchArgs{}
+  if err = args.Read(iprot); err != nil {
+    iprot.ReadMessageEnd()
+    x := thrift.NewTApplicationException(thrift.PROTOCOL_ERROR, err.Error())
+    oprot.WriteMessageBegin("GetAddrBatch", thrift.EXCEPTION, seqId)
+    x.Write(oprot)
+    oprot.WriteMessageEnd()
+    oprot.Flush()
+    return false, err
+  }
+
+  iprot.ReadMessageEnd()
+  result := AddrTXServiceGetAddrBatchResult{}
+var retval map[int64]string
+  var err2 error
+  if retval, err2 = p.handler.GetAddrBatch(args.Msg); err2 != nil {
+  switch v := err2.(type) {
+    case *AddrTXException:
+  result.Err = v
+    default:
+    x := thrift.NewTApplicationException(thrift.INTERNAL_ERROR, "Internal error processing GetAddrBatch: " + err2.Error())
+    oprot.WriteMessageBegin("GetAddrBatch", thrift.EXCEPTION, seqId)
+    x.Write(oprot)
+    oprot.WriteMessageEnd()
+    oprot.Flush()
+    return true, err2
+  }
+  } else {
+    result.Success = retval
+}
+  if err2 = oprot.WriteMessageBegin("GetAddrBatch", thrift.REPLY, seqId); err2 != nil {
+    err = err2
+  }
+  if err2 = result.Write(oprot); err == nil && err2 != nil {
+    err = err2
+  }
+  if err2 = oprot.WriteMessageEnd(); err == nil && err2 != nil {
+    err = err2
+  }
+  if err2 = oprot.Flush(); err == nil && err2 != nil {
+    err = err2
+  }
+  if err != nil {
+    return
+  }
+  return true, err
+}
+
+type addrTXServiceProcessorGetUIDByAddr struct {
+  handler AddrTXService
+}
+
+func (p *addrTXServiceProcessorGetUIDByAddr) Process(seqId int32, iprot, oprot thrift.TProtocol) (success bool, err thrift.TException) {
+  args := AddrTXServiceGetUIDByAddrArgs{}
+  if err = args.Read(iprot); err != nil {
+    iprot.ReadMessageEnd()
+    x := thrift.NewTApplicationException(thrift.PROTOCOL_ERROR, err.Error())
+    oprot.WriteMessageBegin("GetUIDByAddr", thrift.EXCEPTION, seqId)
+    x.Write(oprot)
+    oprot.WriteMessageEnd()
+    oprot.Flush()
+    return false, err
+  }
+
+  iprot.ReadMessageEnd()
+  result := AddrTXServiceGetUIDByAddrResult{}
+var retval int64
+  var err2 error
+  if retval, err2 = p.handler.GetUIDByAddr(args.Msg); err2 != nil {
+  switch v := err2.(type) {
+    case *AddrTXException:
+  result.Err = v
+    default:
+    x := thrift.NewTApplicationException(thrift.INTERNAL_ERROR, "Internal error processing GetUIDByAddr: " + err2.Error())
+    oprot.WriteMessageBegin("GetUIDByAddr", thrift.EXCEPTION, seqId)
+    x.Write(oprot)
+    oprot.WriteMessageEnd()
+    oprot.Flush()
+    return true, err2
+  }
+  } else {
+    result.Success = &retval
+}
+  if err2 = oprot.WriteMessageBegin("GetUIDByAddr", thrift.REPLY, seqId); err2 != nil {
+    err = err2
+  }
+  if err2 = result.Write(oprot); err == nil && err2 != nil {
+    err = err2
+  }
+  if err2 = oprot.WriteMessageEnd(); err == nil && err2 != nil {
+    err = err2
+  }
+  if err2 = oprot.Flush(); err == nil && err2 != nil {
+    err = err2
+  }
+  if err != nil {
+    return
+  }
+  return true, err
+}
+
+type addrTXServiceProcessorFinalizeTX struct {
+  handler AddrTXService
+}
+
+func (p *addrTXServiceProcessorFinalizeTX) Process(seqId int32, iprot, oprot thrift.TProtocol) (success bool, err thrift.TException) {
+  args := AddrTXServiceFinalizeTXArgs{}
+  if err = args.Read(iprot); err != nil {
+    iprot.ReadMessageEnd()
+    x := thrift.NewTApplicationException(thrift.PROTOCOL_ERROR, err.Error())
+    oprot.WriteMessageBegin("FinalizeTX", thrift.EXCEPTION, seqId)
+    x.Write(oprot)
+    oprot.WriteMessageEnd()
+    oprot.Flush()
+    return false, err
+  }
+
+  iprot.ReadMessageEnd()
+  result := AddrTXServiceFinalizeTXResult{}
+var retval string
+  var err2 error
+  if retval, err2 = p.handler.FinalizeTX(args.Msg); err2 != nil {
+  switch v := err2.(type) {
+    case *AddrTXException:
+  result.Err = v
+    default:
+    x := thrift.NewTApplicationException(thrift.INTERNAL_ERROR, "Internal error processing FinalizeTX: " + err2.Error())
+    oprot.WriteMessageBegin("FinalizeTX", thrift.EXCEPTION, seqId)
+    x.Write(oprot)
+    oprot.WriteMessageEnd()
+    oprot.Flush()
+    return true, err2
+  }
+  } else {
+    result.Success = &retval
+}
+  if err2 = oprot.WriteMessageBegin("FinalizeTX", thrift.REPLY, seqId); err2 != nil {
+    err = err2
+  }
+  if err2 = result.Write(oprot); err == nil && err2 != nil {
+    err = err2
+  }
+  if err2 = oprot.WriteMessageEnd(); err == nil && err2 != nil {
+    err = err2
+  }
+  if err2 = oprot.Flush(); err == nil && err2 != nil {
+    err = err2
+  }
+  if err != nil {
+    return
+  }
+  return true, err
+}
+
+
+// HELPER FUNCTIONS AND STRUCTURES
+
+// Attributes:
+//  - Msg
+type AddrTXServiceGetAddrArgs struct {
+  Msg *GetAddrMsg `thrift:"msg,1" db:"msg" json:"msg"`
+}
+
+func NewAddrTXServiceGetAddrArgs() *AddrTXServiceGetAddrArgs {
+  return &AddrTXServiceGetAddrArgs{}
+}
+
+var AddrTXServiceGetAddrArgs_Msg_DEFAULT *GetAddrMsg
+func (p *AddrTXServiceGetAddrArgs) GetMsg() *GetAddrMsg {
+  if !p.IsSetMsg() {
+    return AddrTXServiceGetAddrArgs_Msg_DEFAULT
+  }
+return p.Msg
+}
+func (p *AddrTXServiceGetAddrArgs) IsSetMsg() bool {
+  return p.Msg != nil
+}
+
+func (p *AddrTXServiceGetAddrArgs) Read(iprot thrift.TProtocol) error {
+  if _, err := iprot.ReadStructBegin(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+  }
+
+
+  for {
+    _, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+    if err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+    }
+    if fieldTypeId == thrift.STOP { break; }
+    switch fieldId {
+    case 1:
+      if fieldTypeId == thrift.STRUCT {
+        if err := p.ReadField1(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    default:
+      if err := iprot.Skip(fieldTypeId); err != nil {
+        return err
+      }
+    }
+    if err := iprot.ReadFieldEnd(); err != nil {
+      return err
+    }
+  }
+  if err := iprot.ReadStructEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+  }
+  return nil
+}
+
+func (p *AddrTXServiceGetAddrArgs)  ReadField1(iprot thrift.TProtocol) error {
+  p.Msg = &GetAddrMsg{}
+  if err := p.Msg.Read(iprot); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Msg), err)
+  }
+  return nil
+}
+
+func (p *AddrTXServiceGetAddrArgs) Write(oprot thrift.TProtocol) error {
+  if err := oprot.WriteStructBegin("GetAddr_args"); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err) }
+  if p != nil {
+    if err := p.writeField1(oprot); err != nil { return err }
+  }
+  if err := oprot.WriteFieldStop(); err != nil {
+    return thrift.PrependError("write field stop error: ", err) }
+  if err := oprot.WriteStructEnd(); err != nil {
+    return thrift.PrependError("write struct stop error: ", err) }
+  return nil
+}
+
+func (p *AddrTXServiceGetAddrArgs) writeField1(oprot thrift.TProtocol) (err error) {
+  if err := oprot.WriteFieldBegin("msg", thrift.STRUCT, 1); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:msg: ", p), err) }
+  if err := p.Msg.Write(oprot); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Msg), err)
+  }
+  if err := oprot.WriteFieldEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field end error 1:msg: ", p), err) }
+  return err
+}
+
+func (p *AddrTXServiceGetAddrArgs) String() string {
+  if p == nil {
+    return "<nil>"
+  }
+  return fmt.Sprintf("AddrTXServiceGetAddrArgs(%+v)", *p)
+}
+
+// Attributes:
+//  - Success
+//  - Err
+type AddrTXServiceGetAddrResult struct {
+  Success *string `thrift:"success,0" db:"success" json:"success,omitempty"`
+  Err *AddrTXException `thrift:"err,1" db:"err" json:"err,omitempty"`
+}
+
+func NewAddrTXServiceGetAddrResult() *AddrTXServiceGetAddrResult {
+  return &AddrTXServiceGetAddrResult{}
+}
+
+var AddrTXServiceGetAddrResult_Success_DEFAULT string
+func (p *AddrTXServiceGetAddrResult) GetSuccess() string {
+  if !p.IsSetSuccess() {
+    return AddrTXServiceGetAddrResult_Success_DEFAULT
+  }
+return *p.Success
+}
+var AddrTXServiceGetAddrResult_Err_DEFAULT *AddrTXException
+func (p *AddrTXServiceGetAddrResult) GetErr() *AddrTXException {
+  if !p.IsSetErr() {
+    return AddrTXServiceGetAddrResult_Err_DEFAULT
+  }
+return p.Err
+}
+func (p *AddrTXServiceGetAddrResult) IsSetSuccess() bool {
+  return p.Success != nil
+}
+
+func (p *AddrTXServiceGetAddrResult) IsSetErr() bool {
+  return p.Err != nil
+}
+
+func (p *AddrTXServiceGetAddrResult) Read(iprot thrift.TProtocol) error {
+  if _, err := iprot.ReadStructBegin(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+  }
+
+
+  for {
+    _, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+    if err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+    }
+    if fieldTypeId == thrift.STOP { break; }
+    switch fieldId {
+    case 0:
+      if fieldTypeId == thrift.STRING {
+        if err := p.ReadField0(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    case 1:
+      if fieldTypeId == thrift.STRUCT {
+        if err := p.ReadField1(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    default:
+      if err := iprot.Skip(fieldTypeId); err != nil {
+        return err
+      }
+    }
+    if err := iprot.ReadFieldEnd(); err != nil {
+      return err
+    }
+  }
+  if err := iprot.ReadStructEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+  }
+  return nil
+}
+
+func (p *AddrTXServiceGetAddrResult)  ReadField0(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadString(); err != nil {
+  return thrift.PrependError("error reading field 0: ", err)
+} else {
+  p.Success = &v
+}
+  return nil
+}
+
+func (p *AddrTXServiceGetAddrResult)  ReadField1(iprot thrift.TProtocol) error {
+  p.Err = &AddrTXException{}
+  if err := p.Err.Read(iprot); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Err), err)
+  }
+  return nil
+}
+
+func (p *AddrTXServiceGetAddrResult) Write(oprot thrift.TProtocol) error {
+  if err := oprot.WriteStructBegin("GetAddr_result"); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err) }
+  if p != nil {
+    if err := p.writeField0(oprot); err != nil { return err }
+    if err := p.writeField1(oprot); err != nil { return err }
+  }
+  if err := oprot.WriteFieldStop(); err != nil {
+    return thrift.PrependError("write field stop error: ", err) }
+  if err := oprot.WriteStructEnd(); err != nil {
+    return thrift.PrependError("write struct stop error: ", err) }
+  return nil
+}
+
+func (p *AddrTXServiceGetAddrResult) writeField0(oprot thrift.TProtocol) (err error) {
+  if p.IsSetSuccess() {
+    if err := oprot.WriteFieldBegin("success", thrift.STRING, 0); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 0:success: ", p), err) }
+    if err := oprot.WriteString(string(*p.Success)); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T.success (0) field write error: ", p), err) }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 0:success: ", p), err) }
+  }
+  return err
+}
+
+func (p *AddrTXServiceGetAddrResult) writeField1(oprot thrift.TProtocol) (err error) {
+  if p.IsSetErr() {
+    if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 1); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:err: ", p), err) }
+    if err := p.Err.Write(oprot); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Err), err)
+    }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 1:err: ", p), err) }
+  }
+  return err
+}
+
+func (p *AddrTXServiceGetAddrResult) String() string {
+  if p == nil {
+    return "<nil>"
+  }
+  return fmt.Sprintf("AddrTXServiceGetAddrResult(%+v)", *p)
+}
+
+// Attributes:
+//  - Msg
+type AddrTXServiceGetTXArgs struct {
+  Msg *GetTXMsg `thrift:"msg,1" db:"msg" json:"msg"`
+}
+
+func NewAddrTXServiceGetTXArgs() *AddrTXServiceGetTXArgs {
+  return &AddrTXServiceGetTXArgs{}
+}
+
+var AddrTXServiceGetTXArgs_Msg_DEFAULT *GetTXMsg
+func (p *AddrTXServiceGetTXArgs) GetMsg() *GetTXMsg {
+  if !p.IsSetMsg() {
+    return AddrTXServiceGetTXArgs_Msg_DEFAULT
+  }
+return p.Msg
+}
+func (p *AddrTXServiceGetTXArgs) IsSetMsg() bool {
+  return p.Msg != nil
+}
+
+func (p *AddrTXServiceGetTXArgs) Read(iprot thrift.TProtocol) error {
+  if _, err := iprot.ReadStructBegin(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+  }
+
+
+  for {
+    _, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+    if err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+    }
+    if fieldTypeId == thrift.STOP { break; }
+    switch fieldId {
+    case 1:
+      if fieldTypeId == thrift.STRUCT {
+        if err := p.ReadField1(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    default:
+      if err := iprot.Skip(fieldTypeId); err != nil {
+        return err
+      }
+    }
+    if err := iprot.ReadFieldEnd(); err != nil {
+      return err
+    }
+  }
+  if err := iprot.ReadStructEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+  }
+  return nil
+}
+
+func (p *AddrTXServiceGetTXArgs)  ReadField1(iprot thrift.TProtocol) error {
+  p.Msg = &GetTXMsg{}
+  if err := p.Msg.Read(iprot); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Msg), err)
+  }
+  return nil
+}
+
+func (p *AddrTXServiceGetTXArgs) Write(oprot thrift.TProtocol) error {
+  if err := oprot.WriteStructBegin("GetTX_args"); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err) }
+  if p != nil {
+    if err := p.writeField1(oprot); err != nil { return err }
+  }
+  if err := oprot.WriteFieldStop(); err != nil {
+    return thrift.PrependError("write field stop error: ", err) }
+  if err := oprot.WriteStructEnd(); err != nil {
+    return thrift.PrependError("write struct stop error: ", err) }
+  return nil
+}
+
+func (p *AddrTXServiceGetTXArgs) writeField1(oprot thrift.TProtocol) (err error) {
+  if err := oprot.WriteFieldBegin("msg", thrift.STRUCT, 1); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:msg: ", p), err) }
+  if err := p.Msg.Write(oprot); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Msg), err)
+  }
+  if err := oprot.WriteFieldEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field end error 1:msg: ", p), err) }
+  return err
+}
+
+func (p *AddrTXServiceGetTXArgs) String() string {
+  if p == nil {
+    return "<nil>"
+  }
+  return fmt.Sprintf("AddrTXServiceGetTXArgs(%+v)", *p)
+}
+
+// Attributes:
+//  - Success
+//  - Err
+type AddrTXServiceGetTXResult struct {
+  Success *string `thrift:"success,0" db:"success" json:"success,omitempty"`
+  Err *AddrTXException `thrift:"err,1" db:"err" json:"err,omitempty"`
+}
+
+func NewAddrTXServiceGetTXResult() *AddrTXServiceGetTXResult {
+  return &AddrTXServiceGetTXResult{}
+}
+
+var AddrTXServiceGetTXResult_Success_DEFAULT string
+func (p *AddrTXServiceGetTXResult) GetSuccess() string {
+  if !p.IsSetSuccess() {
+    return AddrTXServiceGetTXResult_Success_DEFAULT
+  }
+return *p.Success
+}
+var AddrTXServiceGetTXResult_Err_DEFAULT *AddrTXException
+func (p *AddrTXServiceGetTXResult) GetErr() *AddrTXException {
+  if !p.IsSetErr() {
+    return AddrTXServiceGetTXResult_Err_DEFAULT
+  }
+return p.Err
+}
+func (p *AddrTXServiceGetTXResult) IsSetSuccess() bool {
+  return p.Success != nil
+}
+
+func (p *AddrTXServiceGetTXResult) IsSetErr() bool {
+  return p.Err != nil
+}
+
+func (p *AddrTXServiceGetTXResult) Read(iprot thrift.TProtocol) error {
+  if _, err := iprot.ReadStructBegin(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+  }
+
+
+  for {
+    _, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+    if err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
     }
-    if err = iprot.ReadMessageEnd(); err != nil {
-      return
+    if fieldTypeId == thrift.STOP { break; }
+    switch fieldId {
+    case 0:
+      if fieldTypeId == thrift.STRING {
+        if err := p.ReadField0(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    case 1:
+      if fieldTypeId == thrift.STRUCT {
+        if err := p.ReadField1(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    default:
+      if err := iprot.Skip(fieldTypeId); err != nil {
+        return err
+      }
+    }
+    if err := iprot.ReadFieldEnd(); err != nil {
+      return err
     }
-    err = error3
-    return
   }
-  if mTypeId != thrift.REPLY {
-    err = thrift.NewTApplicationException(thrift.INVALID_MESSAGE_TYPE_EXCEPTION, "GetTX failed: invalid message type")
-    return
+  if err := iprot.ReadStructEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
   }
-  result := AddrTXServiceGetTXResult{}
-  if err = result.Read(iprot); err != nil {
-    return
+  return nil
+}
+
+func (p *AddrTXServiceGetTXResult)  ReadField0(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadString(); err != nil {
+  return thrift.PrependError("error reading field 0: ", err)
+} else {
+  p.Success = &v
+}
+  return nil
+}
+
+func (p *AddrTXServiceGetTXResult)  ReadField1(iprot thrift.TProtocol) error {
+  p.Err = &AddrTXException{}
+  if err := p.Err.Read(iprot); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Err), err)
   }
-  if err = iprot.ReadMessageEnd(); err != nil {
-    return
+  return nil
+}
+
+func (p *AddrTXServiceGetTXResult) Write(oprot thrift.TProtocol) error {
+  if err := oprot.WriteStructBegin("GetTX_result"); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err) }
+  if p != nil {
+    if err := p.writeField0(oprot); err != nil { return err }
+    if err := p.writeField1(oprot); err != nil { return err }
   }
-  value = result.GetSuccess()
-  return
+  if err := oprot.WriteFieldStop(); err != nil {
+    return thrift.PrependError("write field stop error: ", err) }
+  if err := oprot.WriteStructEnd(); err != nil {
+    return thrift.PrependError("write struct stop error: ", err) }
+  return nil
+}
+
+func (p *AddrTXServiceGetTXResult) writeField0(oprot thrift.TProtocol) (err error) {
+  if p.IsSetSuccess() {
+    if err := oprot.WriteFieldBegin("success", thrift.STRING, 0); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 0:success: ", p), err) }
+    if err := oprot.WriteString(string(*p.Success)); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T.success (0) field write error: ", p), err) }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 0:success: ", p), err) }
+  }
+  return err
 }
 
+func (p *AddrTXServiceGetTXResult) writeField1(oprot thrift.TProtocol) (err error) {
+  if p.IsSetErr() {
+    if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 1); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:err: ", p), err) }
+    if err := p.Err.Write(oprot); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Err), err)
+    }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 1:err: ", p), err) }
+  }
+  return err
+}
 
-type AddrTXServiceProcessor struct {
-  processorMap map[string]thrift.TProcessorFunction
-  handler AddrTXService
+func (p *AddrTXServiceGetTXResult) String() string {
+  if p == nil {
+    return "<nil>"
+  }
+  return fmt.Sprintf("AddrTXServiceGetTXResult(%+v)", *p)
 }
 
-func (p *AddrTXServiceProcessor) AddToProcessorMap(key string, processor thrift.TProcessorFunction) {
-  p.processorMap[key] = processor
+// Attributes:
+//  - Msg
+type AddrTXServiceGetAddrBatchArgs struct {
+  Msg *GetAddrBatchMsg `thrift:"msg,1" db:"msg" json:"msg"`
 }
 
-func (p *AddrTXServiceProcessor) GetProcessorFunction(key string) (processor thrift.TProcessorFunction, ok bool) {
-  processor, ok = p.processorMap[key]
-  return processor, ok
+func NewAddrTXServiceGetAddrBatchArgs() *AddrTXServiceGetAddrBatchArgs {
+  return &AddrTXServiceGetAddrBatchArgs{}
+}
+
+var AddrTXServiceGetAddrBatchArgs_Msg_DEFAULT *GetAddrBatchMsg
+func (p *AddrTXServiceGetAddrBatchArgs) GetMsg() *GetAddrBatchMsg {
+  if !p.IsSetMsg() {
+    return AddrTXServiceGetAddrBatchArgs_Msg_DEFAULT
+  }
+return p.Msg
+}
+func (p *AddrTXServiceGetAddrBatchArgs) IsSetMsg() bool {
+  return p.Msg != nil
+}
+
+func (p *AddrTXServiceGetAddrBatchArgs) Read(iprot thrift.TProtocol) error {
+  if _, err := iprot.ReadStructBegin(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+  }
+
+
+  for {
+    _, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+    if err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+    }
+    if fieldTypeId == thrift.STOP { break; }
+    switch fieldId {
+    case 1:
+      if fieldTypeId == thrift.STRUCT {
+        if err := p.ReadField1(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    default:
+      if err := iprot.Skip(fieldTypeId); err != nil {
+        return err
+      }
+    }
+    if err := iprot.ReadFieldEnd(); err != nil {
+      return err
+    }
+  }
+  if err := iprot.ReadStructEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+  }
+  return nil
+}
+
+func (p *AddrTXServiceGetAddrBatchArgs)  ReadField1(iprot thrift.TProtocol) error {
+  p.Msg = &GetAddrBatchMsg{}
+  if err := p.Msg.Read(iprot); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Msg), err)
+  }
+  return nil
+}
+
+func (p *AddrTXServiceGetAddrBatchArgs) Write(oprot thrift.TProtocol) error {
+  if err := oprot.WriteStructBegin("GetAddrBatch_args"); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err) }
+  if p != nil {
+    if err := p.writeField1(oprot); err != nil { return err }
+  }
+  if err := oprot.WriteFieldStop(); err != nil {
+    return thrift.PrependError("write field stop error: ", err) }
+  if err := oprot.WriteStructEnd(); err != nil {
+    return thrift.PrependError("write struct stop error: ", err) }
+  return nil
+}
+
+func (p *AddrTXServiceGetAddrBatchArgs) writeField1(oprot thrift.TProtocol) (err error) {
+  if err := oprot.WriteFieldBegin("msg", thrift.STRUCT, 1); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:msg: ", p), err) }
+  if err := p.Msg.Write(oprot); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Msg), err)
+  }
+  if err := oprot.WriteFieldEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write field end error 1:msg: ", p), err) }
+  return err
+}
+
+func (p *AddrTXServiceGetAddrBatchArgs) String() string {
+  if p == nil {
+    return "<nil>"
+  }
+  return fmt.Sprintf("AddrTXServiceGetAddrBatchArgs(%+v)", *p)
+}
+
+// Attributes:
+//  - Success
+//  - Err
+type AddrTXServiceGetAddrBatchResult struct {
+  Success map[int64]string `thrift:"success,0" db:"success" json:"success,omitempty"`
+  Err *AddrTXException `thrift:"err,1" db:"err" json:"err,omitempty"`
+}
+
+func NewAddrTXServiceGetAddrBatchResult() *AddrTXServiceGetAddrBatchResult {
+  return &AddrTXServiceGetAddrBatchResult{}
+}
+
+var AddrTXServiceGetAddrBatchResult_Success_DEFAULT map[int64]string
+
+func (p *AddrTXServiceGetAddrBatchResult) GetSuccess() map[int64]string {
+  return p.Success
+}
+var AddrTXServiceGetAddrBatchResult_Err_DEFAULT *AddrTXException
+func (p *AddrTXServiceGetAddrBatchResult) GetErr() *AddrTXException {
+  if !p.IsSetErr() {
+    return AddrTXServiceGetAddrBatchResult_Err_DEFAULT
+  }
+return p.Err
+}
+func (p *AddrTXServiceGetAddrBatchResult) IsSetSuccess() bool {
+  return p.Success != nil
+}
+
+func (p *AddrTXServiceGetAddrBatchResult) IsSetErr() bool {
+  return p.Err != nil
+}
+
+func (p *AddrTXServiceGetAddrBatchResult) Read(iprot thrift.TProtocol) error {
+  if _, err := iprot.ReadStructBegin(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+  }
+
+
+  for {
+    _, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+    if err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+    }
+    if fieldTypeId == thrift.STOP { break; }
+    switch fieldId {
+    case 0:
+      if fieldTypeId == thrift.MAP {
+        if err := p.ReadField0(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    case 1:
+      if fieldTypeId == thrift.STRUCT {
+        if err := p.ReadField1(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
+    default:
+      if err := iprot.Skip(fieldTypeId); err != nil {
+        return err
+      }
+    }
+    if err := iprot.ReadFieldEnd(); err != nil {
+      return err
+    }
+  }
+  if err := iprot.ReadStructEnd(); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+  }
+  return nil
 }
 
-func (p *AddrTXServiceProcessor) ProcessorMap() map[string]thrift.TProcessorFunction {
-  return p.processorMap
+func (p *AddrTXServiceGetAddrBatchResult)  ReadField0(iprot thrift.TProtocol) error {
+  _, _, size, err := iprot.ReadMapBegin()
+  if err != nil {
+    return thrift.PrependError("error reading map begin: ", err)
+  }
+  tMap := make(map[int64]string, size)
+  p.Success =  tMap
+  for i := 0; i < size; i ++ {
+var _key44 int64
+    if v, err := iprot.ReadI64(); err != nil {
+    return thrift.PrependError("error reading field 0: ", err)
+} else {
+    _key44 = v
 }
-
-func NewAddrTXServiceProcessor(handler AddrTXService) *AddrTXServiceProcessor {
-
-  self4 := &AddrTXServiceProcessor{handler:handler, processorMap:make(map[string]thrift.TProcessorFunction)}
-  self4.processorMap["GetAddr"] = &addrTXServiceProcessorGetAddr{handler:handler}
-  self4.processorMap["GetTX"] = &addrTXServiceProcessorGetTX{handler:handler}
-return self4
+var _val45 string
+    if v, err := iprot.ReadString(); err != nil {
+    return thrift.PrependError("error reading field 0: ", err)
+} else {
+    _val45 = v
 }
-
-func (p *AddrTXServiceProcessor) Process(iprot, oprot thrift.TProtocol) (success bool, err thrift.TException) {
-  name, _, seqId, err := iprot.ReadMessageBegin()
-  if err != nil { return false, err }
-  if processor, ok := p.GetProcessorFunction(name); ok {
-    return processor.Process(seqId, iprot, oprot)
+    p.Success[_key44] = _val45
   }
-  iprot.Skip(thrift.STRUCT)
-  iprot.ReadMessageEnd()
-  x5 := thrift.NewTApplicationException(thrift.UNKNOWN_METHOD, "Unknown function " + name)
-  oprot.WriteMessageBegin(name, thrift.EXCEPTION, seqId)
-  x5.Write(oprot)
-  oprot.WriteMessageEnd()
-  oprot.Flush()
-  return false, x5
-
-}
-
-type addrTXServiceProcessorGetAddr struct {
-  handler AddrTXService
+  if err := iprot.ReadMapEnd(); err != nil {
+    return thrift.PrependError("error reading map end: ", err)
+  }
+  return nil
 }
 
-func (p *addrTXServiceProcessorGetAddr) Process(seqId int32, iprot, oprot thrift.TProtocol) (success bool, err thrift.TException) {
-  args := AddrTXServiceGetAddrArgs{}
-  if err = args.Read(iprot); err != nil {
-    iprot.ReadMessageEnd()
-    x := thrift.NewTApplicationException(thrift.PROTOCOL_ERROR, err.Error())
-    oprot.WriteMessageBegin("GetAddr", thrift.EXCEPTION, seqId)
-    x.Write(oprot)
-    oprot.WriteMessageEnd()
-    oprot.Flush()
-    return false, err
+func (p *AddrTXServiceGetAddrBatchResult)  ReadField1(iprot thrift.TProtocol) error {
+  p.Err = &AddrTXException{}
+  if err := p.Err.Read(iprot); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Err), err)
   }
-
-  iprot.ReadMessageEnd()
-  result := AddrTXServiceGetAddrResult{}
-var retval string
-  var err2 error
-  if retval, err2 = p.handler.GetAddr(args.Msg); err2 != nil {
-    x := thrift.NewTApplicationException(thrift.INTERNAL_ERROR, "Internal error processing GetAddr: " + err2.Error())
-    oprot.WriteMessageBegin("GetAddr", thrift.EXCEPTION, seqId)
-    x.Write(oprot)
-    oprot.WriteMessageEnd()
-    oprot.Flush()
-    return true, err2
-  } else {
-    result.Success = &retval
+  return nil
 }
-  if err2 = oprot.WriteMessageBegin("GetAddr", thrift.REPLY, seqId); err2 != nil {
-    err = err2
-  }
-  if err2 = result.Write(oprot); err == nil && err2 != nil {
-    err = err2
-  }
-  if err2 = oprot.WriteMessageEnd(); err == nil && err2 != nil {
-    err = err2
-  }
-  if err2 = oprot.Flush(); err == nil && err2 != nil {
-    err = err2
-  }
-  if err != nil {
-    return
+
+func (p *AddrTXServiceGetAddrBatchResult) Write(oprot thrift.TProtocol) error {
+  if err := oprot.WriteStructBegin("GetAddrBatch_result"); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err) }
+  if p != nil {
+    if err := p.writeField0(oprot); err != nil { return err }
+    if err := p.writeField1(oprot); err != nil { return err }
   }
-  return true, err
+  if err := oprot.WriteFieldStop(); err != nil {
+    return thrift.PrependError("write field stop error: ", err) }
+  if err := oprot.WriteStructEnd(); err != nil {
+    return thrift.PrependError("write struct stop error: ", err) }
+  return nil
 }
 
-type addrTXServiceProcessorGetTX struct {
-  handler AddrTXService
+func (p *AddrTXServiceGetAddrBatchResult) writeField0(oprot thrift.TProtocol) (err error) {
+  if p.IsSetSuccess() {
+    if err := oprot.WriteFieldBegin("success", thrift.MAP, 0); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 0:success: ", p), err) }
+    if err := oprot.WriteMapBegin(thrift.I64, thrift.STRING, len(p.Success)); err != nil {
+      return thrift.PrependError("error writing map begin: ", err)
+    }
+    for k, v := range p.Success {
+      if err := oprot.WriteI64(int64(k)); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err) }
+      if err := oprot.WriteString(string(v)); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err) }
+    }
+    if err := oprot.WriteMapEnd(); err != nil {
+      return thrift.PrependError("error writing map end: ", err)
+    }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 0:success: ", p), err) }
+  }
+  return err
 }
 
-func (p *addrTXServiceProcessorGetTX) Process(seqId int32, iprot, oprot thrift.TProtocol) (success bool, err thrift.TException) {
-  args := AddrTXServiceGetTXArgs{}
-  if err = args.Read(iprot); err != nil {
-    iprot.ReadMessageEnd()
-    x := thrift.NewTApplicationException(thrift.PROTOCOL_ERROR, err.Error())
-    oprot.WriteMessageBegin("GetTX", thrift.EXCEPTION, seqId)
-    x.Write(oprot)
-    oprot.WriteMessageEnd()
-    oprot.Flush()
-    return false, err
+func (p *AddrTXServiceGetAddrBatchResult) writeField1(oprot thrift.TProtocol) (err error) {
+  if p.IsSetErr() {
+    if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 1); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:err: ", p), err) }
+    if err := p.Err.Write(oprot); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Err), err)
+    }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 1:err: ", p), err) }
   }
-
-  iprot.ReadMessageEnd()
-  result := AddrTXServiceGetTXResult{}
-var retval string
-  var err2 error
-  if retval, err2 = p.handler.GetTX(args.Msg); err2 != nil {
-    x := thrift.NewTApplicationException(thrift.INTERNAL_ERROR, "Internal error processing GetTX: " + err2.Error())
-    oprot.WriteMessageBegin("GetTX", thrift.EXCEPTION, seqId)
-    x.Write(oprot)
-    oprot.WriteMessageEnd()
-    oprot.Flush()
-    return true, err2
-  } else {
-    result.Success = &retval
+  return err
 }
-  if err2 = oprot.WriteMessageBegin("GetTX", thrift.REPLY, seqId); err2 != nil {
-    err = err2
-  }
-  if err2 = result.Write(oprot); err == nil && err2 != nil {
-    err = err2
-  }
-  if err2 = oprot.WriteMessageEnd(); err == nil && err2 != nil {
-    err = err2
-  }
-  if err2 = oprot.Flush(); err == nil && err2 != nil {
-    err = err2
-  }
-  if err != nil {
-    return
+
+func (p *AddrTXServiceGetAddrBatchResult) String() string {
+  if p == nil {
+    return "<nil>"
   }
-  return true, err
+  return fmt.Sprintf("AddrTXServiceGetAddrBatchResult(%+v)", *p)
 }
 
-
-// HELPER FUNCTIONS AND STRUCTURES
-
 // Attributes:
 //  - Msg
-type AddrTXServiceGetAddrArgs struct {
-  Msg *GetAddrMsg `thrift:"msg,1" db:"msg" json:"msg"`
+type AddrTXServiceGetUIDByAddrArgs struct {
+  Msg *GetUIDByAddrMsg `thrift:"msg,1" db:"msg" json:"msg"`
 }
 
-func NewAddrTXServiceGetAddrArgs() *AddrTXServiceGetAddrArgs {
-  return &AddrTXServiceGetAddrArgs{}
+func NewAddrTXServiceGetUIDByAddrArgs() *AddrTXServiceGetUIDByAddrArgs {
+  return &AddrTXServiceGetUIDByAddrArgs{}
 }
 
-var AddrTXServiceGetAddrArgs_Msg_DEFAULT *GetAddrMsg
-func (p *AddrTXServiceGetAddrArgs) GetMsg() *GetAddrMsg {
+var AddrTXServiceGetUIDByAddrArgs_Msg_DEFAULT *GetUIDByAddrMsg
+func (p *AddrTXServiceGetUIDByAddrArgs) GetMsg() *GetUIDByAddrMsg {
   if !p.IsSetMsg() {
-    return AddrTXServiceGetAddrArgs_Msg_DEFAULT
+    return AddrTXServiceGetUIDByAddrArgs_Msg_DEFAULT
   }
 return p.Msg
 }
-func (p *AddrTXServiceGetAddrArgs) IsSetMsg() bool {
+func (p *AddrTXServiceGetUIDByAddrArgs) IsSetMsg() bool {
   return p.Msg != nil
 }
 
-func (p *AddrTXServiceGetAddrArgs) Read(iprot thrift.TProtocol) error {
+func (p *AddrTXServiceGetUIDByAddrArgs) Read(iprot thrift.TProtocol) error {
   if _, err := iprot.ReadStructBegin(); err != nil {
     return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
   }
@@ -802,16 +3461,16 @@ func (p *AddrTXServiceGetAddrArgs) Read(iprot thrift.TProtocol) error {
   return nil
 }
 
-func (p *AddrTXServiceGetAddrArgs)  ReadField1(iprot thrift.TProtocol) error {
-  p.Msg = &GetAddrMsg{}
+func (p *AddrTXServiceGetUIDByAddrArgs)  ReadField1(iprot thrift.TProtocol) error {
+  p.Msg = &GetUIDByAddrMsg{}
   if err := p.Msg.Read(iprot); err != nil {
     return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Msg), err)
   }
   return nil
 }
 
-func (p *AddrTXServiceGetAddrArgs) Write(oprot thrift.TProtocol) error {
-  if err := oprot.WriteStructBegin("GetAddr_args"); err != nil {
+func (p *AddrTXServiceGetUIDByAddrArgs) Write(oprot thrift.TProtocol) error {
+  if err := oprot.WriteStructBegin("GetUIDByAddr_args"); err != nil {
     return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err) }
   if p != nil {
     if err := p.writeField1(oprot); err != nil { return err }
@@ -823,7 +3482,7 @@ func (p *AddrTXServiceGetAddrArgs) Write(oprot thrift.TProtocol) error {
   return nil
 }
 
-func (p *AddrTXServiceGetAddrArgs) writeField1(oprot thrift.TProtocol) (err error) {
+func (p *AddrTXServiceGetUIDByAddrArgs) writeField1(oprot thrift.TProtocol) (err error) {
   if err := oprot.WriteFieldBegin("msg", thrift.STRUCT, 1); err != nil {
     return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:msg: ", p), err) }
   if err := p.Msg.Write(oprot); err != nil {
@@ -834,35 +3493,48 @@ func (p *AddrTXServiceGetAddrArgs) writeField1(oprot thrift.TProtocol) (err erro
   return err
 }
 
-func (p *AddrTXServiceGetAddrArgs) String() string {
+func (p *AddrTXServiceGetUIDByAddrArgs) String() string {
   if p == nil {
     return "<nil>"
   }
-  return fmt.Sprintf("AddrTXServiceGetAddrArgs(%+v)", *p)
+  return fmt.Sprintf("AddrTXServiceGetUIDByAddrArgs(%+v)", *p)
 }
 
 // Attributes:
 //  - Success
-type AddrTXServiceGetAddrResult struct {
-  Success *string `thrift:"success,0" db:"success" json:"success,omitempty"`
+//  - Err
+type AddrTXServiceGetUIDByAddrResult struct {
+  Success *int64 `thrift:"success,0" db:"success" json:"success,omitempty"`
+  Err *AddrTXException `thrift:"err,1" db:"err" json:"err,omitempty"`
 }
 
-func NewAddrTXServiceGetAddrResult() *AddrTXServiceGetAddrResult {
-  return &AddrTXServiceGetAddrResult{}
+func NewAddrTXServiceGetUIDByAddrResult() *AddrTXServiceGetUIDByAddrResult {
+  return &AddrTXServiceGetUIDByAddrResult{}
 }
 
-var AddrTXServiceGetAddrResult_Success_DEFAULT string
-func (p *AddrTXServiceGetAddrResult) GetSuccess() string {
+var AddrTXServiceGetUIDByAddrResult_Success_DEFAULT int64
+func (p *AddrTXServiceGetUIDByAddrResult) GetSuccess() int64 {
   if !p.IsSetSuccess() {
-    return AddrTXServiceGetAddrResult_Success_DEFAULT
+    return AddrTXServiceGetUIDByAddrResult_Success_DEFAULT
   }
 return *p.Success
 }
-func (p *AddrTXServiceGetAddrResult) IsSetSuccess() bool {
+var AddrTXServiceGetUIDByAddrResult_Err_DEFAULT *AddrTXException
+func (p *AddrTXServiceGetUIDByAddrResult) GetErr() *AddrTXException {
+  if !p.IsSetErr() {
+    return AddrTXServiceGetUIDByAddrResult_Err_DEFAULT
+  }
+return p.Err
+}
+func (p *AddrTXServiceGetUIDByAddrResult) IsSetSuccess() bool {
   return p.Success != nil
 }
 
-func (p *AddrTXServiceGetAddrResult) Read(iprot thrift.TProtocol) error {
+func (p *AddrTXServiceGetUIDByAddrResult) IsSetErr() bool {
+  return p.Err != nil
+}
+
+func (p *AddrTXServiceGetUIDByAddrResult) Read(iprot thrift.TProtocol) error {
   if _, err := iprot.ReadStructBegin(); err != nil {
     return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
   }
@@ -876,7 +3548,7 @@ func (p *AddrTXServiceGetAddrResult) Read(iprot thrift.TProtocol) error {
     if fieldTypeId == thrift.STOP { break; }
     switch fieldId {
     case 0:
-      if fieldTypeId == thrift.STRING {
+      if fieldTypeId == thrift.I64 {
         if err := p.ReadField0(iprot); err != nil {
           return err
         }
@@ -885,6 +3557,16 @@ func (p *AddrTXServiceGetAddrResult) Read(iprot thrift.TProtocol) error {
           return err
         }
       }
+    case 1:
+      if fieldTypeId == thrift.STRUCT {
+        if err := p.ReadField1(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
     default:
       if err := iprot.Skip(fieldTypeId); err != nil {
         return err
@@ -900,8 +3582,8 @@ func (p *AddrTXServiceGetAddrResult) Read(iprot thrift.TProtocol) error {
   return nil
 }
 
-func (p *AddrTXServiceGetAddrResult)  ReadField0(iprot thrift.TProtocol) error {
-  if v, err := iprot.ReadString(); err != nil {
+func (p *AddrTXServiceGetUIDByAddrResult)  ReadField0(iprot thrift.TProtocol) error {
+  if v, err := iprot.ReadI64(); err != nil {
   return thrift.PrependError("error reading field 0: ", err)
 } else {
   p.Success = &v
@@ -909,11 +3591,20 @@ func (p *AddrTXServiceGetAddrResult)  ReadField0(iprot thrift.TProtocol) error {
   return nil
 }
 
-func (p *AddrTXServiceGetAddrResult) Write(oprot thrift.TProtocol) error {
-  if err := oprot.WriteStructBegin("GetAddr_result"); err != nil {
+func (p *AddrTXServiceGetUIDByAddrResult)  ReadField1(iprot thrift.TProtocol) error {
+  p.Err = &AddrTXException{}
+  if err := p.Err.Read(iprot); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Err), err)
+  }
+  return nil
+}
+
+func (p *AddrTXServiceGetUIDByAddrResult) Write(oprot thrift.TProtocol) error {
+  if err := oprot.WriteStructBegin("GetUIDByAddr_result"); err != nil {
     return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err) }
   if p != nil {
     if err := p.writeField0(oprot); err != nil { return err }
+    if err := p.writeField1(oprot); err != nil { return err }
   }
   if err := oprot.WriteFieldStop(); err != nil {
     return thrift.PrependError("write field stop error: ", err) }
@@ -922,11 +3613,11 @@ func (p *AddrTXServiceGetAddrResult) Write(oprot thrift.TProtocol) error {
   return nil
 }
 
-func (p *AddrTXServiceGetAddrResult) writeField0(oprot thrift.TProtocol) (err error) {
+func (p *AddrTXServiceGetUIDByAddrResult) writeField0(oprot thrift.TProtocol) (err error) {
   if p.IsSetSuccess() {
-    if err := oprot.WriteFieldBegin("success", thrift.STRING, 0); err != nil {
+    if err := oprot.WriteFieldBegin("success", thrift.I64, 0); err != nil {
       return thrift.PrependError(fmt.Sprintf("%T write field begin error 0:success: ", p), err) }
-    if err := oprot.WriteString(string(*p.Success)); err != nil {
+    if err := oprot.WriteI64(int64(*p.Success)); err != nil {
     return thrift.PrependError(fmt.Sprintf("%T.success (0) field write error: ", p), err) }
     if err := oprot.WriteFieldEnd(); err != nil {
       return thrift.PrependError(fmt.Sprintf("%T write field end error 0:success: ", p), err) }
@@ -934,35 +3625,48 @@ func (p *AddrTXServiceGetAddrResult) writeField0(oprot thrift.TProtocol) (err er
   return err
 }
 
-func (p *AddrTXServiceGetAddrResult) String() string {
+func (p *AddrTXServiceGetUIDByAddrResult) writeField1(oprot thrift.TProtocol) (err error) {
+  if p.IsSetErr() {
+    if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 1); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:err: ", p), err) }
+    if err := p.Err.Write(oprot); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Err), err)
+    }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 1:err: ", p), err) }
+  }
+  return err
+}
+
+func (p *AddrTXServiceGetUIDByAddrResult) String() string {
   if p == nil {
     return "<nil>"
   }
-  return fmt.Sprintf("AddrTXServiceGetAddrResult(%+v)", *p)
+  return fmt.Sprintf("AddrTXServiceGetUIDByAddrResult(%+v)", *p)
 }
 
 // Attributes:
 //  - Msg
-type AddrTXServiceGetTXArgs struct {
-  Msg *GetTXMsg `thrift:"msg,1" db:"msg" json:"msg"`
+type AddrTXServiceFinalizeTXArgs struct {
+  Msg *FinalizeTXMsg `thrift:"msg,1" db:"msg" json:"msg"`
 }
 
-func NewAddrTXServiceGetTXArgs() *AddrTXServiceGetTXArgs {
-  return &AddrTXServiceGetTXArgs{}
+func NewAddrTXServiceFinalizeTXArgs() *AddrTXServiceFinalizeTXArgs {
+  return &AddrTXServiceFinalizeTXArgs{}
 }
 
-var AddrTXServiceGetTXArgs_Msg_DEFAULT *GetTXMsg
-func (p *AddrTXServiceGetTXArgs) GetMsg() *GetTXMsg {
+var AddrTXServiceFinalizeTXArgs_Msg_DEFAULT *FinalizeTXMsg
+func (p *AddrTXServiceFinalizeTXArgs) GetMsg() *FinalizeTXMsg {
   if !p.IsSetMsg() {
-    return AddrTXServiceGetTXArgs_Msg_DEFAULT
+    return AddrTXServiceFinalizeTXArgs_Msg_DEFAULT
   }
 return p.Msg
 }
-func (p *AddrTXServiceGetTXArgs) IsSetMsg() bool {
+func (p *AddrTXServiceFinalizeTXArgs) IsSetMsg() bool {
   return p.Msg != nil
 }
 
-func (p *AddrTXServiceGetTXArgs) Read(iprot thrift.TProtocol) error {
+func (p *AddrTXServiceFinalizeTXArgs) Read(iprot thrift.TProtocol) error {
   if _, err := iprot.ReadStructBegin(); err != nil {
     return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
   }
@@ -1000,16 +3704,16 @@ func (p *AddrTXServiceGetTXArgs) Read(iprot thrift.TProtocol) error {
   return nil
 }
 
-func (p *AddrTXServiceGetTXArgs)  ReadField1(iprot thrift.TProtocol) error {
-  p.Msg = &GetTXMsg{}
+func (p *AddrTXServiceFinalizeTXArgs)  ReadField1(iprot thrift.TProtocol) error {
+  p.Msg = &FinalizeTXMsg{}
   if err := p.Msg.Read(iprot); err != nil {
     return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Msg), err)
   }
   return nil
 }
 
-func (p *AddrTXServiceGetTXArgs) Write(oprot thrift.TProtocol) error {
-  if err := oprot.WriteStructBegin("GetTX_args"); err != nil {
+func (p *AddrTXServiceFinalizeTXArgs) Write(oprot thrift.TProtocol) error {
+  if err := oprot.WriteStructBegin("FinalizeTX_args"); err != nil {
     return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err) }
   if p != nil {
     if err := p.writeField1(oprot); err != nil { return err }
@@ -1021,7 +3725,7 @@ func (p *AddrTXServiceGetTXArgs) Write(oprot thrift.TProtocol) error {
   return nil
 }
 
-func (p *AddrTXServiceGetTXArgs) writeField1(oprot thrift.TProtocol) (err error) {
+func (p *AddrTXServiceFinalizeTXArgs) writeField1(oprot thrift.TProtocol) (err error) {
   if err := oprot.WriteFieldBegin("msg", thrift.STRUCT, 1); err != nil {
     return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:msg: ", p), err) }
   if err := p.Msg.Write(oprot); err != nil {
@@ -1032,35 +3736,48 @@ func (p *AddrTXServiceGetTXArgs) writeField1(oprot thrift.TProtocol) (err error)
   return err
 }
 
-func (p *AddrTXServiceGetTXArgs) String() string {
+func (p *AddrTXServiceFinalizeTXArgs) String() string {
   if p == nil {
     return "<nil>"
   }
-  return fmt.Sprintf("AddrTXServiceGetTXArgs(%+v)", *p)
+  return fmt.Sprintf("AddrTXServiceFinalizeTXArgs(%+v)", *p)
 }
 
 // Attributes:
 //  - Success
-type AddrTXServiceGetTXResult struct {
+//  - Err
+type AddrTXServiceFinalizeTXResult struct {
   Success *string `thrift:"success,0" db:"success" json:"success,omitempty"`
+  Err *AddrTXException `thrift:"err,1" db:"err" json:"err,omitempty"`
 }
 
-func NewAddrTXServiceGetTXResult() *AddrTXServiceGetTXResult {
-  return &AddrTXServiceGetTXResult{}
+func NewAddrTXServiceFinalizeTXResult() *AddrTXServiceFinalizeTXResult {
+  return &AddrTXServiceFinalizeTXResult{}
 }
 
-var AddrTXServiceGetTXResult_Success_DEFAULT string
-func (p *AddrTXServiceGetTXResult) GetSuccess() string {
+var AddrTXServiceFinalizeTXResult_Success_DEFAULT string
+func (p *AddrTXServiceFinalizeTXResult) GetSuccess() string {
   if !p.IsSetSuccess() {
-    return AddrTXServiceGetTXResult_Success_DEFAULT
+    return AddrTXServiceFinalizeTXResult_Success_DEFAULT
   }
 return *p.Success
 }
-func (p *AddrTXServiceGetTXResult) IsSetSuccess() bool {
+var AddrTXServiceFinalizeTXResult_Err_DEFAULT *AddrTXException
+func (p *AddrTXServiceFinalizeTXResult) GetErr() *AddrTXException {
+  if !p.IsSetErr() {
+    return AddrTXServiceFinalizeTXResult_Err_DEFAULT
+  }
+return p.Err
+}
+func (p *AddrTXServiceFinalizeTXResult) IsSetSuccess() bool {
   return p.Success != nil
 }
 
-func (p *AddrTXServiceGetTXResult) Read(iprot thrift.TProtocol) error {
+func (p *AddrTXServiceFinalizeTXResult) IsSetErr() bool {
+  return p.Err != nil
+}
+
+func (p *AddrTXServiceFinalizeTXResult) Read(iprot thrift.TProtocol) error {
   if _, err := iprot.ReadStructBegin(); err != nil {
     return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
   }
@@ -1083,6 +3800,16 @@ func (p *AddrTXServiceGetTXResult) Read(iprot thrift.TProtocol) error {
           return err
         }
       }
+    case 1:
+      if fieldTypeId == thrift.STRUCT {
+        if err := p.ReadField1(iprot); err != nil {
+          return err
+        }
+      } else {
+        if err := iprot.Skip(fieldTypeId); err != nil {
+          return err
+        }
+      }
     default:
       if err := iprot.Skip(fieldTypeId); err != nil {
         return err
@@ -1098,7 +3825,7 @@ func (p *AddrTXServiceGetTXResult) Read(iprot thrift.TProtocol) error {
   return nil
 }
 
-func (p *AddrTXServiceGetTXResult)  ReadField0(iprot thrift.TProtocol) error {
+func (p *AddrTXServiceFinalizeTXResult)  ReadField0(iprot thrift.TProtocol) error {
   if v, err := iprot.ReadString(); err != nil {
   return thrift.PrependError("error reading field 0: ", err)
 } else {
@@ -1107,11 +3834,20 @@ func (p *AddrTXServiceGetTXResult)  ReadField0(iprot thrift.TProtocol) error {
   return nil
 }
 
-func (p *AddrTXServiceGetTXResult) Write(oprot thrift.TProtocol) error {
-  if err := oprot.WriteStructBegin("GetTX_result"); err != nil {
+func (p *AddrTXServiceFinalizeTXResult)  ReadField1(iprot thrift.TProtocol) error {
+  p.Err = &AddrTXException{}
+  if err := p.Err.Read(iprot); err != nil {
+    return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Err), err)
+  }
+  return nil
+}
+
+func (p *AddrTXServiceFinalizeTXResult) Write(oprot thrift.TProtocol) error {
+  if err := oprot.WriteStructBegin("FinalizeTX_result"); err != nil {
     return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err) }
   if p != nil {
     if err := p.writeField0(oprot); err != nil { return err }
+    if err := p.writeField1(oprot); err != nil { return err }
   }
   if err := oprot.WriteFieldStop(); err != nil {
     return thrift.PrependError("write field stop error: ", err) }
@@ -1120,7 +3856,7 @@ func (p *AddrTXServiceGetTXResult) Write(oprot thrift.TProtocol) error {
   return nil
 }
 
-func (p *AddrTXServiceGetTXResult) writeField0(oprot thrift.TProtocol) (err error) {
+func (p *AddrTXServiceFinalizeTXResult) writeField0(oprot thrift.TProtocol) (err error) {
   if p.IsSetSuccess() {
     if err := oprot.WriteFieldBegin("success", thrift.STRING, 0); err != nil {
       return thrift.PrependError(fmt.Sprintf("%T write field begin error 0:success: ", p), err) }
@@ -1132,11 +3868,24 @@ func (p *AddrTXServiceGetTXResult) writeField0(oprot thrift.TProtocol) (err erro
   return err
 }
 
-func (p *AddrTXServiceGetTXResult) String() string {
+func (p *AddrTXServiceFinalizeTXResult) writeField1(oprot thrift.TProtocol) (err error) {
+  if p.IsSetErr() {
+    if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 1); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:err: ", p), err) }
+    if err := p.Err.Write(oprot); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Err), err)
+    }
+    if err := oprot.WriteFieldEnd(); err != nil {
+      return thrift.PrependError(fmt.Sprintf("%T write field end error 1:err: ", p), err) }
+  }
+  return err
+}
+
+func (p *AddrTXServiceFinalizeTXResult) String() string {
   if p == nil {
     return "<nil>"
   }
-  return fmt.Sprintf("AddrTXServiceGetTXResult(%+v)", *p)
+  return fmt.Sprintf("AddrTXServiceFinalizeTXResult(%+v)", *p)
 }
 
 
@@ -23,6 +23,9 @@ func Usage() {
   fmt.Fprintln(os.Stderr, "\nFunctions:")
   fmt.Fprintln(os.Stderr, "  string GetAddr(GetAddrMsg msg)")
   fmt.Fprintln(os.Stderr, "  string GetTX(GetTXMsg msg)")
+  fmt.Fprintln(os.Stderr, "  map<i64, string> GetAddrBatch(GetAddrBatchMsg msg)")
+  fmt.Fprintln(os.Stderr, "  i64 GetUIDByAddr(GetUIDByAddrMsg msg)")
+  fmt.Fprintln(os.Stderr, "  string FinalizeTX(FinalizeTXMsg msg)")
   fmt.Fprintln(os.Stderr)
   os.Exit(0)
 }
@@ -122,19 +125,19 @@ func main() {
       fmt.Fprintln(os.Stderr, "GetAddr requires 1 args")
       flag.Usage()
     }
-    arg6 := flag.Arg(1)
-    mbTrans7 := thrift.NewTMemoryBufferLen(len(arg6))
-    defer mbTrans7.Close()
-    _, err8 := mbTrans7.WriteString(arg6)
-    if err8 != nil {
+    arg14 := flag.Arg(1)
+    mbTrans15 := thrift.NewTMemoryBufferLen(len(arg14))
+    defer mbTrans15.Close()
+    _, err16 := mbTrans15.WriteString(arg14)
+    if err16 != nil {
       Usage()
       return
     }
-    factory9 := thrift.NewTSimpleJSONProtocolFactory()
-    jsProt10 := factory9.GetProtocol(mbTrans7)
+    factory17 := thrift.NewTSimpleJSONProtocolFactory()
+    jsProt18 := factory17.GetProtocol(mbTrans15)
     argvalue0 := addrtx.NewGetAddrMsg()
-    err11 := argvalue0.Read(jsProt10)
-    if err11 != nil {
+    err19 := argvalue0.Read(jsProt18)
+    if err19 != nil {
       Usage()
       return
     }
@@ -147,19 +150,19 @@ func main() {
       fmt.Fprintln(os.Stderr, "GetTX requires 1 args")
       flag.Usage()
     }
-    arg12 := flag.Arg(1)
-    mbTrans13 := thrift.NewTMemoryBufferLen(len(arg12))
-    defer mbTrans13.Close()
-    _, err14 := mbTrans13.WriteString(arg12)
-    if err14 != nil {
+    arg20 := flag.Arg(1)
+    mbTrans21 := thrift.NewTMemoryBufferLen(len(arg20))
+    defer mbTrans21.Close()
+    _, err22 := mbTrans21.WriteString(arg20)
+    if err22 != nil {
       Usage()
       return
     }
-    factory15 := thrift.NewTSimpleJSONProtocolFactory()
-    jsProt16 := factory15.GetProtocol(mbTrans13)
+    factory23 := thrift.NewTSimpleJSONProtocolFactory()
+    jsProt24 := factory23.GetProtocol(mbTrans21)
     argvalue0 := addrtx.NewGetTXMsg()
-    err17 := argvalue0.Read(jsProt16)
-    if err17 != nil {
+    err25 := argvalue0.Read(jsProt24)
+    if err25 != nil {
       Usage()
       return
     }
@@ -167,6 +170,81 @@ func main() {
     fmt.Print(client.GetTX(value0))
     fmt.Print("\n")
     break
+  case "GetAddrBatch":
+    if flag.NArg() - 1 != 1 {
+      fmt.Fprintln(os.Stderr, "GetAddrBatch requires 1 args")
+      flag.Usage()
+    }
+    arg26 := flag.Arg(1)
+    mbTrans27 := thrift.NewTMemoryBufferLen(len(arg26))
+    defer mbTrans27.Close()
+    _, err28 := mbTrans27.WriteString(arg26)
+    if err28 != nil {
+      Usage()
+      return
+    }
+    factory29 := thrift.NewTSimpleJSONProtocolFactory()
+    jsProt30 := factory29.GetProtocol(mbTrans27)
+    argvalue0 := addrtx.NewGetAddrBatchMsg()
+    err31 := argvalue0.Read(jsProt30)
+    if err31 != nil {
+      Usage()
+      return
+    }
+    value0 := argvalue0
+    fmt.Print(client.GetAddrBatch(value0))
+    fmt.Print("\n")
+    break
+  case "GetUIDByAddr":
+    if flag.NArg() - 1 != 1 {
+      fmt.Fprintln(os.Stderr, "GetUIDByAddr requires 1 args")
+      flag.Usage()
+    }
+    arg32 := flag.Arg(1)
+    mbTrans33 := thrift.NewTMemoryBufferLen(len(arg32))
+    defer mbTrans33.Close()
+    _, err34 := mbTrans33.WriteString(arg32)
+    if err34 != nil {
+      Usage()
+      return
+    }
+    factory35 := thrift.NewTSimpleJSONProtocolFactory()
+    jsProt36 := factory35.GetProtocol(mbTrans33)
+    argvalue0 := addrtx.NewGetUIDByAddrMsg()
+    err37 := argvalue0.Read(jsProt36)
+    if err37 != nil {
+      Usage()
+      return
+    }
+    value0 := argvalue0
+    fmt.Print(client.GetUIDByAddr(value0))
+    fmt.Print("\n")
+    break
+  case "FinalizeTX":
+    if flag.NArg() - 1 != 1 {
+      fmt.Fprintln(os.Stderr, "FinalizeTX requires 1 args")
+      flag.Usage()
+    }
+    arg38 := flag.Arg(1)
+    mbTrans39 := thrift.NewTMemoryBufferLen(len(arg38))
+    defer mbTrans39.Close()
+    _, err40 := mbTrans39.WriteString(arg38)
+    if err40 != nil {
+      Usage()
+      return
+    }
+    factory41 := thrift.NewTSimpleJSONProtocolFactory()
+    jsProt42 := factory41.GetProtocol(mbTrans39)
+    argvalue0 := addrtx.NewFinalizeTXMsg()
+    err43 := argvalue0.Read(jsProt42)
+    if err43 != nil {
+      Usage()
+      return
+    }
+    value0 := argvalue0
+    fmt.Print(client.FinalizeTX(value0))
+    fmt.Print("\n")
+    break
   case "":
     Usage()
     break
@@ -1,26 +1,33 @@
 package main
 
 import (
-	"crypto/sha256"
+	"bytes"
 	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"math/big"
+	"strconv"
+	"strings"
 
 	"github.com/GameLeLe/trade-addr-tx-service/base58check"
+	"github.com/GameLeLe/trade-addr-tx-service/bech32"
+	"github.com/GameLeLe/trade-addr-tx-service/btc"
 	"github.com/GameLeLe/trade-addr-tx-service/eth"
 	"github.com/GameLeLe/trade-addr-tx-service/hdwallet"
+	addrtx "github.com/GameLeLe/trade-addr-tx-service/thrift/addrtx"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
-	"golang.org/x/crypto/ripemd160"
 )
 
+//genETHAddr returns the EIP-55 checksummed address of compressedKey
 func genETHAddr(compressedKey []byte) string {
 	x, y := hdwallet.Expand(compressedKey)
 	four, _ := hex.DecodeString("04")
 	paddedKey := append(four, append(x.Bytes(), y.Bytes()...)...)
 	pubKey := crypto.ToECDSAPub(paddedKey)
 	addr := crypto.PubkeyToAddress(*pubKey)
-	return hex.EncodeToString(addr[:])
+	return eth.ChecksumAddress(addr[:])
 }
 func genBTCAddr(compressedKey []byte, isTestnet bool) string {
 	var publicKeyPrefix byte
@@ -29,32 +36,299 @@ func genBTCAddr(compressedKey []byte, isTestnet bool) string {
 	} else {
 		publicKeyPrefix = 0x00
 	}
-	shadPublicKeyBytes := sha256.Sum256(compressedKey)
-	ripeHash := ripemd160.New()
-	ripeHash.Write(shadPublicKeyBytes[:])
-	ripeHashedBytes := ripeHash.Sum(nil)
+	address := base58check.Encode(publicKeyPrefix, btc.Hash160(compressedKey))
+	return address
+}
 
-	address := base58check.Encode(publicKeyPrefix, ripeHashedBytes)
+//genP2WPKHAddr returns the bech32 native segwit address of compressedKey
+func genP2WPKHAddr(compressedKey []byte, isTestnet bool) string {
+	hrp := "bc"
+	if isTestnet {
+		hrp = "tb"
+	}
+	//a 20 byte v0 program always encodes
+	address, _ := bech32.EncodeSegwitAddress(hrp, 0, btc.Hash160(compressedKey))
 	return address
 }
 
-func getETHTX(fromPubKey []byte, toPubKey []byte, amount int64) string {
+//genP2SHP2WPKHAddr returns the base58 nested segwit address of compressedKey,
+//P2SH of its P2WPKH redeem script
+func genP2SHP2WPKHAddr(compressedKey []byte, isTestnet bool) string {
+	var scriptHashPrefix byte
+	if isTestnet {
+		scriptHashPrefix = 0xC4
+	} else {
+		scriptHashPrefix = 0x05
+	}
+	redeemScript := btc.P2WPKHRedeemScript(btc.Hash160(compressedKey))
+	return base58check.Encode(scriptHashPrefix, btc.Hash160(redeemScript))
+}
+
+//genP2TRAddr returns the bech32m taproot address of compressedKey as BIP-86
+//internal key, spendable by key path only
+func genP2TRAddr(compressedKey []byte, isTestnet bool) (string, error) {
+	hrp := "bc"
+	if isTestnet {
+		hrp = "tb"
+	}
+	outputKey, err := btc.TaprootOutputKey(compressedKey)
+	if err != nil {
+		return "", err
+	}
+	return bech32.EncodeSegwitAddress(hrp, 1, outputKey)
+}
+
+//genBTCTypedAddr returns the mainnet address of addrType paying to compressedKey
+func genBTCTypedAddr(compressedKey []byte, addrType addrtx.BTCAddrType) (string, error) {
+	switch addrType {
+	case addrtx.BTCAddrType_P2PKH:
+		return genBTCAddr(compressedKey, false), nil
+	case addrtx.BTCAddrType_P2WPKH:
+		return genP2WPKHAddr(compressedKey, false), nil
+	case addrtx.BTCAddrType_P2SH_P2WPKH:
+		return genP2SHP2WPKHAddr(compressedKey, false), nil
+	case addrtx.BTCAddrType_P2TR:
+		addr, err := genP2TRAddr(compressedKey, false)
+		if err != nil {
+			return "", newAddrTXError(addrtx.ErrorCode_DERIVATION_FAILED, "tweak taproot key: %v", err)
+		}
+		return addr, nil
+	default:
+		return "", newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "btc address type %v not supported", addrType)
+	}
+}
+
+//parseBIP32Path returns the child indexes of a path like m/84'/0'/0'/0,
+//hardened by a ' or h suffix
+func parseBIP32Path(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("bip32 path %q does not start at m", path)
+	}
+	var indexes []uint32
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+		i, err := strconv.ParseUint(part, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("bip32 path %q: invalid index %q", path, part)
+		}
+		if hardened {
+			i += 1 << 31
+		}
+		indexes = append(indexes, uint32(i))
+	}
+	return indexes, nil
+}
+
+//getETHTX returns the hex encoded json of an eth.UnsignedTX, replay protected
+//by chainID. It is an EIP-1559 dynamic fee tx unless fee is a legacy gas price.
+//A non nil token makes it a transfer of amount of the token limited to gas
+func getETHTX(fromPubKey []byte, toPubKey []byte, amount int64, nonce uint64, chainID *big.Int, fee *eth.Fee, token *eth.Token, gas uint64) (string, error) {
+	if amount < 0 {
+		return "", newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "amount must not be negative")
+	}
+	if chainID == nil || chainID.Sign() <= 0 {
+		return "", newAddrTXError(addrtx.ErrorCode_INTERNAL_ERROR, "eth chain id not configured")
+	}
 	toAddr := common.HexToAddress(genETHAddr(toPubKey))
 	var totalAmount *big.Int
-	var nonce uint64
 	totalAmount = new(big.Int)
 	totalAmount.SetInt64(amount)
-	//TODO how to get next nonce of from account?
-	nonce = 1
-
-	gasLimit := new(big.Int)
-	gasPrice := new(big.Int)
-	gasLimit.SetInt64(eth.DefaultGasLimit)
-	gasPrice.SetInt64(eth.DefaultGasPrice)
-
-	tx := types.NewTransaction(nonce, toAddr, totalAmount, gasLimit, gasPrice, nil)
-	//tx.WithSignature
-	//TODO error need to be catched
-	jsonStr, _ := tx.MarshalJSON()
-	return hex.EncodeToString(jsonStr)
+
+	//a token transfer sends no ether, it calls transfer on the contract
+	recipient, value := toAddr, totalAmount
+	var data []byte
+	if token != nil {
+		var err error
+		data, err = token.TransferData(toAddr, totalAmount)
+		if err != nil {
+			return "", newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "%v", err)
+		}
+		recipient, value = token.Contract, new(big.Int)
+	}
+
+	var unsigned *eth.UnsignedTX
+	if fee.IsDynamic() {
+		tx := eth.NewDynamicFeeTX(chainID, nonce, &recipient, value, gas, fee, data)
+		unsigned = eth.NewUnsignedDynamicFeeTX(tx)
+	} else {
+		gasLimit := new(big.Int)
+		gasLimit.SetUint64(gas)
+		tx := types.NewTransaction(nonce, recipient, value, gasLimit, fee.GasPrice, data)
+		unsigned = eth.NewUnsignedTX(tx, chainID)
+	}
+	if token != nil {
+		unsigned.Token = token.NewTokenTransfer(toAddr, totalAmount)
+	}
+	jsonStr, err := json.Marshal(unsigned)
+	if err != nil {
+		return "", newAddrTXError(addrtx.ErrorCode_INTERNAL_ERROR, "marshal eth tx: %v", err)
+	}
+	return hex.EncodeToString(jsonStr), nil
+}
+
+//getBTCTX builds an unsigned tx paying amount from fromAddr, the address of
+//fromKey, to toAddr with the utxos chosen by selector and reserves them in
+//utxoCache, so concurrent calls do not spend them again until the reservation
+//expires. The fee is feeRate satoshi per virtual byte of the signed tx,
+//change is what is left. The tx is returned as a base64 PSBT of psbtVersion,
+//see newBTCPSBT.
+func getBTCTX(utxoCache *btc.UTXOCache, txs btc.RawTXGetter, selector btc.CoinSelector, feeRate uint64, fromKey *btc.KeyDerivation, fromAddr, toAddr string, amount int64, psbtVersion uint32) (string, error) {
+	if amount <= 0 {
+		return "", newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "amount must be positive")
+	}
+	totalAmount := uint64(amount)
+	fromPub, err := btc.GetPublicKey(fromKey.PubKey, false)
+	if err != nil {
+		return "", newAddrTXError(addrtx.ErrorCode_DERIVATION_FAILED, "parse public key of %s: %v", fromAddr, err)
+	}
+	toScript, err := btc.AddressScript(toAddr)
+	if err != nil {
+		return "", newAddrTXError(addrtx.ErrorCode_INTERNAL_ERROR, "create script of %s: %v", toAddr, err)
+	}
+	//change goes back to the sender
+	changeScript, err := btc.AddressScript(fromAddr)
+	if err != nil {
+		return "", newAddrTXError(addrtx.ErrorCode_INTERNAL_ERROR, "create script of %s: %v", fromAddr, err)
+	}
+	fee := &btc.TXFee{Rate: feeRate, Outputs: [][]byte{toScript}, Change: changeScript}
+
+	var selection *btc.Selection
+	var selectErr error
+	_, err = utxoCache.Spend(fromAddr, &btc.Key{Pub: fromPub}, func(utxos btc.UTXOs) (btc.UTXOs, error) {
+		selection, selectErr = selector.Select(utxos, totalAmount, fee)
+		if selectErr != nil {
+			return nil, nil
+		}
+		return selection.UTXOs, nil
+	})
+	if err != nil {
+		return "", newAddrTXError(addrtx.ErrorCode_UPSTREAM_UNAVAILABLE, "get utxo of %s from %s: %v", fromAddr, utxoCache.GetServiceName(), err)
+	}
+	if e, ok := selectErr.(*btc.InsufficientFundsError); ok {
+		return "", newAddrTXError(addrtx.ErrorCode_INSUFFICIENT_FUNDS, "insufficient funds in %s: have %d, need %d", fromAddr, e.Have, e.Need)
+	}
+	if selectErr != nil {
+		return "", newAddrTXError(addrtx.ErrorCode_INTERNAL_ERROR, "select utxos of %s: %v", fromAddr, selectErr)
+	}
+
+	//the only P2SH addresses issued are P2SH-P2WPKH
+	var redeemScript []byte
+	if btc.GetScriptType(changeScript) == btc.ScriptP2SH {
+		redeemScript = btc.P2WPKHRedeemScript(btc.Hash160(fromKey.PubKey))
+	}
+	tx := btc.TX{}
+	for _, utxo := range selection.UTXOs {
+		txin := &btc.TXin{}
+		txin.Hash = utxo.Hash
+		txin.Index = utxo.Index
+		txin.Sequence = uint32(0xffffffff)
+		txin.PrevScriptPubkey = utxo.Script
+		txin.Amount = utxo.Amount
+		txin.RedeemScript = redeemScript
+		tx.Txin = append(tx.Txin, txin)
+	}
+	tx.Txout = append(tx.Txout, &btc.TXout{Value: totalAmount, ScriptPubkey: toScript})
+	if selection.Change > 0 {
+		tx.Txout = append(tx.Txout, &btc.TXout{Value: selection.Change, ScriptPubkey: changeScript})
+	}
+	psbt, err := newBTCPSBT(&tx, txs, fromKey, psbtVersion)
+	if err != nil {
+		utxoCache.Release(selection.UTXOs)
+		return "", err
+	}
+	return psbt, nil
+}
+
+//finalizeBTCTX combines psbts, base64 PSBTs of one tx signed by different
+//signers, and returns the signed tx once all its inputs can be finalized
+func finalizeBTCTX(psbts []string) ([]byte, error) {
+	if len(psbts) == 0 {
+		return nil, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "no psbt to finalize")
+	}
+	var combined *btc.PSBT
+	for i, s := range psbts {
+		p, err := btc.ParsePSBTBase64(s)
+		if err != nil {
+			return nil, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "parse psbt %d: %v", i, err)
+		}
+		if combined == nil {
+			combined = p
+		} else if err := combined.Combine(p); err != nil {
+			return nil, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "combine psbt %d: %v", i, err)
+		}
+	}
+	if err := combined.Finalize(); err != nil {
+		return nil, newAddrTXError(addrtx.ErrorCode_INVALID_ARGUMENT, "finalize btc tx: %v", err)
+	}
+	rawtx, err := combined.Extract()
+	if err != nil {
+		return nil, newAddrTXError(addrtx.ErrorCode_INTERNAL_ERROR, "extract btc tx: %v", err)
+	}
+	return rawtx, nil
+}
+
+//newBTCPSBT returns tx as a base64 PSBT signers need no other context for:
+//every input has the previous tx fetched from txs, taproot ones excepted
+//since their sighash commits to all spent amounts, and segwit ones also the
+//spent output. Inputs and change, which pay fromKey, get its origin. The PSBT
+//is of version 0 or 2 as psbtVersion says.
+func newBTCPSBT(tx *btc.TX, txs btc.RawTXGetter, fromKey *btc.KeyDerivation, psbtVersion uint32) (string, error) {
+	psbt, err := btc.NewPSBT(tx)
+	if err != nil {
+		return "", newAddrTXError(addrtx.ErrorCode_INTERNAL_ERROR, "make btc psbt: %v", err)
+	}
+	prevTXs := make(map[string][]byte)
+	for i, in := range psbt.TX.Txin {
+		if err := psbt.SetInputKey(i, fromKey); err != nil {
+			return "", newAddrTXError(addrtx.ErrorCode_INTERNAL_ERROR, "set key of btc input: %v", err)
+		}
+		if btc.GetScriptType(in.PrevScriptPubkey) == btc.ScriptP2TR {
+			continue
+		}
+		//segwit v0 signers only need the witness utxo, hardware wallets still
+		//check it against the previous tx when the backend has it, like
+		//bitcoind without -txindex may not
+		segwit := psbt.Inputs[i].WitnessUTXO != nil
+		if txs == nil {
+			if segwit {
+				continue
+			}
+			return "", newAddrTXError(addrtx.ErrorCode_INTERNAL_ERROR, "btc backend can not get the previous tx %x", in.Hash)
+		}
+		prevTX, ok := prevTXs[string(in.Hash)]
+		if !ok {
+			if prevTX, err = txs.GetRawTX(in.Hash); err != nil && !segwit {
+				return "", newAddrTXError(addrtx.ErrorCode_UPSTREAM_UNAVAILABLE, "get btc tx %x: %v", in.Hash, err)
+			}
+			prevTXs[string(in.Hash)] = prevTX
+		}
+		if prevTX == nil {
+			continue
+		}
+		if err := psbt.SetNonWitnessUTXO(i, prevTX); err != nil {
+			return "", newAddrTXError(addrtx.ErrorCode_UPSTREAM_UNAVAILABLE, "previous btc tx: %v", err)
+		}
+	}
+	//the inputs all spend the script of the sender, outputs paying it are change
+	for i, out := range psbt.TX.Txout {
+		if bytes.Equal(out.ScriptPubkey, psbt.TX.Txin[0].PrevScriptPubkey) {
+			if err := psbt.SetOutputKey(i, fromKey); err != nil {
+				return "", newAddrTXError(addrtx.ErrorCode_INTERNAL_ERROR, "set key of btc change: %v", err)
+			}
+		}
+	}
+	//offline signers sign the hashes without computing them
+	if err := psbt.SetSigHashes(); err != nil {
+		return "", newAddrTXError(addrtx.ErrorCode_INTERNAL_ERROR, "btc sighashes: %v", err)
+	}
+	psbt.Version = psbtVersion
+	s, err := psbt.Base64()
+	if err != nil {
+		return "", newAddrTXError(addrtx.ErrorCode_INTERNAL_ERROR, "encode btc psbt: %v", err)
+	}
+	return s, nil
 }
@@ -1,13 +1,18 @@
 package main
 
 import (
+	"bytes"
+	"encoding/hex"
 	"fmt"
 	"net"
+	"reflect"
 	"strings"
 	"testing"
 
 	bip39 "github.com/GameLeLe/trade-addr-tx-service/bip39"
+	btc "github.com/GameLeLe/trade-addr-tx-service/btc"
 	hdwallet "github.com/GameLeLe/trade-addr-tx-service/hdwallet"
+	addrtx "github.com/GameLeLe/trade-addr-tx-service/thrift/addrtx"
 )
 
 func TestBTCAddrBIP32(t *testing.T) {
@@ -46,6 +51,75 @@ func TestBTCAddrBIP32(t *testing.T) {
 	}
 }
 
+func TestBTCAddrBIP84(t *testing.T) {
+	//test vectors from BIP-84
+	seed := bip39.NewSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	account := hdwallet.MasterKey(seed)
+	for _, i := range []uint32{84, 0, 0} {
+		account, _ = account.Child(i + 1<<31)
+	}
+	cases := []struct {
+		path     []uint32
+		expected string
+	}{
+		{[]uint32{0, 0}, "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"},
+		{[]uint32{0, 1}, "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g"},
+		{[]uint32{1, 0}, "bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el"},
+	}
+	for _, c := range cases {
+		child := account.Pub()
+		for _, i := range c.path {
+			child, _ = child.Child(i)
+		}
+		if addr := genP2WPKHAddr(child.Key, false); addr != c.expected {
+			t.Errorf("BTC p2wpkh addr not matched: %s|%s", addr, c.expected)
+		}
+	}
+	//the testnet address of the same key
+	if addr := genP2WPKHAddr(account.Pub().Key, true); addr[:3] != "tb1" {
+		t.Errorf("testnet p2wpkh addr should start with tb1: %s", addr)
+	}
+}
+
+func TestBTCAddrBIP49(t *testing.T) {
+	//test vector from BIP-49, the key of m/49'/1'/0'/0/0 on testnet
+	pubKey, _ := hex.DecodeString("03a1af804ac108a8a51782198c2d034b28bf90c8803f5a53f76276fa69a4eae77f")
+	if addr := genP2SHP2WPKHAddr(pubKey, true); addr != "2Mww8dCYPUpKHofjgcXcBCEGmniw9CoaiD2" {
+		t.Errorf("BTC p2sh-p2wpkh addr not matched: %s", addr)
+	}
+	redeemScript := btc.P2WPKHRedeemScript(btc.Hash160(pubKey))
+	if hex.EncodeToString(redeemScript) != "001438971f73930f6c141d977ac4fd4a727c854935b3" {
+		t.Errorf("redeem script not matched: %x", redeemScript)
+	}
+}
+
+func TestBTCAddrBIP86(t *testing.T) {
+	//test vectors from BIP-86
+	seed := bip39.NewSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	account := hdwallet.MasterKey(seed)
+	for _, i := range []uint32{86, 0, 0} {
+		account, _ = account.Child(i + 1<<31)
+	}
+	cases := []struct {
+		path     []uint32
+		expected string
+	}{
+		{[]uint32{0, 0}, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"},
+		{[]uint32{0, 1}, "bc1p4qhjn9zdvkux4e44uhx8tc55attvtyu358kutcqkudyccelu0was9fqzwh"},
+		{[]uint32{1, 0}, "bc1p3qkhfews2uk44qtvauqyr2ttdsw7svhkl9nkm9s9c3x4ax5h60wqwruhk7"},
+	}
+	for _, c := range cases {
+		child := account.Pub()
+		for _, i := range c.path {
+			child, _ = child.Child(i)
+		}
+		addr, err := genP2TRAddr(child.Key, false)
+		if err != nil || addr != c.expected {
+			t.Errorf("BTC p2tr addr not matched: %s|%s %v", addr, c.expected, err)
+		}
+	}
+}
+
 func TestETHAddrBIP32(t *testing.T) {
 	seed := getSeed()
 	masterprv := hdwallet.MasterKey(seed)
@@ -70,12 +144,12 @@ func TestETHAddrBIP32(t *testing.T) {
 	}
 	for _, c := range cases {
 		uid := c.uid
-		expectedAddr := strings.ToLower(c.expected)
+		expectedAddr := c.expected
 		childpubUID, err := childpub0.Child(uint32(uid))
 		if err != nil {
 			t.Errorf("get uid related pub key error: %v", err)
 		}
-		addr := strings.ToLower("0x" + genETHAddr(childpubUID.Pub().Key))
+		addr := genETHAddr(childpubUID.Pub().Key)
 		if addr != expectedAddr {
 			t.Errorf("ETH addr not matched: %s|%s", addr, expectedAddr)
 		}
@@ -169,12 +243,12 @@ func TestETHAddrBIP44(t *testing.T) {
 	}
 	for _, c := range cases {
 		uid := c.uid
-		expectedAddr := strings.ToLower(c.expected)
+		expectedAddr := c.expected
 		childpubUID, err := masterpub.Child(uint32(uid))
 		if err != nil {
 			t.Errorf("get uid related pub key error: %v", err)
 		}
-		addr := strings.ToLower("0x" + genETHAddr(childpubUID.Pub().Key))
+		addr := genETHAddr(childpubUID.Pub().Key)
 		if addr != expectedAddr {
 			t.Errorf("ETH addr not matched: %s|%s", addr, expectedAddr)
 		}
@@ -203,3 +277,196 @@ func TestSocket(t *testing.T) {
 	}
 	fmt.Println("break loop")
 }
+
+type fakeBTCService struct {
+	utxos btc.UTXOs
+	//txs are the raw txs by hex txid
+	txs map[string][]byte
+	//sent are the txs broadcast, sendErr fails broadcasts
+	sent    [][]byte
+	sendErr error
+}
+
+//newFakeBTCService returns a service with utxos of amounts paying script, the
+//outputs of one tx it also serves
+func newFakeBTCService(script []byte, amounts ...uint64) *fakeBTCService {
+	tx := &btc.TX{Txin: []*btc.TXin{{Hash: make([]byte, 32), Sequence: 0xffffffff, PrevScriptPubkey: script}}}
+	for _, amount := range amounts {
+		tx.Txout = append(tx.Txout, &btc.TXout{Value: amount, ScriptPubkey: script})
+	}
+	rawtx, _ := tx.MakeUnsignedTX()
+	s := &fakeBTCService{txs: map[string][]byte{hex.EncodeToString(tx.TXID()): rawtx}}
+	for i, amount := range amounts {
+		s.utxos = append(s.utxos, &btc.UTXO{Hash: tx.TXID(), Index: uint32(i), Amount: amount, Script: script})
+	}
+	return s
+}
+
+func (s *fakeBTCService) GetServiceName() string {
+	return "fakeBTCService"
+}
+
+func (s *fakeBTCService) GetUTXO(addr string, key *btc.Key) (btc.UTXOs, error) {
+	return s.utxos, nil
+}
+
+func (s *fakeBTCService) SendTX(data []byte) ([]byte, error) {
+	if s.sendErr != nil {
+		return nil, s.sendErr
+	}
+	tx, err := btc.ParseTX(data)
+	if err != nil {
+		return nil, err
+	}
+	s.sent = append(s.sent, data)
+	return tx.TXID(), nil
+}
+
+func (s *fakeBTCService) GetRawTX(txid []byte) ([]byte, error) {
+	rawtx, ok := s.txs[hex.EncodeToString(txid)]
+	if !ok {
+		return nil, fmt.Errorf("tx %x not found", txid)
+	}
+	return rawtx, nil
+}
+
+//parseBTCPSBT parses the PSBT returned by GetTX, with its unsigned tx in hex
+func parseBTCPSBT(t *testing.T, s string) (*btc.PSBT, string) {
+	p, err := btc.ParsePSBTBase64(s)
+	if err != nil {
+		t.Fatalf("parse psbt %s: %v", s, err)
+	}
+	rawtx, err := p.TX.MakeUnsignedTX()
+	if err != nil {
+		t.Fatalf("unsigned tx of psbt: %v", err)
+	}
+	return p, hex.EncodeToString(rawtx)
+}
+
+func TestBTCTX(t *testing.T) {
+	seed := getSeed()
+	masterpub := hdwallet.MasterKey(seed).Pub()
+	fromPub, _ := masterpub.Child(1)
+	toPub, _ := masterpub.Child(2)
+	fromAddr, toAddr := genBTCAddr(fromPub.Key, false), genBTCAddr(toPub.Key, false)
+	fromScript, _ := btc.CreateP2PKHScriptPubkey(fromAddr)
+	toScript, _ := btc.CreateP2PKHScriptPubkey(toAddr)
+	fromKey := &btc.KeyDerivation{PubKey: fromPub.Key, Fingerprint: []byte{0xde, 0xad, 0xbe, 0xef}, Path: []uint32{44 + 1<<31, 1 << 31, 1 << 31, 0, 1}}
+
+	fake := newFakeBTCService(fromScript, 30000, 500000, 20000)
+	service := btc.NewUTXOCache(fake, 0, 0)
+	psbt, err := getBTCTX(service, fake, btc.LargestFirst{}, btc.DefaultFeeRate, fromKey, fromAddr, toAddr, 400000, 0)
+	if err != nil {
+		t.Fatalf("get btc tx error: %v", err)
+	}
+	p, txHex := parseBTCPSBT(t, psbt)
+	//only the largest utxo is needed to cover amount and fee
+	if !strings.HasPrefix(txHex, "0100000001") {
+		t.Errorf("btc tx should spend exactly one input: %s", txHex)
+	}
+	if !strings.Contains(txHex, "801a060000000000"+"19"+hex.EncodeToString(toScript)) {
+		t.Errorf("btc tx does not pay the receiver: %s", txHex)
+	}
+	//one input and two outputs at 10 satoshi per byte
+	fee := uint64(btc.TXOverheadSize+btc.P2PKHInputSize+2*btc.P2PKHOutputSize) * btc.DefaultFeeRate
+	if change := 500000 - 400000 - fee; change != 97740 || !strings.Contains(txHex, "cc7d010000000000"+"19"+hex.EncodeToString(fromScript)) {
+		t.Errorf("btc tx does not return change to the sender: %s", txHex)
+	}
+	//signers get the previous tx, the key origins and the script type
+	in := p.Inputs[0]
+	if in.NonWitnessUTXO == nil || in.WitnessUTXO != nil || in.ScriptType != "p2pkh" {
+		t.Errorf("btc psbt input %+v", in)
+	}
+	if !reflect.DeepEqual(in.Derivations, []*btc.KeyDerivation{fromKey}) || !reflect.DeepEqual(p.Outputs[1].Derivations, in.Derivations) {
+		t.Errorf("btc psbt key origins %+v, change %+v", in.Derivations, p.Outputs[1].Derivations)
+	}
+	if len(p.Outputs[0].Derivations) != 0 {
+		t.Errorf("btc psbt receiver is not change: %+v", p.Outputs[0].Derivations)
+	}
+
+	//the largest utxo is reserved by the first tx
+	_, err = getBTCTX(service, fake, btc.LargestFirst{}, btc.DefaultFeeRate, fromKey, fromAddr, toAddr, 400000, 0)
+	if e, ok := err.(*addrtx.AddrTXException); !ok || e.Code != addrtx.ErrorCode_INSUFFICIENT_FUNDS {
+		t.Errorf("btc tx should fail with insufficient funds: %v", err)
+	}
+	//inputs whose previous tx can not be fetched are released
+	txs := fake.txs
+	fake.txs = nil
+	_, err = getBTCTX(service, fake, btc.LargestFirst{}, btc.DefaultFeeRate, fromKey, fromAddr, toAddr, 30000, 0)
+	if e, ok := err.(*addrtx.AddrTXException); !ok || e.Code != addrtx.ErrorCode_UPSTREAM_UNAVAILABLE {
+		t.Errorf("btc tx should fail without previous txs: %v", err)
+	}
+	_, err = getBTCTX(service, nil, btc.LargestFirst{}, btc.DefaultFeeRate, fromKey, fromAddr, toAddr, 30000, 0)
+	if e, ok := err.(*addrtx.AddrTXException); !ok || e.Code != addrtx.ErrorCode_INTERNAL_ERROR {
+		t.Errorf("legacy btc tx should fail without a tx getter: %v", err)
+	}
+	fake.txs = txs
+	psbt, err = getBTCTX(service, fake, btc.LargestFirst{}, btc.DefaultFeeRate, fromKey, fromAddr, toAddr, 30000, 0)
+	if err != nil {
+		t.Fatalf("get btc tx error: %v", err)
+	}
+	if p, txHex = parseBTCPSBT(t, psbt); !strings.HasPrefix(txHex, "0100000002") || !bytes.Equal(p.Inputs[0].NonWitnessUTXO, p.Inputs[1].NonWitnessUTXO) {
+		t.Errorf("btc tx should spend the two unreserved inputs of one tx: %s", txHex)
+	}
+
+	//paying to a native segwit address
+	fake = newFakeBTCService(fromScript, 500000)
+	service = btc.NewUTXOCache(fake, 0, 0)
+	psbt, err = getBTCTX(service, fake, btc.LargestFirst{}, btc.DefaultFeeRate, fromKey, fromAddr, genP2WPKHAddr(toPub.Key, false), 400000, 0)
+	if _, txHex = parseBTCPSBT(t, psbt); err != nil || !strings.Contains(txHex, "801a060000000000"+"16"+"0014"+hex.EncodeToString(btc.Hash160(toPub.Key))) {
+		t.Errorf("btc tx does not pay the p2wpkh receiver: %s %v", txHex, err)
+	}
+
+	//segwit inputs carry the spent output, the previous tx when there is one
+	fromAddr = genP2WPKHAddr(fromPub.Key, false)
+	fromScript, _ = btc.AddressScript(fromAddr)
+	fake = newFakeBTCService(fromScript, 500000)
+	txs = fake.txs
+	for _, getter := range []btc.RawTXGetter{nil, fake} {
+		//a backend without the previous tx, like bitcoind without -txindex
+		fake.txs = nil
+		service = btc.NewUTXOCache(fake, 0, 0)
+		psbt, err = getBTCTX(service, getter, btc.LargestFirst{}, btc.DefaultFeeRate, fromKey, fromAddr, toAddr, 400000, 0)
+		if err != nil {
+			t.Fatalf("get p2wpkh btc tx error: %v", err)
+		}
+		p, _ = parseBTCPSBT(t, psbt)
+		if in := p.Inputs[0]; in.WitnessUTXO == nil || in.WitnessUTXO.Value != 500000 || in.NonWitnessUTXO != nil || in.ScriptType != "p2wpkh" {
+			t.Errorf("btc psbt p2wpkh input %+v", in)
+		}
+	}
+	fake.txs = txs
+	service = btc.NewUTXOCache(fake, 0, 0)
+	psbt, err = getBTCTX(service, fake, btc.LargestFirst{}, btc.DefaultFeeRate, fromKey, fromAddr, toAddr, 400000, 0)
+	if err != nil {
+		t.Fatalf("get p2wpkh btc tx error: %v", err)
+	}
+	if p, _ = parseBTCPSBT(t, psbt); p.Inputs[0].NonWitnessUTXO == nil || p.Inputs[0].WitnessUTXO == nil {
+		t.Errorf("btc psbt p2wpkh input lacks its previous tx %+v", p.Inputs[0])
+	}
+
+	//the same tx as a version 2 psbt
+	service = btc.NewUTXOCache(fake, 0, 0)
+	psbt, err = getBTCTX(service, fake, btc.LargestFirst{}, btc.DefaultFeeRate, fromKey, fromAddr, toAddr, 400000, 2)
+	if err != nil {
+		t.Fatalf("get btc psbt v2 error: %v", err)
+	}
+	if p2, _ := parseBTCPSBT(t, psbt); p2.Version != 2 || !bytes.Equal(p2.Inputs[0].SigHash, p.Inputs[0].SigHash) {
+		t.Errorf("btc psbt v2 %+v differs from v0", p2.Inputs[0])
+	}
+}
+
+func TestParseBIP32Path(t *testing.T) {
+	path, err := parseBIP32Path("m/84'/0h/0'/0/7")
+	if err != nil || !reflect.DeepEqual(path, []uint32{84 + 1<<31, 1 << 31, 1 << 31, 0, 7}) {
+		t.Errorf("parse bip32 path %v %v", path, err)
+	}
+	if path, err := parseBIP32Path("m"); err != nil || len(path) != 0 {
+		t.Errorf("parse master path %v %v", path, err)
+	}
+	for _, invalid := range []string{"", "84'/0'", "m/", "m/-1", "m/2147483648", "m/0''", "m/x"} {
+		if _, err := parseBIP32Path(invalid); err == nil {
+			t.Errorf("invalid bip32 path %q parsed", invalid)
+		}
+	}
+}